@@ -25,9 +25,14 @@
 package main
 
 import (
+	"container/list"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/vhive-serverless/loader/pkg/generator"
@@ -37,6 +42,8 @@ import (
 	"github.com/vhive-serverless/loader/pkg/common"
 	"github.com/vhive-serverless/loader/pkg/config"
 	"github.com/vhive-serverless/loader/pkg/driver"
+	"github.com/vhive-serverless/loader/pkg/driver/deployment"
+	"github.com/vhive-serverless/loader/pkg/synthesizer"
 	"github.com/vhive-serverless/loader/pkg/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -54,6 +61,8 @@ var (
 	iatGeneration = flag.Bool("iatGeneration", false, "Generate IATs only or run invocations as well")
 	iatFromFile   = flag.Bool("generated", false, "True if iats were already generated")
 	dryRun        = flag.Bool("dryRun", false, "Dry run mode - do not deploy functions or generate invocations")
+	runMode       = flag.String("runMode", "", "Run only some phases of the experiment - choose from [deploy-only, run-only, clean-only]")
+	manifestPath  = flag.String("endpointManifest", "", "Path to the endpoint manifest of the run modes (default <OutputPathPrefix>_endpoints.json)")
 )
 
 func init() {
@@ -76,6 +85,11 @@ func init() {
 }
 
 func main() {
+	if flag.NArg() > 0 {
+		runSubcommand(flag.Args())
+		return
+	}
+
 	cfg := config.ReadConfigurationFile(*configPath)
 	if cfg.EnableZipkinTracing {
 		// TODO: how not to exclude Zipkin spans here? - file a feature request
@@ -106,6 +120,37 @@ func main() {
 		common.CheckCPULimit(cfg.CPULimit)
 	}
 
+	if cfg.RunID == "" {
+		cfg.RunID = common.NewRunID()
+	}
+	if err := common.ValidateRunScope(cfg.FunctionNamePrefix, cfg.RunID); err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("Run ID: %s", cfg.RunID)
+
+	switch cfg.ReadinessPolicy {
+	case "", driver.AbortReadinessPolicy, driver.ExcludeReadinessPolicy:
+	default:
+		log.Fatal("Unsupported readiness policy.")
+	}
+
+	switch *runMode {
+	case "", driver.RunOnlyRunMode:
+	case driver.DeployOnlyRunMode:
+		if cfg.ReadinessPolicy == driver.ExcludeReadinessPolicy {
+			log.Fatal("Functions cannot be excluded in the deploy-only mode, as the experiment runs later on all of them.")
+		}
+	case driver.CleanOnlyRunMode:
+		driver.CleanDeployment(&config.Configuration{
+			LoaderConfiguration:  &cfg,
+			RunMode:              *runMode,
+			EndpointManifestPath: endpointManifestPath(&cfg),
+		})
+		return
+	default:
+		log.Fatal("Unsupported run mode.")
+	}
+
 	if cfg.TracePath == "RPS" {
 		runRPSMode(&cfg, *iatFromFile, *iatGeneration)
 	} else {
@@ -113,6 +158,14 @@ func main() {
 	}
 }
 
+func endpointManifestPath(cfg *config.LoaderConfiguration) string {
+	if *manifestPath != "" {
+		return *manifestPath
+	}
+
+	return cfg.OutputPathPrefix + "_endpoints.json"
+}
+
 func determineDurationToParse(runtimeDuration int, warmupDuration int) int {
 	result := 0
 
@@ -126,22 +179,39 @@ func determineDurationToParse(runtimeDuration int, warmupDuration int) int {
 }
 
 func parseIATDistribution(cfg *config.LoaderConfiguration) (common.IatDistribution, bool) {
-	switch cfg.IATDistribution {
-	case "exponential":
-		return common.Exponential, false
-	case "exponential_shift":
-		return common.Exponential, true
-	case "uniform":
-		return common.Uniform, false
-	case "uniform_shift":
-		return common.Uniform, true
-	case "equidistant":
-		return common.Equidistant, false
-	default:
+	iatDistribution, shiftIAT, err := common.ParseIATDistribution(cfg.IATDistribution)
+	if err != nil {
 		log.Fatal("Unsupported IAT distribution.")
 	}
 
-	return common.Exponential, false
+	return iatDistribution, shiftIAT
+}
+
+func parseExecutionSampling(cfg *config.LoaderConfiguration) common.ExecutionSampling {
+	executionSampling, err := common.ParseExecutionSampling(cfg.ExecutionSampling)
+	if err != nil {
+		log.Fatal("Unsupported execution sampling.")
+	}
+
+	return executionSampling
+}
+
+func parseTraceFormat(cfg *config.LoaderConfiguration) common.TraceFormat {
+	traceFormat, err := common.ParseTraceFormat(cfg.TraceFormat)
+	if err != nil {
+		log.Fatal("Unsupported trace format.")
+	}
+
+	return traceFormat
+}
+
+func parseSeedMode(cfg *config.LoaderConfiguration) common.SeedMode {
+	seedMode, err := common.ParseSeedMode(cfg.SeedMode)
+	if err != nil {
+		log.Fatal("Unsupported seed mode.")
+	}
+
+	return seedMode
 }
 
 func parseYAMLSpecification(cfg *config.LoaderConfiguration) string {
@@ -173,15 +243,99 @@ func parseTraceGranularity(cfg *config.LoaderConfiguration) common.TraceGranular
 }
 
 func runTraceMode(cfg *config.LoaderConfiguration, readIATFromFile bool, writeIATsToFile bool) {
+	experimentDriver := createTraceDriver(cfg)
+
+	// Skip experiments execution during dry run mode
+	if *dryRun {
+		return
+	}
+
+	log.Infof("Using %s as a service YAML specification file.\n", experimentDriver.Configuration.YAMLPath)
+
+	experimentDriver.GenerateSpecification()
+	experimentDriver.ReadOrWriteFileSpecification(writeIATsToFile, readIATFromFile)
+	experimentDriver.RunExperiment()
+}
+
+// parseAppMemoryStats reads the optional application memory statistics of the trace used for per-app deployment
+func parseAppMemoryStats(traceDirectory string, skipInvalidRows bool) map[string]*common.FunctionMemoryStats {
+	path := filepath.Join(traceDirectory, "app_memory.csv")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.Infof("No application memory statistics found in %s. The memory of the functions of an application will be summed up.", traceDirectory)
+		return nil
+	}
+
+	appMemoryStats, err := trace.ParseAppMemoryTrace(path, skipInvalidRows)
+	if err != nil {
+		log.Fatalf("Failed to parse the application memory statistics - %v", err)
+	}
+
+	return appMemoryStats
+}
+
+func createTraceDriver(cfg *config.LoaderConfiguration) *driver.Driver {
 	durationToParse := determineDurationToParse(cfg.ExperimentDuration, cfg.WarmupDuration)
 	yamlPath := parseYAMLSpecification(cfg)
 
-	// Azure trace parsing
-	traceParser := trace.NewAzureParser(cfg.TracePath, durationToParse)
-	functions := traceParser.Parse()
+	parserConfiguration := trace.ParserConfiguration{
+		Format:          parseTraceFormat(cfg),
+		Path:            cfg.TracePath,
+		Duration:        durationToParse,
+		StartMinute:     cfg.TraceStartMinute,
+		SkipInvalidRows: cfg.SkipInvalidTraceRows,
+	}
+
+	var traceParser trace.TraceParser
+	traceDirectory := cfg.TracePath
+	if cfg.ExactReplay {
+		if parseTraceFormat(cfg) != common.AzureTraceFormat {
+			log.Fatal("Exact replay supports only per-invocation traces in the Azure 2021 format.")
+		} else if parseTraceGranularity(cfg) != common.MinuteGranularity {
+			log.Fatal("Exact replay supports only the minute granularity.")
+		}
+
+		// Per-invocation trace parsing - the specification is taken from the trace as is
+		traceParser = trace.NewExactReplayParser(cfg.TracePath, durationToParse).WithStartMinute(cfg.TraceStartMinute)
+		traceDirectory = filepath.Dir(cfg.TracePath)
+	} else {
+		var err error
+
+		traceParser, err = trace.NewTraceParser(parserConfiguration)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	functions, err := traceParser.Parse()
+	if err != nil {
+		log.Fatalf("Failed to parse the trace - %v", err)
+	}
+
+	if cfg.TraceTransformPath != "" {
+		if cfg.ExactReplay {
+			log.Fatal("Trace transformations are not supported with exact replay.")
+		}
+
+		functions = transformTrace(cfg.TraceTransformPath, functions, parserConfiguration)
+	}
+
+	var workflows []*list.List
+	if cfg.WorkflowDefinitionPath != "" {
+		if !cfg.DAGMode {
+			log.Fatal("Workflow definitions require DAGMode to be enabled.")
+		} else if cfg.ExactReplay {
+			log.Fatal("Workflow definitions are not supported with exact replay.")
+		}
+
+		definitions := config.ReadWorkflowDefinitions(cfg.WorkflowDefinitionPath)
+		functions, workflows, err = generator.CreateWorkflows(definitions, functions, durationToParse)
+		if err != nil {
+			log.Fatalf("Failed to create the workflows - %v", err)
+		}
+	}
 
 	// Dirigent metadata parsing
-	dirigentMetadataParser := trace.NewDirigentMetadataParser(cfg.TracePath, functions, yamlPath, cfg.Platform)
+	dirigentMetadataParser := trace.NewDirigentMetadataParser(traceDirectory, functions, yamlPath, cfg.Platform)
 	dirigentMetadataParser.Parse()
 
 	log.Infof("Traces contain the following %d functions:\n", len(functions))
@@ -191,31 +345,39 @@ func runTraceMode(cfg *config.LoaderConfiguration, readIATFromFile bool, writeIA
 
 	iatType, shiftIAT := parseIATDistribution(cfg)
 
-	experimentDriver := driver.NewDriver(&config.Configuration{
+	var functionOverrides []common.FunctionOverride
+	if cfg.FunctionOverridesPath != "" {
+		functionOverrides = config.ReadFunctionOverrides(cfg.FunctionOverridesPath)
+	}
+
+	var appMemoryStats map[string]*common.FunctionMemoryStats
+	if cfg.DeployPerApp {
+		appMemoryStats = parseAppMemoryStats(traceDirectory, cfg.SkipInvalidTraceRows)
+	}
+
+	return driver.NewDriver(&config.Configuration{
 		LoaderConfiguration:  cfg,
 		FailureConfiguration: config.ReadFailureConfiguration(*failurePath),
 
-		IATDistribution:  iatType,
-		ShiftIAT:         shiftIAT,
-		TraceGranularity: parseTraceGranularity(cfg),
-		TraceDuration:    durationToParse,
+		IATDistribution:   iatType,
+		ShiftIAT:          shiftIAT,
+		TraceGranularity:  parseTraceGranularity(cfg),
+		ExecutionSampling: parseExecutionSampling(cfg),
+		SeedMode:          parseSeedMode(cfg),
+		TraceDuration:     durationToParse,
+
+		FunctionOverrides: functionOverrides,
+		AppMemoryStats:    appMemoryStats,
+		Workflows:         workflows,
 
 		YAMLPath: yamlPath,
 		TestMode: false,
 
+		RunMode:              *runMode,
+		EndpointManifestPath: endpointManifestPath(cfg),
+
 		Functions: functions,
 	})
-
-	// Skip experiments execution during dry run mode
-	if *dryRun {
-		return
-	}
-
-	log.Infof("Using %s as a service YAML specification file.\n", experimentDriver.Configuration.YAMLPath)
-
-	experimentDriver.GenerateSpecification()
-	experimentDriver.ReadOrWriteFileSpecification(writeIATsToFile, readIATFromFile)
-	experimentDriver.RunExperiment()
 }
 
 func runRPSMode(cfg *config.LoaderConfiguration, readIATFromFile bool, writeIATsToFile bool) {
@@ -236,6 +398,9 @@ func runRPSMode(cfg *config.LoaderConfiguration, readIATFromFile bool, writeIATs
 
 		YAMLPath: parseYAMLSpecification(cfg),
 
+		RunMode:              *runMode,
+		EndpointManifestPath: endpointManifestPath(cfg),
+
 		Functions: generator.CreateRPSFunctions(cfg, warmFunction, warmStartCount, coldFunctions, coldStartCount),
 	})
 
@@ -247,3 +412,298 @@ func runRPSMode(cfg *config.LoaderConfiguration, readIATFromFile bool, writeIATs
 	experimentDriver.ReadOrWriteFileSpecification(writeIATsToFile, readIATFromFile)
 	experimentDriver.RunExperiment()
 }
+
+func runSubcommand(args []string) {
+	switch {
+	case len(args) >= 2 && args[0] == "trace" && args[1] == "sample":
+		runTraceSampleCommand(args[2:])
+	case len(args) >= 2 && args[0] == "trace" && args[1] == "synthesize":
+		runTraceSynthesizeCommand(args[2:])
+	case len(args) >= 2 && args[0] == "trace" && args[1] == "import":
+		runTraceImportCommand(args[2:])
+	case len(args) >= 2 && args[0] == "trace" && args[1] == "stats":
+		runTraceStatsCommand(args[2:])
+	case len(args) >= 2 && args[0] == "trace" && args[1] == "transform":
+		runTraceTransformCommand(args[2:])
+	case len(args) >= 1 && args[0] == "validate-spec":
+		runValidateSpecCommand(args[1:])
+	case len(args) >= 2 && args[0] == "runs" && args[1] == "list":
+		runRunsListCommand(args[2:])
+	case len(args) >= 2 && args[0] == "runs" && args[1] == "clean":
+		runRunsCleanCommand(args[2:])
+	default:
+		log.Fatalf("Unknown command '%s'.", strings.Join(args, " "))
+	}
+}
+
+func runTraceSampleCommand(args []string) {
+	flags := flag.NewFlagSet("trace sample", flag.ExitOnError)
+	tracePath := flags.String("trace", "data/traces/example", "Path to the Azure trace directory to sample from")
+	outputPath := flags.String("output", "data/traces/sample", "Path to the directory the sampled trace is written to")
+	duration := flags.Int("duration", 1440, "Number of trace minutes to consider")
+	functionCount := flags.Int("functions", 0, "Number of functions to sample")
+	targetRPS := flags.Float64("rps", 0, "Target total RPS of the sample, used if the number of functions is not set")
+	memoryBudget := flags.Float64("memoryBudget", 0, "Upper bound on the estimated cluster memory in MiB needed by the sample")
+	trials := flags.Int("trials", 16, "Number of random samples out of which the closest to the original trace is kept")
+	seed := flags.Int64("seed", 42, "Seed of the sampler")
+	_ = flags.Parse(args)
+
+	report, err := trace.SampleTrace(*tracePath, *outputPath, *duration, trace.SamplerConfiguration{
+		FunctionCount:   *functionCount,
+		TargetRPS:       *targetRPS,
+		MemoryBudgetMiB: *memoryBudget,
+		Trials:          *trials,
+		Seed:            *seed,
+	})
+	if err != nil {
+		log.Fatalf("Failed to sample the trace - %v", err)
+	}
+
+	log.Infof("Sampled %d out of %d functions into %s.", report.SampledFunctions, report.OriginalFunctions, *outputPath)
+	log.Infof("RPS: %.2f (original: %.2f), memory: %.0f MiB (original: %.0f MiB)",
+		report.SampledRPS, report.OriginalRPS, report.SampledMemoryMiB, report.OriginalMemoryMiB)
+	log.Infof("KS distance - invocation rate: %.3f, runtime: %.3f, memory: %.3f",
+		report.Distance.InvocationRate, report.Distance.Runtime, report.Distance.Memory)
+}
+
+func runTraceSynthesizeCommand(args []string) {
+	flags := flag.NewFlagSet("trace synthesize", flag.ExitOnError)
+	specPath := flags.String("spec", "cmd/synthesizer_spec.json", "Path to the synthetic workload specification")
+	outputPath := flags.String("output", "data/traces/synthetic", "Path to the directory the synthetic trace is written to")
+	_ = flags.Parse(args)
+
+	spec, err := synthesizer.ReadSpecification(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read the synthetic workload specification - %v", err)
+	}
+
+	if err = synthesizer.SynthesizeTrace(spec, *outputPath); err != nil {
+		log.Fatalf("Failed to synthesize the trace - %v", err)
+	}
+
+	log.Infof("Synthetic trace written to %s.", *outputPath)
+}
+
+func runTraceImportCommand(args []string) {
+	flags := flag.NewFlagSet("trace import", flag.ExitOnError)
+	format := flags.String("format", trace.Azure2019Format, "Format of the raw dataset - choose from [azure2019, azure2021]")
+	inputPath := flags.String("input", "", "Directory with the raw 2019 dataset files or path to the 2021 invocation trace")
+	outputPath := flags.String("output", "data/traces/imported", "Path to the directory the trace is written to")
+	days := flags.String("days", "", "Comma-separated days of the 2019 dataset to import, all by default")
+	memory := flags.Float64("memory", 128, "Memory in MiB of the functions of the 2021 dataset, which contains no memory information")
+	_ = flags.Parse(args)
+
+	var selectedDays []int
+	for _, day := range strings.Split(*days, ",") {
+		if day = strings.TrimSpace(day); day == "" {
+			continue
+		}
+
+		number, err := strconv.Atoi(day)
+		if err != nil || number < 1 {
+			log.Fatalf("Invalid day '%s'.", day)
+		}
+		selectedDays = append(selectedDays, number)
+	}
+
+	err := trace.ImportAzureTrace(trace.ImportConfiguration{
+		Format:    *format,
+		InputPath: *inputPath,
+		Days:      selectedDays,
+		MemoryMiB: *memory,
+	}, *outputPath)
+	if err != nil {
+		log.Fatalf("Failed to import the dataset - %v", err)
+	}
+
+	log.Infof("Trace written to %s.", *outputPath)
+}
+
+func transformTrace(pipelinePath string, functions []*common.Function, parserConfiguration trace.ParserConfiguration) []*common.Function {
+	pipeline, err := trace.ReadTransformPipeline(pipelinePath)
+	if err != nil {
+		log.Fatalf("Failed to read the trace transform pipeline - %v", err)
+	}
+
+	functions, err = pipeline.Apply(functions, parserConfiguration)
+	if err != nil {
+		log.Fatalf("Failed to transform the trace - %v", err)
+	}
+
+	return functions
+}
+
+func runTraceTransformCommand(args []string) {
+	flags := flag.NewFlagSet("trace transform", flag.ExitOnError)
+	tracePath := flags.String("trace", "data/traces/example", "Path to the trace directory")
+	traceFormat := flags.String("format", "azure", "Format of the trace - choose from [azure, huawei, alibaba]")
+	duration := flags.Int("duration", 1440, "Number of trace minutes to consider")
+	startMinute := flags.Int("startMinute", 0, "Minute of the trace the window starts at")
+	pipelinePath := flags.String("pipeline", "cmd/trace_transform.json", "Path to the transform pipeline")
+	outputPath := flags.String("output", "data/traces/transformed", "Path to the directory the transformed trace is written to")
+	_ = flags.Parse(args)
+
+	format, err := common.ParseTraceFormat(*traceFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	parserConfiguration := trace.ParserConfiguration{
+		Format:      format,
+		Path:        *tracePath,
+		Duration:    *duration,
+		StartMinute: *startMinute,
+	}
+
+	parser, err := trace.NewTraceParser(parserConfiguration)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	functions, err := parser.Parse()
+	if err != nil {
+		log.Fatalf("Failed to parse the trace - %v", err)
+	}
+
+	functions = transformTrace(*pipelinePath, functions, parserConfiguration)
+
+	if err = trace.WriteAzureTrace(*outputPath, functions); err != nil {
+		log.Fatalf("Failed to write the transformed trace - %v", err)
+	}
+
+	log.Infof("Transformed trace with %d functions written to %s.", len(functions), *outputPath)
+}
+
+func runTraceStatsCommand(args []string) {
+	flags := flag.NewFlagSet("trace stats", flag.ExitOnError)
+	tracePath := flags.String("trace", "data/traces/example", "Path to the trace directory")
+	traceFormat := flags.String("format", "azure", "Format of the trace - choose from [azure, huawei, alibaba]")
+	duration := flags.Int("duration", 1440, "Number of trace minutes to consider")
+	startMinute := flags.Int("startMinute", 0, "Minute of the trace the window starts at")
+	cpuLimit := flags.String("cpuLimit", common.CPULimit1vCPU, "CPU limit mapping the resources are estimated with - choose from [1vCPU, GCP]")
+	output := flags.String("output", "table", "Output format - choose from [table, json]")
+	_ = flags.Parse(args)
+
+	common.CheckCPULimit(*cpuLimit)
+
+	format, err := common.ParseTraceFormat(*traceFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	parser, err := trace.NewTraceParser(trace.ParserConfiguration{
+		Format:      format,
+		Path:        *tracePath,
+		Duration:    *duration,
+		StartMinute: *startMinute,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	functions, err := parser.Parse()
+	if err != nil {
+		log.Fatalf("Failed to parse the trace - %v", err)
+	}
+
+	statistics := trace.ComputeTraceStatistics(functions, *cpuLimit)
+
+	switch *output {
+	case "table":
+		err = statistics.WriteTable(os.Stdout)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(statistics)
+	default:
+		log.Fatalf("Unsupported output format '%s'.", *output)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to write the trace statistics - %v", err)
+	}
+}
+
+func runValidateSpecCommand(args []string) {
+	flags := flag.NewFlagSet("validate-spec", flag.ExitOnError)
+	validatedConfigPath := flags.String("config", *configPath, "Path to loader configuration file")
+	reportPath := flags.String("report", "validation_report.json", "Path to the validation report")
+	generated := flags.Bool("generated", false, "Validate the previously generated workload specification instead of generating a new one")
+	alpha := flags.Float64("alpha", 0.01, "Family-wise significance level of the IAT distribution tests")
+	tolerance := flags.Float64("tolerance", 0.02, "Tolerated error of the runtime and memory percentiles on top of the sampling noise")
+	minSamples := flags.Int("minSamples", 100, "Minimum number of samples for a statistical check to be performed")
+	_ = flags.Parse(args)
+
+	cfg := config.ReadConfigurationFile(*validatedConfigPath)
+	if cfg.TracePath == "RPS" {
+		log.Fatal("Only trace-based workloads can be validated.")
+	}
+
+	experimentDriver := createTraceDriver(&cfg)
+	experimentDriver.GenerateSpecification()
+	experimentDriver.ReadOrWriteFileSpecification(false, *generated)
+
+	driverConfig := experimentDriver.Configuration
+	report := generator.ValidateSpecification(driverConfig.Functions, driverConfig.IATDistribution, driverConfig.ShiftIAT,
+		driverConfig.TraceGranularity, generator.ValidationConfiguration{
+			Alpha:               *alpha,
+			PercentileTolerance: *tolerance,
+			MinSamples:          *minSamples,
+			Seed:                cfg.Seed,
+		})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to serialize the validation report - %v", err)
+	}
+	if err = os.WriteFile(*reportPath, data, 0644); err != nil {
+		log.Fatalf("Failed to write the validation report - %v", err)
+	}
+
+	if !report.Passed {
+		for _, function := range report.Functions {
+			for _, failure := range function.Failures {
+				log.Warnf("%s: %s", function.Name, failure)
+			}
+		}
+
+		log.Errorf("Validation failed for %d out of %d functions. See %s.", report.FailedFunctions, len(report.Functions), *reportPath)
+		os.Exit(1)
+	}
+
+	log.Infof("Specification of all %d functions has been validated. See %s.", len(report.Functions), *reportPath)
+}
+
+func runRunsListCommand(args []string) {
+	flags := flag.NewFlagSet("runs list", flag.ExitOnError)
+	namespace := flags.String("namespace", "", "Kubernetes namespace to look for Knative services in (default all namespaces)")
+	_ = flags.Parse(args)
+
+	runs, err := deployment.ListKnativeRuns(*namespace)
+	if err != nil {
+		log.Fatalf("Failed to list the runs - %v", err)
+	}
+
+	log.Infof("Found %d runs with Knative services on the cluster:", len(runs))
+	for _, run := range runs {
+		fmt.Printf("\t%s\t%d services\tcreated %s by %s\n", run.RunID, run.Services, run.Created, run.Owner)
+	}
+}
+
+func runRunsCleanCommand(args []string) {
+	flags := flag.NewFlagSet("runs clean", flag.ExitOnError)
+	runID := flags.String("run", "", "ID of the run whose Knative services are deleted")
+	namespace := flags.String("namespace", "", "Kubernetes namespace to delete Knative services from (default all namespaces)")
+	_ = flags.Parse(args)
+
+	if *runID == "" {
+		log.Fatal("The ID of the run to clean has to be set with -run.")
+	}
+
+	deleted, err := deployment.CleanKnativeRun(*namespace, *runID)
+	if err != nil {
+		log.Fatalf("Deleted %d Knative services of run %s, but failed to delete others - %v", deleted, *runID, err)
+	}
+
+	log.Infof("Deleted %d Knative services of run %s.", deleted, *runID)
+}
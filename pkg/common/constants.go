@@ -27,6 +27,7 @@ package common
 const (
 	FunctionNamePrefix      = "trace-func"
 	OneSecondInMicroseconds = 1_000_000.0
+	MinutesInADay           = 1440
 )
 
 const (
@@ -35,6 +36,9 @@ const (
 
 	// MaxExecTimeMilli 60s (avg. p96 from Wild)
 	MaxExecTimeMilli = 60e3
+
+	// MinExecTimeMicro 1µs - lower bound of runtimes sampled with sub-millisecond precision
+	MinExecTimeMicro = 1
 )
 
 const (
@@ -43,6 +47,9 @@ const (
 	MaxMemQuotaMib = 10_240
 	MinMemQuotaMib = 1
 
+	// DefaultTraceMemoryMiB Memory of the functions of traces that do not contain memory usage
+	DefaultTraceMemoryMiB = 128
+
 	// OvercommitmentRatio Machine overcommitment ratio to provide to CPU requests in YAML specification.
 	// Value taken from the Firecracker NSDI'20 paper.
 	OvercommitmentRatio = 10
@@ -56,6 +63,41 @@ const (
 	Equidistant
 )
 
+// ExecutionSampling determines how runtime and memory are sampled from the trace percentiles
+type ExecutionSampling int
+
+const (
+	// BucketSampling picks a percentile bucket and then a uniform integer inside of it
+	BucketSampling ExecutionSampling = iota
+	// LinearSampling samples a piecewise-linear inverse CDF built from the percentiles
+	LinearSampling
+	// SplineSampling samples a monotone cubic spline inverse CDF built from the percentiles
+	SplineSampling
+)
+
+// SeedMode determines how the random streams of the specification generator are seeded
+type SeedMode int
+
+const (
+	// LegacySeed seeds a single pair of streams with the global seed, consumed by all the functions in trace order
+	LegacySeed SeedMode = iota
+	// PerFunctionSeed derives the streams of each function from the global seed and the function hash, so that the
+	// specification of a function does not depend on the other functions in the trace
+	PerFunctionSeed
+)
+
+// TraceFormat determines the schema of the trace in TracePath
+type TraceFormat int
+
+const (
+	// AzureTraceFormat is the format of the Azure Functions 2019 trace, also written by the trace subcommands
+	AzureTraceFormat TraceFormat = iota
+	// HuaweiTraceFormat is the per-minute function trace of Huawei Cloud
+	HuaweiTraceFormat
+	// AlibabaTraceFormat is the per-minute microservice call rate and response time trace of Alibaba
+	AlibabaTraceFormat
+)
+
 type TraceGranularity int
 
 const (
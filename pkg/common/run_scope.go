@@ -0,0 +1,86 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package common
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+const (
+	// maxRunScopeLength Leaves room in the 63 characters of a Kubernetes name for the function index and the suffix
+	// of Knative revisions
+	maxRunScopeLength = 40
+
+	runIDAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+var (
+	functionNamePrefixRegex = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+	runIDRegex              = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// NewRunID returns an identifier of a run of the loader made of its start time and a random suffix, e.g.,
+// 20261019-143046-k3x9
+func NewRunID() string {
+	suffix := make([]byte, 4)
+	for i := range suffix {
+		suffix[i] = runIDAlphabet[rand.Intn(len(runIDAlphabet))]
+	}
+
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102-150405"), suffix)
+}
+
+// RunScope returns the prefix of the names of the functions deployed by a run of the loader
+func RunScope(prefix string, runID string) string {
+	if prefix == "" {
+		prefix = FunctionNamePrefix
+	}
+	if runID == "" {
+		return prefix
+	}
+
+	return prefix + "-" + runID
+}
+
+// RunScopedName returns the name the index-th function of a run is deployed under, e.g., trace-func-20261019-143046-k3x9-3
+func RunScopedName(scope string, index int) string {
+	return fmt.Sprintf("%s-%d", scope, index)
+}
+
+// ValidateRunScope checks that the names of the functions of a run are valid Kubernetes names
+func ValidateRunScope(prefix string, runID string) error {
+	if prefix != "" && !functionNamePrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("function name prefix '%s' must consist of lower case letters, digits and hyphens, and start with a letter", prefix)
+	} else if runID != "" && !runIDRegex.MatchString(runID) {
+		return fmt.Errorf("run ID '%s' must consist of lower case letters, digits and hyphens", runID)
+	} else if scope := RunScope(prefix, runID); len(scope) > maxRunScopeLength {
+		return fmt.Errorf("function names prefixed with '%s' are longer than %d characters", scope, maxRunScopeLength)
+	}
+
+	return nil
+}
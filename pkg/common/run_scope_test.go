@@ -0,0 +1,22 @@
+package common
+
+import "testing"
+
+func TestValidateRunScope(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		runID  string
+		valid  bool
+	}{
+		{prefix: "", runID: NewRunID(), valid: true},
+		{prefix: "alice", runID: "exp-1", valid: true},
+		{prefix: "Alice", runID: "exp-1", valid: false},
+		{prefix: "alice", runID: "exp_1", valid: false},
+		{prefix: "1alice", runID: "exp", valid: false},
+		{prefix: "a-very-long-prefix-of-the-team", runID: NewRunID(), valid: false},
+	} {
+		if err := ValidateRunScope(test.prefix, test.runID); (err == nil) != test.valid {
+			t.Errorf("Unexpected validation of prefix '%s' and run ID '%s' - %v", test.prefix, test.runID, err)
+		}
+	}
+}
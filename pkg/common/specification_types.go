@@ -24,6 +24,11 @@
 
 package common
 
+import (
+	"fmt"
+	"regexp"
+)
+
 // IATArray Hold the IATs of invocations for a particular function. Values in this array tells individual function driver
 // how much time to sleep before firing an invocation. First invocations should be fired right away after the start of
 // experiment, i.e., should typically have a IAT of 0.
@@ -35,6 +40,11 @@ type ProbabilisticDuration []float64
 type RuntimeSpecification struct {
 	Runtime int
 	Memory  int
+
+	// RuntimeMicroseconds Sampled runtime with sub-millisecond precision, set only by the continuous execution spec
+	// samplers. The functions are invoked with Runtime, as the invocation protocols carry milliseconds, so this is
+	// only used to validate the sampled distribution.
+	RuntimeMicroseconds int `json:",omitempty"`
 }
 
 type RuntimeSpecificationArray []RuntimeSpecification
@@ -45,3 +55,127 @@ type FunctionSpecification struct {
 	RawDuration          ProbabilisticDuration     `json:"RawDuration"`
 	RuntimeSpecification RuntimeSpecificationArray `json:"RuntimeSpecification"`
 }
+
+// FunctionOverride replaces the experiment-wide IAT distribution and the runtime/memory clamps for the functions it
+// matches. Zero values mean that the experiment-wide setting is kept.
+type FunctionOverride struct {
+	HashFunction string `json:"HashFunction"`
+	NamePattern  string `json:"NamePattern"`
+
+	IATDistribution string `json:"IATDistribution"`
+
+	MinRuntimeMilli int `json:"MinRuntimeMilli"`
+	MaxRuntimeMilli int `json:"MaxRuntimeMilli"`
+	MinMemoryMiB    int `json:"MinMemoryMiB"`
+	MaxMemoryMiB    int `json:"MaxMemoryMiB"`
+
+	// namePattern NamePattern as compiled by Compile
+	namePattern *regexp.Regexp
+}
+
+// Compile compiles NamePattern once, before the override is matched against the functions
+func (o *FunctionOverride) Compile() error {
+	if o.NamePattern == "" {
+		return nil
+	}
+
+	namePattern, err := regexp.Compile(o.NamePattern)
+	if err != nil {
+		return err
+	}
+
+	o.namePattern = namePattern
+	return nil
+}
+
+// Matches reports whether the override applies to the given function. HashFunction is compared exactly, while
+// NamePattern is a regular expression matched against the function name, which requires the override to be compiled.
+func (o *FunctionOverride) Matches(function *Function) bool {
+	if o.HashFunction != "" {
+		return function.InvocationStats != nil && function.InvocationStats.HashFunction == o.HashFunction
+	}
+
+	return o.namePattern != nil && o.namePattern.MatchString(function.Name)
+}
+
+// FindFunctionOverride returns the override for the given function. Overrides keyed by HashFunction take precedence
+// over the ones keyed by name pattern, and among equals the first one in the list wins.
+func FindFunctionOverride(function *Function, overrides []FunctionOverride) *FunctionOverride {
+	var byPattern *FunctionOverride
+
+	for i := 0; i < len(overrides); i++ {
+		if !overrides[i].Matches(function) {
+			continue
+		}
+
+		if overrides[i].HashFunction != "" {
+			return &overrides[i]
+		} else if byPattern == nil {
+			byPattern = &overrides[i]
+		}
+	}
+
+	return byPattern
+}
+
+// ParseIATDistribution converts the IAT distribution name from the configuration file into the distribution type
+// and whether the IATs should be shifted inside the minute
+func ParseIATDistribution(name string) (IatDistribution, bool, error) {
+	switch name {
+	case "exponential":
+		return Exponential, false, nil
+	case "exponential_shift":
+		return Exponential, true, nil
+	case "uniform":
+		return Uniform, false, nil
+	case "uniform_shift":
+		return Uniform, true, nil
+	case "equidistant":
+		return Equidistant, false, nil
+	default:
+		return Exponential, false, fmt.Errorf("unsupported IAT distribution '%s'", name)
+	}
+}
+
+// ParseSeedMode converts the seed mode name from the configuration file into its type. The empty string selects the
+// legacy mode.
+func ParseSeedMode(name string) (SeedMode, error) {
+	switch name {
+	case "", "legacy":
+		return LegacySeed, nil
+	case "per_function":
+		return PerFunctionSeed, nil
+	default:
+		return LegacySeed, fmt.Errorf("unsupported seed mode '%s'", name)
+	}
+}
+
+// ParseExecutionSampling converts the execution sampling name from the configuration file into its type. The empty
+// string selects the default bucket sampling.
+func ParseExecutionSampling(name string) (ExecutionSampling, error) {
+	switch name {
+	case "", "bucket":
+		return BucketSampling, nil
+	case "linear":
+		return LinearSampling, nil
+	case "spline":
+		return SplineSampling, nil
+	default:
+		return BucketSampling, fmt.Errorf("unsupported execution sampling '%s'", name)
+	}
+}
+
+// ParseTraceFormat converts the trace format name from the configuration file into its type. The empty string selects
+// the Azure format.
+func ParseTraceFormat(name string) (TraceFormat, error) {
+	switch name {
+	case "", "azure":
+		return AzureTraceFormat, nil
+	case "huawei":
+		return HuaweiTraceFormat, nil
+	case "alibaba":
+		return AlibabaTraceFormat, nil
+	default:
+		return AzureTraceFormat, fmt.Errorf("unsupported trace format '%s'", name)
+	}
+}
@@ -88,6 +88,11 @@ type DirigentMetadata struct {
 type Function struct {
 	Name     string
 	Endpoint string
+	// ServiceName Name of the service the function is deployed into if the functions of its application share one
+	// service, with Name telling the service which function to emulate. Empty if deployed as a service of its own.
+	ServiceName string
+	// TraceName Name of the function in the trace if it is deployed under a run-scoped name
+	TraceName string
 
 	// From the static trace profiler
 	InitialScale int
@@ -96,6 +101,9 @@ type Function struct {
 	RuntimeStats     *FunctionRuntimeStats
 	MemoryStats      *FunctionMemoryStats
 	DirigentMetadata *DirigentMetadata
+	// ExecutionStatsPeriods Runtime and memory statistics changing over the trace window, e.g., per day of a
+	// multi-day trace. Empty if RuntimeStats and MemoryStats apply to the whole window.
+	ExecutionStatsPeriods []ExecutionStatsPeriod
 
 	ColdStartBusyLoopMs int
 
@@ -103,12 +111,30 @@ type Function struct {
 	MemoryRequestsMiB int
 	CPULimitsMilli    int
 
+	// Override of the experiment-wide generation settings, if any
+	Override *FunctionOverride
+
 	Specification *FunctionSpecification
 }
 
+// ExecutionStatsPeriod holds the runtime and memory statistics in effect from FromMinute of the trace window on
+type ExecutionStatsPeriod struct {
+	FromMinute   int
+	RuntimeStats *FunctionRuntimeStats
+	MemoryStats  *FunctionMemoryStats
+}
+
 type Node struct {
+	// Name Name of the node in an explicitly defined workflow
+	Name     string
 	Function *Function
 	Branches []*list.List
 	Depth    int
 	DAG      string
+	// Edges Definitions of the edges to the Branches in an explicitly defined workflow
+	Edges []WorkflowEdge
+	// Parents Number of predecessors in an explicitly defined workflow, with several parents making the node a join
+	Parents int
+	// Quorum Number of parents that must take their edges to the join before a join is invoked
+	Quorum int
 }
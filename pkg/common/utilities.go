@@ -145,7 +145,12 @@ func GetName(function *Function) int {
 	if parts[0] == "test" {
 		return 0
 	}
-	functionId, err := strconv.Atoi(parts[2])
+	index := parts[2]
+	if function.TraceName != "" {
+		// run-scoped names end with the index of the function
+		index = parts[len(parts)-1]
+	}
+	functionId, err := strconv.Atoi(index)
 	if err != nil {
 		log.Fatal(err)
 	}
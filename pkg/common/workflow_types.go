@@ -0,0 +1,94 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package common
+
+// WorkflowDefinitions is the content of a workflow definition file
+type WorkflowDefinitions struct {
+	Workflows []WorkflowDefinition `json:"Workflows" yaml:"Workflows"`
+}
+
+// WorkflowDefinition describes a workflow (DAG) of functions. The workflow is invoked ArrivalRate times per minute of
+// the trace or, if ArrivalRate is zero, as often as the trace function bound to its root node.
+type WorkflowDefinition struct {
+	Name        string  `json:"Name" yaml:"Name"`
+	ArrivalRate float64 `json:"ArrivalRate" yaml:"ArrivalRate"`
+
+	Nodes []WorkflowNode `json:"Nodes" yaml:"Nodes"`
+	Edges []WorkflowEdge `json:"Edges" yaml:"Edges"`
+}
+
+// WorkflowNode is bound either to a function of the trace, by its HashFunction or name, or to an explicit runtime and
+// memory. A node with several parents is invoked once Quorum of them take their edges to it, or all of them if zero.
+type WorkflowNode struct {
+	Name     string `json:"Name" yaml:"Name"`
+	Function string `json:"Function" yaml:"Function"`
+
+	RuntimeMilli int `json:"RuntimeMilli" yaml:"RuntimeMilli"`
+	MemoryMiB    int `json:"MemoryMiB" yaml:"MemoryMiB"`
+
+	Quorum int `json:"Quorum" yaml:"Quorum"`
+}
+
+// WorkflowEdge invokes the node To once the node From completes, sending it either the response of From or a synthetic
+// payload of PayloadBytes, drawn from PayloadDistribution. The edge is only taken if the outcome of From meets Condition
+// and, for successful invocations, the response of From matches ResponseRegex, and then with Probability if set. Of the
+// edges of From sharing a Group, at most one is taken, each with its Probability.
+type WorkflowEdge struct {
+	From string `json:"From" yaml:"From"`
+	To   string `json:"To" yaml:"To"`
+
+	Condition     EdgeCondition `json:"Condition" yaml:"Condition"`
+	ResponseRegex string        `json:"ResponseRegex" yaml:"ResponseRegex"`
+	Probability   float64       `json:"Probability" yaml:"Probability"`
+	Group         string        `json:"Group" yaml:"Group"`
+
+	PayloadBytes        int                 `json:"PayloadBytes" yaml:"PayloadBytes"`
+	PayloadDistribution PayloadDistribution `json:"PayloadDistribution" yaml:"PayloadDistribution"`
+	ForwardResponse     bool                `json:"ForwardResponse" yaml:"ForwardResponse"`
+}
+
+type PayloadDistribution string
+
+const (
+	// ConstantPayload sends PayloadBytes
+	ConstantPayload PayloadDistribution = "constant"
+	// UniformPayload draws the size uniformly from [0, 2 * PayloadBytes]
+	UniformPayload PayloadDistribution = "uniform"
+	// ExponentialPayload draws the size from an exponential distribution with the mean PayloadBytes
+	ExponentialPayload PayloadDistribution = "exponential"
+)
+
+type EdgeCondition string
+
+const (
+	// SuccessCondition takes the edge if the parent succeeds, the default
+	SuccessCondition EdgeCondition = "success"
+	// FailureCondition takes the edge if the parent fails, including timeouts
+	FailureCondition EdgeCondition = "failure"
+	// TimeoutCondition takes the edge if the parent times out
+	TimeoutCondition EdgeCondition = "timeout"
+	// AlwaysCondition takes the edge whatever the outcome of the parent
+	AlwaysCondition EdgeCondition = "always"
+)
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"container/list"
+
 	"github.com/vhive-serverless/loader/pkg/common"
 )
 
@@ -11,13 +13,29 @@ type Configuration struct {
 	IATDistribution  common.IatDistribution
 	ShiftIAT         bool // shift the invocations inside minute
 	TraceGranularity common.TraceGranularity
+	// ExecutionSampling How runtime and memory are sampled from the trace percentiles
+	ExecutionSampling common.ExecutionSampling
+	// SeedMode Whether functions share the random streams of the specification generator
+	SeedMode common.SeedMode
 	// TraceDuration In minutes.
 	TraceDuration int
+	// FunctionOverrides Per-function replacements of IATDistribution/ShiftIAT and of the runtime/memory clamps
+	FunctionOverrides []common.FunctionOverride
+
+	// AppMemoryStats Memory statistics of the applications of the trace by HashApp, used with DeployPerApp
+	AppMemoryStats map[string]*common.FunctionMemoryStats
 
 	YAMLPath string
 	TestMode bool
 
+	// RunMode Phases of the experiment the loader runs - all of them if empty
+	RunMode string
+	// EndpointManifestPath Manifest of the deployment written in the deploy-only mode and read in the others
+	EndpointManifestPath string
+
 	Functions []*common.Function
+	// Workflows Lists of nodes of the explicitly defined workflows, invoked instead of generated DAGs
+	Workflows []*list.List
 }
 
 func (c *Configuration) WithWarmup() bool {
@@ -26,8 +26,11 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
+	"github.com/vhive-serverless/loader/pkg/common"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -47,6 +50,10 @@ type LoaderConfiguration struct {
 	YAMLSelector   string `json:"YAMLSelector"`
 	EndpointPort   int    `json:"EndpointPort"`
 
+	RunID              string `json:"RunID"`
+	FunctionNamePrefix string `json:"FunctionNamePrefix"`
+	Namespace          string `json:"Namespace"`
+
 	DirigentControlPlaneIP   string `json:"DirigentControlPlaneIP"`
 	BusyLoopOnSandboxStartup bool   `json:"BusyLoopOnSandboxStartup"`
 
@@ -65,28 +72,44 @@ type LoaderConfiguration struct {
 	RpsDataSizeMB               float64 `json:"RpsDataSizeMB"`
 	RpsFile                     string  `json:"RpsFile"`
 
-	TracePath          string `json:"TracePath"`
-	Granularity        string `json:"Granularity"`
-	OutputPathPrefix   string `json:"OutputPathPrefix"`
-	IATDistribution    string `json:"IATDistribution"`
-	CPULimit           string `json:"CPULimit"`
-	ExperimentDuration int    `json:"ExperimentDuration"`
-	WarmupDuration     int    `json:"WarmupDuration"`
-	PrepullMode        string `json:"PrepullMode"`
+	TracePath            string `json:"TracePath"`
+	TraceFormat          string `json:"TraceFormat"`
+	SkipInvalidTraceRows bool   `json:"SkipInvalidTraceRows"`
+	TraceTransformPath   string `json:"TraceTransformPath"`
+	TraceStartMinute     int    `json:"TraceStartMinute"`
+	ExactReplay          bool   `json:"ExactReplay"`
+	Granularity          string `json:"Granularity"`
+	OutputPathPrefix     string `json:"OutputPathPrefix"`
+	IATDistribution      string `json:"IATDistribution"`
+	CPULimit             string `json:"CPULimit"`
+	ExperimentDuration   int    `json:"ExperimentDuration"`
+	WarmupDuration       int    `json:"WarmupDuration"`
+	PrepullMode          string `json:"PrepullMode"`
+	DeployPerApp         bool   `json:"DeployPerApp"`
+
+	SeedMode                 string  `json:"SeedMode"`
+	WorkloadSpecPath         string  `json:"WorkloadSpecPath"`
+	FunctionOverridesPath    string  `json:"FunctionOverridesPath"`
+	ExecutionSampling        string  `json:"ExecutionSampling"`
+	RuntimeMemoryCorrelation float64 `json:"RuntimeMemoryCorrelation"`
 
 	IsPartiallyPanic            bool   `json:"IsPartiallyPanic"`
 	EnableZipkinTracing         bool   `json:"EnableZipkinTracing"`
 	EnableMetricsScrapping      bool   `json:"EnableMetricsScrapping"`
 	MetricScrapingPeriodSeconds int    `json:"MetricScrapingPeriodSeconds"`
 	AutoscalingMetric           string `json:"AutoscalingMetric"`
-
-	GRPCConnectionTimeoutSeconds int  `json:"GRPCConnectionTimeoutSeconds"`
-	GRPCFunctionTimeoutSeconds   int  `json:"GRPCFunctionTimeoutSeconds"`
-	DAGMode                      bool `json:"DAGMode"`
-	EnableDAGDataset             bool `json:"EnableDAGDataset"`
-	Width                        int  `json:"Width"`
-	Depth                        int  `json:"Depth"`
-	VSwarm                       bool `json:"VSwarm"`
+	KnativeReadyTimeoutSeconds  int    `json:"KnativeReadyTimeoutSeconds"`
+	ReadinessTimeoutSeconds     int    `json:"ReadinessTimeoutSeconds"`
+	ReadinessPolicy             string `json:"ReadinessPolicy"`
+
+	GRPCConnectionTimeoutSeconds int    `json:"GRPCConnectionTimeoutSeconds"`
+	GRPCFunctionTimeoutSeconds   int    `json:"GRPCFunctionTimeoutSeconds"`
+	DAGMode                      bool   `json:"DAGMode"`
+	EnableDAGDataset             bool   `json:"EnableDAGDataset"`
+	Width                        int    `json:"Width"`
+	Depth                        int    `json:"Depth"`
+	WorkflowDefinitionPath       string `json:"WorkflowDefinitionPath"`
+	VSwarm                       bool   `json:"VSwarm"`
 }
 
 func ReadConfigurationFile(path string) LoaderConfiguration {
@@ -121,3 +144,61 @@ func ReadFailureConfiguration(path string) *FailureConfiguration {
 
 	return &config
 }
+
+func ReadFunctionOverrides(path string) []common.FunctionOverride {
+	byteValue, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var overrides []common.FunctionOverride
+	err = json.Unmarshal(byteValue, &overrides)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := range overrides {
+		override := &overrides[i]
+		if override.HashFunction == "" && override.NamePattern == "" {
+			log.Fatalf("Function override %d has neither HashFunction nor NamePattern set.", i)
+		}
+
+		if err = override.Compile(); err != nil {
+			log.Fatalf("Invalid name pattern of function override %d - %v", i, err)
+		}
+
+		if override.IATDistribution != "" {
+			if _, _, err = common.ParseIATDistribution(override.IATDistribution); err != nil {
+				log.Fatalf("Invalid IAT distribution of function override %d - %v", i, err)
+			}
+		}
+
+		if err = validateOverrideBounds(override.MinRuntimeMilli, override.MaxRuntimeMilli, common.MaxExecTimeMilli); err != nil {
+			log.Fatalf("Invalid runtime bounds of function override %d - %v", i, err)
+		}
+		if err = validateOverrideBounds(override.MinMemoryMiB, override.MaxMemoryMiB, common.MaxMemQuotaMib); err != nil {
+			log.Fatalf("Invalid memory bounds of function override %d - %v", i, err)
+		}
+	}
+
+	log.Infof("Read %d function override(s) from %s.", len(overrides), path)
+
+	return overrides
+}
+
+// validateOverrideBounds checks the lower bound of an override against its upper bound, or against the global upper
+// bound if the override keeps it, as the specification would otherwise be capped below the requested lower bound
+func validateOverrideBounds(lower int, upper int, globalUpper int) error {
+	if lower < 0 || upper < 0 {
+		return fmt.Errorf("bounds must not be negative")
+	}
+
+	if upper == 0 {
+		upper = globalUpper
+	}
+	if lower > upper {
+		return fmt.Errorf("lower bound %d is greater than the upper bound %d", lower, upper)
+	}
+
+	return nil
+}
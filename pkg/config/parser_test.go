@@ -29,6 +29,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
 )
 
 func TestConfigParser(t *testing.T) {
@@ -70,3 +72,47 @@ func TestConfigParser(t *testing.T) {
 		t.Error("Unexpected configuration read.")
 	}
 }
+
+func TestFunctionOverridesParser(t *testing.T) {
+	overrides := ReadFunctionOverrides("test_overrides.json")
+
+	if len(overrides) != 2 {
+		t.Fatal("Unexpected number of function overrides read.")
+	}
+
+	if overrides[0].HashFunction != "c13acdc7567b225971cef2416a3a2b03c8a4d8d154df48afe75834e2f5c59ddf" ||
+		overrides[0].IATDistribution != "equidistant" ||
+		overrides[0].MaxRuntimeMilli != 1000 ||
+		overrides[1].NamePattern != "^trace-func-1-" ||
+		overrides[1].IATDistribution != "exponential_shift" ||
+		overrides[1].MinMemoryMiB != 128 ||
+		overrides[1].MaxMemoryMiB != 512 {
+
+		t.Error("Unexpected function overrides read.")
+	}
+
+	if !overrides[1].Matches(&common.Function{Name: "trace-func-1-42"}) || overrides[1].Matches(&common.Function{Name: "trace-func-2-42"}) {
+		t.Error("Name pattern of the function override is not matched as read.")
+	}
+}
+
+func TestValidateOverrideBounds(t *testing.T) {
+	tests := []struct {
+		lower, upper int
+		valid        bool
+	}{
+		{lower: 0, upper: 0, valid: true},
+		{lower: 10, upper: 20, valid: true},
+		{lower: 20, upper: 10, valid: false},
+		{lower: 100, upper: 0, valid: true},
+		// lower bound above the global upper bound the override keeps
+		{lower: 2000, upper: 0, valid: false},
+		{lower: -1, upper: 0, valid: false},
+	}
+
+	for _, test := range tests {
+		if err := validateOverrideBounds(test.lower, test.upper, 1000); (err == nil) != test.valid {
+			t.Errorf("Bounds [%d, %d] validated as %v - %v.", test.lower, test.upper, err == nil, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadWorkflowDefinitions reads the workflow definitions from a JSON file or, if the extension is .yaml or .yml, a
+// YAML file
+func ReadWorkflowDefinitions(path string) []common.WorkflowDefinition {
+	byteValue, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var definitions common.WorkflowDefinitions
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(byteValue, &definitions)
+	default:
+		err = json.Unmarshal(byteValue, &definitions)
+	}
+	if err != nil {
+		log.Fatalf("Failed to parse the workflow definitions - %v", err)
+	}
+
+	if err = validateWorkflowDefinitions(definitions.Workflows); err != nil {
+		log.Fatalf("Invalid workflow definitions in %s - %v", path, err)
+	}
+
+	log.Infof("Read %d workflow(s) from %s.", len(definitions.Workflows), path)
+
+	return definitions.Workflows
+}
+
+func validateWorkflowDefinitions(workflows []common.WorkflowDefinition) error {
+	if len(workflows) == 0 {
+		return fmt.Errorf("no workflows defined")
+	}
+
+	names := make(map[string]bool)
+	for i, workflow := range workflows {
+		if workflow.Name == "" {
+			return fmt.Errorf("workflow %d has no name", i)
+		} else if names[workflow.Name] {
+			return fmt.Errorf("workflow %s is defined more than once", workflow.Name)
+		}
+		names[workflow.Name] = true
+
+		if err := validateWorkflowDefinition(&workflow); err != nil {
+			return fmt.Errorf("workflow %s: %v", workflow.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateWorkflowDefinition(workflow *common.WorkflowDefinition) error {
+	if workflow.ArrivalRate < 0 {
+		return fmt.Errorf("negative arrival rate")
+	} else if len(workflow.Nodes) == 0 {
+		return fmt.Errorf("no nodes defined")
+	}
+
+	nodes := make(map[string]*common.WorkflowNode)
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+
+		switch {
+		case node.Name == "":
+			return fmt.Errorf("node %d has no name", i)
+		case nodes[node.Name] != nil:
+			return fmt.Errorf("node %s is defined more than once", node.Name)
+		case (node.Function == "") == (node.RuntimeMilli <= 0):
+			return fmt.Errorf("node %s must be bound either to a trace function or to a positive runtime", node.Name)
+		case node.Function != "" && node.MemoryMiB != 0:
+			return fmt.Errorf("node %s bound to a trace function cannot set the memory", node.Name)
+		case node.MemoryMiB < 0:
+			return fmt.Errorf("node %s has a negative memory", node.Name)
+		case node.Quorum < 0:
+			return fmt.Errorf("node %s has a negative quorum", node.Name)
+		}
+
+		nodes[node.Name] = node
+	}
+
+	children := make(map[string][]string)
+	parents := make(map[string]int)
+	edges := make(map[[2]string]bool)
+	groups := make(map[[2]string]float64)
+	for _, edge := range workflow.Edges {
+		if nodes[edge.From] == nil || nodes[edge.To] == nil {
+			return fmt.Errorf("edge %s -> %s refers to an undefined node", edge.From, edge.To)
+		} else if edges[[2]string{edge.From, edge.To}] {
+			return fmt.Errorf("edge %s -> %s is defined more than once", edge.From, edge.To)
+		}
+		edges[[2]string{edge.From, edge.To}] = true
+
+		if err := validateEdgePayload(&edge); err != nil {
+			return fmt.Errorf("edge %s -> %s: %v", edge.From, edge.To, err)
+		} else if err = validateEdgeCondition(&edge); err != nil {
+			return fmt.Errorf("edge %s -> %s: %v", edge.From, edge.To, err)
+		}
+		if edge.Group != "" {
+			groups[[2]string{edge.From, edge.Group}] += edge.Probability
+		}
+
+		parents[edge.To]++
+		children[edge.From] = append(children[edge.From], edge.To)
+	}
+
+	for group, probability := range groups {
+		if probability > 1+1e-9 {
+			return fmt.Errorf("probabilities of the edges of node %s in group %s sum up to more than 1", group[0], group[1])
+		}
+	}
+
+	for _, node := range workflow.Nodes {
+		if node.Quorum > parents[node.Name] {
+			return fmt.Errorf("quorum %d of node %s exceeds its %d parent(s)", node.Quorum, node.Name, parents[node.Name])
+		}
+	}
+
+	var roots []string
+	for _, node := range workflow.Nodes {
+		if parents[node.Name] == 0 {
+			roots = append(roots, node.Name)
+		}
+	}
+	if len(roots) != 1 {
+		return fmt.Errorf("expected exactly one node without parents, got %d", len(roots))
+	}
+
+	if workflow.ArrivalRate == 0 && nodes[roots[0]].Function == "" {
+		return fmt.Errorf("arrival rate not set and root node %s not bound to a trace function", roots[0])
+	}
+
+	// topological sort from the only root, which does not reach the nodes on cycles or behind them
+	remaining := make(map[string]int)
+	for name, count := range parents {
+		remaining[name] = count
+	}
+
+	sorted := 0
+	queue := []string{roots[0]}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			if remaining[child]--; remaining[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+		queue = queue[1:]
+		sorted++
+	}
+	if sorted != len(workflow.Nodes) {
+		return fmt.Errorf("the edges contain a cycle")
+	}
+
+	return nil
+}
+
+func validateEdgePayload(edge *common.WorkflowEdge) error {
+	switch edge.PayloadDistribution {
+	case "", common.ConstantPayload, common.UniformPayload, common.ExponentialPayload:
+	default:
+		return fmt.Errorf("unknown payload distribution '%s'", edge.PayloadDistribution)
+	}
+
+	if edge.PayloadBytes < 0 {
+		return fmt.Errorf("negative payload size")
+	} else if edge.ForwardResponse && (edge.PayloadBytes > 0 || edge.PayloadDistribution != "") {
+		return fmt.Errorf("a synthetic payload cannot be combined with forwarding the response")
+	}
+
+	return nil
+}
+
+func validateEdgeCondition(edge *common.WorkflowEdge) error {
+	switch edge.Condition {
+	case "", common.SuccessCondition, common.FailureCondition, common.TimeoutCondition, common.AlwaysCondition:
+	default:
+		return fmt.Errorf("unknown condition '%s'", edge.Condition)
+	}
+
+	if edge.ResponseRegex != "" {
+		if edge.Condition != "" && edge.Condition != common.SuccessCondition {
+			return fmt.Errorf("a response regex requires the success condition")
+		} else if _, err := regexp.Compile(edge.ResponseRegex); err != nil {
+			return fmt.Errorf("invalid response regex - %v", err)
+		}
+	}
+
+	if edge.Probability < 0 || edge.Probability > 1 {
+		return fmt.Errorf("probability %g not in [0, 1]", edge.Probability)
+	} else if edge.Group != "" && edge.Probability == 0 {
+		return fmt.Errorf("edges in a group require a probability")
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestWorkflowDefinitionsParser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflows.yaml")
+	content := `
+Workflows:
+  - Name: etl
+    ArrivalRate: 2.5
+    Nodes:
+      - Name: extract
+        Function: c13acdc7567b225971cef2416a3a2b03c8a4d8d154df48afe75834e2f5c59ddf
+      - Name: load
+        RuntimeMilli: 100
+        MemoryMiB: 256
+    Edges:
+      - From: extract
+        To: load
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows := ReadWorkflowDefinitions(path)
+	if len(workflows) != 1 || workflows[0].Name != "etl" || workflows[0].ArrivalRate != 2.5 ||
+		len(workflows[0].Nodes) != 2 || workflows[0].Nodes[1].MemoryMiB != 256 ||
+		len(workflows[0].Edges) != 1 || workflows[0].Edges[0].To != "load" {
+
+		t.Errorf("Unexpected workflow definitions read: %+v", workflows)
+	}
+
+	if len(ReadWorkflowDefinitions("../../cmd/workflows.json")) != 3 {
+		t.Error("Unexpected number of workflows in the example definitions.")
+	}
+}
+
+func TestWorkflowDefinitionsValidation(t *testing.T) {
+	nodes := []common.WorkflowNode{
+		{Name: "a", Function: "f"},
+		{Name: "b", RuntimeMilli: 10},
+		{Name: "c", RuntimeMilli: 10},
+	}
+
+	edges := func(nodes ...string) []common.WorkflowEdge {
+		var result []common.WorkflowEdge
+		for i := 0; i < len(nodes); i += 2 {
+			result = append(result, common.WorkflowEdge{From: nodes[i], To: nodes[i+1]})
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		workflow common.WorkflowDefinition
+		err      string
+	}{
+		{
+			name:     "valid",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "b", "a", "c")},
+		},
+		{
+			name:     "unbound_node",
+			workflow: common.WorkflowDefinition{Name: "w", ArrivalRate: 1, Nodes: []common.WorkflowNode{{Name: "a"}}},
+			err:      "must be bound",
+		},
+		{
+			name:     "undefined_node",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "d")},
+			err:      "undefined node",
+		},
+		{
+			name:     "join",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "b", "a", "c", "b", "c")},
+		},
+		{
+			name: "quorum",
+			workflow: common.WorkflowDefinition{Name: "w", Edges: edges("a", "b", "a", "c", "b", "c"), Nodes: []common.WorkflowNode{
+				{Name: "a", Function: "f"}, {Name: "b", RuntimeMilli: 10}, {Name: "c", RuntimeMilli: 10, Quorum: 3},
+			}},
+			err: "exceeds its 2 parent(s)",
+		},
+		{
+			name:     "duplicate_edge",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "b", "a", "c", "a", "c")},
+			err:      "more than once",
+		},
+		{
+			name:     "multiple_roots",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "b")},
+			err:      "exactly one node without parents",
+		},
+		{
+			name:     "cycle",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("a", "b", "b", "c", "c", "b")},
+			err:      "cycle",
+		},
+		{
+			name: "payload",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", PayloadBytes: 1024, PayloadDistribution: common.ExponentialPayload},
+				{From: "a", To: "c", ForwardResponse: true},
+			}},
+		},
+		{
+			name: "negative_payload",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", PayloadBytes: -1}, {From: "a", To: "c"},
+			}},
+			err: "negative payload size",
+		},
+		{
+			name: "payload_distribution",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", PayloadBytes: 1, PayloadDistribution: "normal"}, {From: "a", To: "c"},
+			}},
+			err: "unknown payload distribution",
+		},
+		{
+			name: "forwarded_synthetic_payload",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", PayloadBytes: 1, ForwardResponse: true}, {From: "a", To: "c"},
+			}},
+			err: "cannot be combined",
+		},
+		{
+			name: "conditions",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", ResponseRegex: "^OK", Group: "ab", Probability: 0.5},
+				{From: "a", To: "c", Condition: common.FailureCondition, Group: "ab", Probability: 0.5},
+			}},
+		},
+		{
+			name: "unknown_condition",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", Condition: "sometimes"}, {From: "a", To: "c"},
+			}},
+			err: "unknown condition",
+		},
+		{
+			name: "failure_regex",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", Condition: common.FailureCondition, ResponseRegex: "OK"}, {From: "a", To: "c"},
+			}},
+			err: "requires the success condition",
+		},
+		{
+			name: "invalid_regex",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", ResponseRegex: "(OK"}, {From: "a", To: "c"},
+			}},
+			err: "invalid response regex",
+		},
+		{
+			name: "probability",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", Probability: 1.5}, {From: "a", To: "c"},
+			}},
+			err: "not in [0, 1]",
+		},
+		{
+			name: "group_probability",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", Group: "ab", Probability: 0.6}, {From: "a", To: "c", Group: "ab", Probability: 0.6},
+			}},
+			err: "sum up to more than 1",
+		},
+		{
+			name: "group_without_probability",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: []common.WorkflowEdge{
+				{From: "a", To: "b", Group: "ab"}, {From: "a", To: "c"},
+			}},
+			err: "require a probability",
+		},
+		{
+			name:     "no_arrival_rate",
+			workflow: common.WorkflowDefinition{Name: "w", Nodes: nodes, Edges: edges("b", "a", "b", "c")},
+			err:      "arrival rate",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateWorkflowDefinitions([]common.WorkflowDefinition{test.workflow})
+			if test.err == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("Expected an error containing '%s', got %v", test.err, err)
+			}
+		})
+	}
+}
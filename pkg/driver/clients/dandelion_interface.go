@@ -54,7 +54,9 @@ type DandelionDeserializeResponse struct {
 	return bytes.NewBuffer(body)
 }*/
 
-func composeBusyLoopBody(functionName, image string, runtime, iterations int) *bytes.Buffer {
+// composeBusyLoopBody encodes the busy loop request, which carries the payload of the workflow edge, if any, as an
+// item next to the busy loop input
+func composeBusyLoopBody(functionName, image string, runtime, iterations int, payload []byte) *bytes.Buffer {
 	request := DandelionRequest{
 		Name: functionName,
 		Sets: []InputSet{
@@ -77,6 +79,13 @@ func composeBusyLoopBody(functionName, image string, runtime, iterations int) *b
 			},
 		},
 	}
+	if payload != nil {
+		request.Sets[0].Items = append(request.Sets[0].Items, InputItem{
+			Identifier: "payload",
+			Key:        1,
+			Data:       payload,
+		})
+	}
 
 	body, err := bson.Marshal(request)
 	if err != nil {
@@ -35,6 +35,8 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/mem"
 	"strings"
 	"time"
 
@@ -42,20 +44,38 @@ import (
 )
 
 type invoker interface {
-	Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool
+	Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool
+}
+
+// timedCodec records the time the wrapped codec takes to serialize a request
+type timedCodec struct {
+	encoding.CodecV2
+	record *mc.ExecutionRecord
+}
+
+func (c timedCodec) Marshal(v any) (mem.BufferSlice, error) {
+	start := time.Now()
+	defer func() { c.record.SerializationTime = time.Since(start).Microseconds() }()
+
+	return c.CodecV2.Marshal(v)
 }
 
 type ExecutorRPC struct {
 }
 
-func (i ExecutorRPC) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool {
+func (i ExecutorRPC) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool {
 	grpcClient := proto.NewExecutorClient(conn)
 
+	var callOptions []grpc.CallOption
+	if payload != nil {
+		callOptions = append(callOptions, grpc.ForceCodecV2(timedCodec{CodecV2: encoding.GetCodecV2("proto"), record: record}))
+	}
+
 	response, err := grpcClient.Execute(executionCxt, &proto.FaasRequest{
-		Message:           "nothing",
+		Message:           string(payload),
 		RuntimeInMilliSec: uint32(runtimeSpec.Runtime),
 		MemoryInMebiBytes: uint32(runtimeSpec.Memory),
-	})
+	}, callOptions...)
 
 	if err != nil {
 		logrus.Debugf("gRPC timeout exceeded for function %s - %s", function.Name, err)
@@ -66,8 +86,13 @@ func (i ExecutorRPC) Invoke(function *common.Function, runtimeSpec *common.Runti
 		return false
 	}
 
-	record.Instance = extractInstanceName(response.GetMessage())
+	serviceName := function.Name
+	if function.ServiceName != "" {
+		serviceName = function.ServiceName
+	}
+	record.Instance = extractInstanceName(response.GetMessage(), serviceName)
 	record.ActualDuration = response.DurationInMicroSec
+	record.ResponseBody = response.GetMessage()
 
 	if strings.HasPrefix(response.GetMessage(), "FAILURE - mem_alloc") {
 		record.MemoryAllocationTimeout = true
@@ -84,7 +109,8 @@ func (i ExecutorRPC) Invoke(function *common.Function, runtimeSpec *common.Runti
 type SayHelloRPC struct {
 }
 
-func (i SayHelloRPC) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool {
+// Invoke calls a vSwarm benchmark, which works on its own input and hence ignores the payload
+func (i SayHelloRPC) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, _ []byte, conn *grpc.ClientConn, record *mc.ExecutionRecord, executionCxt context.Context) bool {
 	grpcClient := helloworld.NewGreeterClient(conn)
 	response, err := grpcClient.SayHello(executionCxt, &helloworld.HelloRequest{
 		Name: "Invoke Relay",
@@ -121,6 +147,14 @@ func newGRPCInvoker(cfg *config.LoaderConfiguration, invoker invoker) *grpcInvok
 }
 
 func (i *grpcInvoker) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification) (bool, *mc.ExecutionRecord) {
+	return i.invoke(function, runtimeSpec, nil)
+}
+
+func (i *grpcInvoker) InvokeWithPayload(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte) (bool, *mc.ExecutionRecord) {
+	return i.invoke(function, runtimeSpec, payload)
+}
+
+func (i *grpcInvoker) invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte) (bool, *mc.ExecutionRecord) {
 	logrus.Tracef("(Invoke)\t %s: %d[ms], %d[MiB]", function.Name, runtimeSpec.Runtime, runtimeSpec.Memory)
 
 	record := &mc.ExecutionRecord{
@@ -138,7 +172,7 @@ func (i *grpcInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 	var dialOptions []grpc.DialOption
 	dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if strings.Contains(strings.ToLower(i.cfg.Platform), "dirigent") {
-		dialOptions = append(dialOptions, grpc.WithAuthority(function.Name)) // Dirigent specific
+		dialOptions = append(dialOptions, grpc.WithAuthority(serviceName(function))) // Dirigent specific
 	}
 	if i.cfg.EnableZipkinTracing {
 		dialOptions = append(dialOptions, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
@@ -160,14 +194,15 @@ func (i *grpcInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 	record.GRPCConnectionEstablishTime = time.Since(grpcStart).Microseconds()
 	executionCxt, cancelExecution := context.WithTimeout(context.Background(), time.Duration(i.cfg.GRPCFunctionTimeoutSeconds)*time.Second)
 	defer cancelExecution()
-	success := i.invoker.Invoke(function, runtimeSpec, conn, record, executionCxt)
+	success := i.invoker.Invoke(function, runtimeSpec, payload, conn, record, executionCxt)
 	record.ResponseTime = time.Since(start).Microseconds()
 	logrus.Tracef("(E2E Latency) %s: %.2f[ms]\n", function.Name, float64(record.ResponseTime)/1e3)
 	return success, record
 }
 
-func extractInstanceName(data string) string {
-	indexOfHyphen := strings.LastIndex(data, common.FunctionNamePrefix)
+// extractInstanceName returns the name of the pod from the response, which starts with the name of the service
+func extractInstanceName(data string, serviceName string) string {
+	indexOfHyphen := strings.LastIndex(data, serviceName)
 	if indexOfHyphen == -1 {
 		return data
 	}
@@ -84,6 +84,14 @@ func CreateFilePayload(filePath string) *bytes.Buffer {
 }
 
 func (i *httpInvoker) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification) (bool, *mc.ExecutionRecord) {
+	return i.invoke(function, runtimeSpec, nil)
+}
+
+func (i *httpInvoker) InvokeWithPayload(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte) (bool, *mc.ExecutionRecord) {
+	return i.invoke(function, runtimeSpec, payload)
+}
+
+func (i *httpInvoker) invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, edgePayload []byte) (bool, *mc.ExecutionRecord) {
 	isDandelion := strings.Contains(strings.ToLower(i.cfg.Platform), "dandelion")
 	isKnative := strings.Contains(strings.ToLower(i.cfg.Platform), "knative")
 
@@ -103,8 +111,14 @@ func (i *httpInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 	/*if body := composeDandelionMatMulBody(function.Name); isDandelion && body != nil {
 		requestBody = body
 	}*/
-	if body := composeBusyLoopBody(function.Name, function.DirigentMetadata.Image, runtimeSpec.Runtime, function.DirigentMetadata.IterationMultiplier); isDandelion && body != nil {
-		requestBody = body
+	if isDandelion {
+		ts := time.Now()
+		if body := composeBusyLoopBody(serviceName(function), function.DirigentMetadata.Image, runtimeSpec.Runtime, function.DirigentMetadata.IterationMultiplier, edgePayload); body != nil {
+			requestBody = body
+			if edgePayload != nil {
+				record.SerializationTime = time.Since(ts).Microseconds()
+			}
+		}
 	}
 	if i.cfg.RpsTarget != 0 {
 		ts := time.Now()
@@ -116,6 +130,10 @@ func (i *httpInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 			log.Debugf("Took %v to generate request body.", time.Since(ts))
 		}
 	}
+	if edgePayload != nil && !isDandelion {
+		// the payload is the body as is, so there is no serialization time to record
+		requestBody = bytes.NewBuffer(edgePayload)
+	}
 
 	start := time.Now()
 	record.StartTime = start.UnixMicro()
@@ -133,7 +151,7 @@ func (i *httpInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 
 	// add system specific stuff
 	if !isKnative {
-		req.Host = function.Name
+		req.Host = serviceName(function)
 	}
 
 	req.Header.Set("workload", function.DirigentMetadata.Image)
@@ -192,6 +210,7 @@ func (i *httpInvoker) Invoke(function *common.Function, runtimeSpec *common.Runt
 	}
 
 	record.ResponseTime = time.Since(start).Microseconds()
+	record.ResponseBody = string(body)
 
 	if strings.HasPrefix(string(body), "FAILURE - mem_alloc") {
 		record.MemoryAllocationTimeout = true
@@ -13,6 +13,11 @@ type Invoker interface {
 	Invoke(*common.Function, *common.RuntimeSpecification) (bool, *metric.ExecutionRecord)
 }
 
+// PayloadInvoker is an Invoker able to send a payload in the request, e.g., the data passed along an edge of a DAG
+type PayloadInvoker interface {
+	InvokeWithPayload(*common.Function, *common.RuntimeSpecification, []byte) (bool, *metric.ExecutionRecord)
+}
+
 func CreateInvoker(cfg *config.LoaderConfiguration, announceDoneExe *sync.WaitGroup, readOpenWhiskMetadata *sync.Mutex) Invoker {
 	switch cfg.Platform {
 	case "AWSLambda":
@@ -43,3 +48,12 @@ func CreateInvoker(cfg *config.LoaderConfiguration, announceDoneExe *sync.WaitGr
 
 	return nil
 }
+
+// serviceName returns the name of the service invocations of the function are addressed to
+func serviceName(function *common.Function) string {
+	if function.ServiceName != "" {
+		return function.ServiceName
+	}
+
+	return function.Name
+}
@@ -22,21 +22,31 @@ func newAWSLambdaDeployer() *awsLambdaDeployer {
 func (ld *awsLambdaDeployer) Deploy(cfg *config.Configuration) {
 	ld.functions = cfg.Functions
 
-	internalAWSDeployment(cfg.Functions)
+	internalAWSDeployment(cfg.Functions, common.RunScope(cfg.LoaderConfiguration.FunctionNamePrefix, cfg.LoaderConfiguration.RunID))
 }
 
 func (ld *awsLambdaDeployer) Clean() {
 	CleanAWSLambda(ld.functions)
 }
 
-func internalAWSDeployment(functions []*common.Function) {
+func (ld *awsLambdaDeployer) Deployed() []DeployedService {
+	return functionServices(ld.functions)
+}
+
+// Restore relies on the serverless.yml files of the deployment being in the working directory
+func (ld *awsLambdaDeployer) Restore(services []DeployedService) {
+	ld.functions = serviceFunctions(services)
+}
+
+// internalAWSDeployment deploys the functions as stacks named after the scope of the run
+func internalAWSDeployment(functions []*common.Function, scope string) {
 	const provider = "aws"
 
 	// Check if all required dependencies are installed, verify that AWS account is clean and ready for deployment
-	awsAccountId, functionGroups := initAWSLambda(functions, provider)
+	awsAccountId, functionGroups := initAWSLambda(functions, provider, scope)
 
 	// Create all the serverless.yml files
-	createSlsConfigFiles(functionGroups, provider, awsAccountId)
+	createSlsConfigFiles(functionGroups, provider, awsAccountId, scope)
 
 	// Use goroutines to deploy functions in parallel, and ensure all finishes
 	// Due to CPU and memory constraints, by default, we will deploy 2 serverless.yml files in parallel and wait for them to finish before deploying the next 2
@@ -126,10 +136,10 @@ func cleanAWSElasticContainerRegistry() {
 	}
 }
 
-// cleanAWSCloudWatchLogGroups cleans up the AWS CloudWatch log groups by deleting all log groups with the prefix "/aws/lambda/trace-func-"
-func cleanAWSCloudWatchLogGroups() {
+// cleanAWSCloudWatchLogGroups cleans up the AWS CloudWatch log groups by deleting all log groups of the functions of the run, e.g., with the prefix "/aws/lambda/trace-func-"
+func cleanAWSCloudWatchLogGroups(scope string) {
 	// Check if CloudWatch log groups exist, if so, delete them
-	logGroupPrefix := fmt.Sprintf("/aws/lambda/%s-", common.FunctionNamePrefix)
+	logGroupPrefix := fmt.Sprintf("/aws/lambda/%s-", scope)
 
 	checkExistLogGroupsCmd := exec.Command("aws", "logs", "describe-log-groups", "--log-group-name-prefix", logGroupPrefix, "--query", "logGroups[*].logGroupName", "--output", "json")
 	stdOutstdErr, err := checkExistLogGroupsCmd.CombinedOutput()
@@ -155,7 +165,7 @@ func cleanAWSCloudWatchLogGroups() {
 }
 
 // initAWSLambda initializes the AWS Lambda deployment environment by checking dependencies, cleaning up previous resources, and initialising ECR repository through initECRRepository
-func initAWSLambda(functions []*common.Function, provider string) (string, [][]*common.Function) {
+func initAWSLambda(functions []*common.Function, provider string, scope string) (string, [][]*common.Function) {
 	// Check if all required dependencies are installed
 	log.Debug("Checking dependencies for AWS deployment")
 	checkDependencies()
@@ -163,9 +173,9 @@ func initAWSLambda(functions []*common.Function, provider string) (string, [][]*
 	// Clean up previous resources, if any
 	log.Debug("Checking and cleaning up previous AWS Lambda resources")
 	functionGroups := separateFunctions(functions)
-	createSlsConfigFiles(functionGroups, provider, "") // serverless.yml files created do not require AWS account ID
+	createSlsConfigFiles(functionGroups, provider, "", scope) // serverless.yml files created do not require AWS account ID
 	CleanAWSLambda(functions)
-	cleanAWSCloudWatchLogGroups() // Clean up CloudWatch log groups (in rare occasions, log groups persist even after `sls remove`)
+	cleanAWSCloudWatchLogGroups(scope) // Clean up CloudWatch log groups (in rare occasions, log groups persist even after `sls remove`)
 
 	// Create a Private ECR Repository and Upload the Docker Image
 	log.Debug("Initialising ECR Repository for AWS Lambda deployment")
@@ -264,11 +274,11 @@ func separateFunctions(functions []*common.Function) [][]*common.Function {
 }
 
 // createSlsConfigFiles creates serverless.yml files for each group of functions
-func createSlsConfigFiles(functionGroups [][]*common.Function, provider string, awsAccountId string) {
+func createSlsConfigFiles(functionGroups [][]*common.Function, provider string, awsAccountId string, scope string) {
 	for i := 0; i < len(functionGroups); i++ {
 		log.Debugf("Creating serverless-%d.yml", i)
 		serverless := Serverless{}
-		serverless.CreateHeader(i, provider)
+		serverless.CreateHeader(i, provider, scope)
 
 		for j := 0; j < len(functionGroups[i]); j++ {
 			serverless.AddFunctionConfig(functionGroups[i][j], provider, awsAccountId)
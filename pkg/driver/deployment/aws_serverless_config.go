@@ -40,9 +40,9 @@ type slsFunction struct {
 	Timeout     string `yaml:"timeout"`
 }
 
-// CreateHeader sets the fields Service, FrameworkVersion, and Provider
-func (s *Serverless) CreateHeader(index int, provider string) {
-	s.Service = fmt.Sprintf("loader-%d", index)
+// CreateHeader sets the fields Service, FrameworkVersion, and Provider, with the service named after the scope of the run
+func (s *Serverless) CreateHeader(index int, provider string, scope string) {
+	s.Service = fmt.Sprintf("loader-%s-%d", scope, index)
 	s.FrameworkVersion = "3"
 	s.Provider = slsProvider{
 		Name:             provider,
@@ -74,6 +74,10 @@ func (s *Serverless) AddPackagePattern(pattern string) {
 func (s *Serverless) AddFunctionConfig(function *common.Function, provider string, awsAccountId string) {
 	// Extract trace-func-0 from trace-func-0-2642643831809466437 by splitting on "-"
 	shortName := fmt.Sprintf("%s-%s", common.FunctionNamePrefix, strings.Split(function.Name, "-")[2])
+	if function.TraceName != "" {
+		// run-scoped names are short and unique already
+		shortName = function.Name
+	}
 
 	var image string
 	var timeout string
@@ -138,7 +142,7 @@ func CleanServerless(index int) bool {
 
 	slsRemoveCmd := exec.Command("sls", "remove", "--config", fmt.Sprintf("./serverless-%d.yml", index))
 	stdoutStderr, err := slsRemoveCmd.CombinedOutput()
-	if err != nil && !strings.Contains(string(stdoutStderr), fmt.Sprintf("Stack 'loader-%d-dev' does not exist", index)) {
+	if err != nil && !strings.Contains(string(stdoutStderr), "does not exist") {
 		log.Errorf("Failed to undeploy serverless-%d.yml: %v\n%s", index, err, stdoutStderr)
 		return false
 	}
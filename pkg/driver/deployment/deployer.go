@@ -8,6 +8,10 @@ import (
 type FunctionDeployer interface {
 	Deploy(cfg *config.Configuration)
 	Clean()
+	// Deployed returns the services created by the deployer
+	Deployed() []DeployedService
+	// Restore takes over services created by another run of the loader, which Clean then deletes
+	Restore(services []DeployedService)
 }
 
 func CreateDeployer(cfg *config.Configuration) FunctionDeployer {
@@ -15,7 +19,7 @@ func CreateDeployer(cfg *config.Configuration) FunctionDeployer {
 	case "AWSLambda":
 		return newAWSLambdaDeployer()
 	case "Dirigent", "Dirigent-Dandelion":
-		return newDirigentDeployer()
+		return newDirigentDeployer(cfg.LoaderConfiguration.DirigentControlPlaneIP)
 	case "Knative":
 		return newKnativeDeployer()
 	case "OpenWhisk":
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -17,14 +18,31 @@ import (
 	"github.com/vhive-serverless/loader/pkg/config"
 )
 
-type dirigentDeployer struct{}
+const (
+	dirigentRetries        = 5
+	dirigentInitialBackoff = time.Second
+	dirigentMaxBackoff     = 30 * time.Second
+)
+
+type dirigentDeployer struct {
+	controlPlaneAddress string
+	// initialBackoff Time before the first retry of a failed request, doubled on every further retry
+	initialBackoff time.Duration
+
+	// functions Functions registered by the deployer, which are the only ones Clean deregisters
+	functions      []*common.Function
+	functionsMutex sync.Mutex
+}
 
 type dirigentDeploymentConfiguration struct {
 	RegistrationServer string
 }
 
-func newDirigentDeployer() *dirigentDeployer {
-	return &dirigentDeployer{}
+func newDirigentDeployer(controlPlaneAddress string) *dirigentDeployer {
+	return &dirigentDeployer{
+		controlPlaneAddress: controlPlaneAddress,
+		initialBackoff:      dirigentInitialBackoff,
+	}
 }
 
 func newDirigentDeployerConfiguration(cfg *config.Configuration) dirigentDeploymentConfiguration {
@@ -33,8 +51,12 @@ func newDirigentDeployerConfiguration(cfg *config.Configuration) dirigentDeploym
 	}
 }
 
-func (*dirigentDeployer) Deploy(cfg *config.Configuration) {
+func (dd *dirigentDeployer) Deploy(cfg *config.Configuration) {
 	dirigentConfig := newDirigentDeployerConfiguration(cfg)
+	dd.controlPlaneAddress = dirigentConfig.RegistrationServer
+
+	var failed []string
+	failedMutex := sync.Mutex{}
 
 	wg := &sync.WaitGroup{}
 	wg.Add(len(cfg.Functions))
@@ -43,20 +65,139 @@ func (*dirigentDeployer) Deploy(cfg *config.Configuration) {
 		go func(idx int) {
 			defer wg.Done()
 
-			deployDirigent(
-				cfg.Functions[idx],
-				dirigentConfig.RegistrationServer,
-				cfg.LoaderConfiguration.BusyLoopOnSandboxStartup,
-				cfg.LoaderConfiguration.PrepullMode,
-				cfg.LoaderConfiguration.RpsRequestedGpu,
-			)
+			function := cfg.Functions[idx]
+			err := dd.retry(fmt.Sprintf("register %s", function.Name), func() error {
+				return deployDirigent(
+					function,
+					dirigentConfig.RegistrationServer,
+					cfg.LoaderConfiguration.BusyLoopOnSandboxStartup,
+					cfg.LoaderConfiguration.PrepullMode,
+					cfg.LoaderConfiguration.RpsRequestedGpu,
+				)
+			})
+			if err != nil {
+				log.Errorf("Failed to register function %s with the control plane - %v", function.Name, err)
+
+				failedMutex.Lock()
+				failed = append(failed, function.Name)
+				failedMutex.Unlock()
+
+				return
+			}
+
+			checkForRegistration(dirigentConfig.RegistrationServer, function.Name, cfg.LoaderConfiguration.PrepullMode)
+
+			dd.functionsMutex.Lock()
+			dd.functions = append(dd.functions, function)
+			dd.functionsMutex.Unlock()
 		}(i)
 	}
 
 	wg.Wait()
+
+	if len(failed) > 0 {
+		log.Errorf("Registered %d out of %d functions, failed to register: %s", len(cfg.Functions)-len(failed),
+			len(cfg.Functions), strings.Join(failed, ", "))
+	}
 }
 
-func (*dirigentDeployer) Clean() {}
+// Clean deregisters the functions registered by the deployer from the control plane
+func (dd *dirigentDeployer) Clean() {
+	dd.functionsMutex.Lock()
+	defer dd.functionsMutex.Unlock()
+
+	var failed []string
+	failedMutex := sync.Mutex{}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(dd.functions))
+
+	for i := 0; i < len(dd.functions); i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			function := dd.functions[idx]
+			err := dd.retry(fmt.Sprintf("deregister %s", function.Name), func() error {
+				return deregisterDirigent(function.Name, dd.controlPlaneAddress)
+			})
+			if err != nil {
+				log.Errorf("Failed to deregister function %s from the control plane - %v", function.Name, err)
+
+				failedMutex.Lock()
+				failed = append(failed, function.Name)
+				failedMutex.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(failed) > 0 {
+		log.Errorf("Deregistered %d out of %d functions, failed to deregister: %s", len(dd.functions)-len(failed),
+			len(dd.functions), strings.Join(failed, ", "))
+	} else {
+		log.Infof("Deregistered %d functions from the control plane.", len(dd.functions))
+	}
+
+	dd.functions = nil
+}
+
+func (dd *dirigentDeployer) Deployed() []DeployedService {
+	dd.functionsMutex.Lock()
+	defer dd.functionsMutex.Unlock()
+
+	return functionServices(dd.functions)
+}
+
+func (dd *dirigentDeployer) Restore(services []DeployedService) {
+	dd.functionsMutex.Lock()
+	defer dd.functionsMutex.Unlock()
+
+	dd.functions = serviceFunctions(services)
+}
+
+// retry calls the request until it succeeds or fails permanently, backing off exponentially between the attempts, and
+// returns the error of the last attempt
+func (dd *dirigentDeployer) retry(description string, request func() error) error {
+	backoff := dd.initialBackoff
+
+	var err error
+	for attempt := 1; attempt <= dirigentRetries; attempt++ {
+		if err = request(); err == nil || !isTransientDirigentError(err) {
+			return err
+		}
+
+		if attempt < dirigentRetries {
+			log.Debugf("Attempt %d to %s failed, retrying in %v - %v", attempt, description, backoff, err)
+
+			time.Sleep(backoff)
+			backoff = min(2*backoff, dirigentMaxBackoff)
+		}
+	}
+
+	return err
+}
+
+// controlPlaneError is the response of the control plane to a request it rejected
+type controlPlaneError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *controlPlaneError) Error() string {
+	return fmt.Sprintf("got status code %d - %s", e.StatusCode, e.Body)
+}
+
+// isTransientDirigentError reports whether the request may succeed if repeated, which is the case for transport errors
+// and server errors of the control plane, but not for the requests it rejected
+func isTransientDirigentError(err error) bool {
+	var rejected *controlPlaneError
+	if errors.As(err, &rejected) {
+		return rejected.StatusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
 
 var registrationClient = &http.Client{
 	Timeout: 300 * time.Second, // time for a request to timeout
@@ -82,7 +223,7 @@ var checkClient = &http.Client{
 	},
 }
 
-func deployDirigent(function *common.Function, controlPlaneAddress string, busyLoopOnColdStart bool, prepullMode string, requestedGpu int) {
+func deployDirigent(function *common.Function, controlPlaneAddress string, busyLoopOnColdStart bool, prepullMode string, requestedGpu int) error {
 	metadata := function.DirigentMetadata
 
 	if metadata == nil {
@@ -110,34 +251,55 @@ func deployDirigent(function *common.Function, controlPlaneAddress string, busyL
 
 	log.Debug(payload)
 
-	resp, err := registrationClient.PostForm(fmt.Sprintf("http://%s/registerService", controlPlaneAddress), payload)
+	body, err := postToControlPlane(fmt.Sprintf("http://%s/registerService", controlPlaneAddress), payload)
 	if err != nil {
-		log.Error("Failed to register a service with the control plane - ", err.Error())
-		return
+		return err
+	}
+
+	if len(body) == 0 {
+		return fmt.Errorf("function registration returned no data plane(s)")
+	}
+	endpoints := strings.Split(string(body), ";")
+
+	log.Debugf("Got the following endpoints: %v", endpoints)
+	function.Endpoint = endpoints[rand.Intn(len(endpoints))]
+
+	return nil
+}
+
+// deregisterDirigent deregisters the function, which is considered done if the control plane does not know the function
+func deregisterDirigent(functionName string, controlPlaneAddress string) error {
+	_, err := postToControlPlane(fmt.Sprintf("http://%s/deregisterService", controlPlaneAddress), url.Values{
+		"name": {functionName},
+	})
+
+	var rejected *controlPlaneError
+	if errors.As(err, &rejected) && rejected.StatusCode == http.StatusNotFound {
+		log.Debugf("Function %s was not registered with the control plane.", functionName)
+		return nil
+	}
+
+	return err
+}
+
+// postToControlPlane posts the form to the control plane and returns the body of the response
+func postToControlPlane(address string, payload url.Values) ([]byte, error) {
+	resp, err := registrationClient.PostForm(address, payload)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error("Failed to read response body.")
-		return
+		return nil, fmt.Errorf("failed to read response body - %v", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Errorf("Got status code %d while registering %s. Body: %s", resp.StatusCode, function.Name, body)
-		return
+		return nil, &controlPlaneError{StatusCode: resp.StatusCode, Body: body}
 	}
 
-	endpoints := strings.Split(string(body), ";")
-	if len(endpoints) == 0 {
-		log.Error("Function registration returned no data plane(s).")
-		return
-	}
-
-	log.Debugf("Got the following endpoints: %v", endpoints)
-	function.Endpoint = endpoints[rand.Intn(len(endpoints))]
-
-	checkForRegistration(controlPlaneAddress, function.Name, prepullMode)
+	return body, nil
 }
 
 func checkForRegistration(controlPlaneAddress, functionName, prepullMode string) {
@@ -0,0 +1,113 @@
+package deployment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/config"
+)
+
+// fakeControlPlane stands in for the registration endpoints of the Dirigent control plane, failing the first request
+// for every function in flaky, all requests for the functions in broken, and rejecting the functions in invalid
+type fakeControlPlane struct {
+	mutex      sync.Mutex
+	registered map[string]bool
+	attempts   map[string]int
+	flaky      map[string]bool
+	broken     map[string]bool
+	invalid    map[string]bool
+}
+
+func (c *fakeControlPlane) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	name := r.FormValue("name")
+	c.attempts[r.URL.Path+" "+name]++
+
+	if c.broken[name] || (c.flaky[name] && c.attempts[r.URL.Path+" "+name] == 1) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	} else if c.invalid[name] {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/registerService":
+		c.registered[name] = true
+		_, _ = w.Write([]byte("10.0.0.1:8080;10.0.0.2:8080"))
+	case "/deregisterService":
+		if !c.registered[name] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		delete(c.registered, name)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestDirigentDeployer(t *testing.T) {
+	controlPlane := &fakeControlPlane{
+		registered: map[string]bool{"other-run-0": true},
+		attempts:   make(map[string]int),
+		flaky:      map[string]bool{"trace-func-1": true},
+		broken:     map[string]bool{"trace-func-2": true},
+		invalid:    map[string]bool{"trace-func-3": true},
+	}
+	server := httptest.NewServer(controlPlane)
+	defer server.Close()
+
+	functions := make([]*common.Function, 4)
+	for i := range functions {
+		functions[i] = &common.Function{
+			Name:             common.RunScopedName("trace-func", i),
+			DirigentMetadata: &common.DirigentMetadata{Image: "image", Port: 80, Protocol: "tcp"},
+		}
+	}
+
+	address := strings.TrimPrefix(server.URL, "http://")
+	deployer := newDirigentDeployer(address)
+	deployer.initialBackoff = time.Millisecond
+	deployer.Deploy(&config.Configuration{
+		LoaderConfiguration: &config.LoaderConfiguration{DirigentControlPlaneIP: address},
+		Functions:           functions,
+	})
+
+	if functions[0].Endpoint == "" || functions[1].Endpoint == "" || functions[2].Endpoint != "" || functions[3].Endpoint != "" {
+		t.Errorf("Unexpected endpoints %s, %s, %s and %s.", functions[0].Endpoint, functions[1].Endpoint, functions[2].Endpoint, functions[3].Endpoint)
+	}
+	if controlPlane.attempts["/registerService trace-func-2"] != dirigentRetries {
+		t.Errorf("Expected %d attempts to register the broken function, got %d.", dirigentRetries, controlPlane.attempts["/registerService trace-func-2"])
+	}
+	if controlPlane.attempts["/registerService trace-func-3"] != 1 {
+		t.Errorf("Expected the rejected registration not to be retried, got %d attempts.", controlPlane.attempts["/registerService trace-func-3"])
+	}
+	if deployed := deployer.Deployed(); len(deployed) != 2 {
+		t.Errorf("Expected only the registered functions to be deployed, got %v.", deployed)
+	}
+
+	controlPlane.flaky = map[string]bool{"trace-func-0": true}
+	// deregistered behind the back of the deployer
+	delete(controlPlane.registered, "trace-func-1")
+	deployer.Clean()
+
+	if len(controlPlane.registered) != 1 || !controlPlane.registered["other-run-0"] {
+		t.Errorf("Expected only the functions of the deployer to be deregistered, got %v.", controlPlane.registered)
+	}
+	if controlPlane.attempts["/deregisterService trace-func-0"] != 2 || controlPlane.attempts["/deregisterService trace-func-1"] != 1 ||
+		controlPlane.attempts["/deregisterService trace-func-2"] != 0 {
+
+		t.Errorf("Unexpected deregistration attempts %v.", controlPlane.attempts)
+	}
+	if err := deregisterDirigent("unknown", address); err != nil {
+		t.Errorf("Expected an unknown function to count as deregistered, got %v.", err)
+	}
+}
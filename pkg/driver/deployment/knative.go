@@ -2,34 +2,61 @@ package deployment
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/vhive-serverless/loader/pkg/common"
 	"github.com/vhive-serverless/loader/pkg/config"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	"math"
-	"os/exec"
-	"regexp"
+	"os"
+	"os/user"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	bareMetalLbGateway = "10.200.3.4.sslip.io" // Address of the bare-metal load balancer.
-	namespace          = "default"
-)
 
-var (
-	urlRegex = regexp.MustCompile("at URL:\nhttp://([^\n]+)")
+	defaultKnativeReadyTimeout = 10 * time.Minute
+	knativeReadyPollInterval   = time.Second
+
+	// RunIDLabel Label of the services deployed by a run of the loader
+	RunIDLabel      = "loader.vhive-serverless.io/run-id"
+	managedByLabel  = "app.kubernetes.io/managed-by"
+	managedBy       = "loader"
+	ownerAnnotation = "loader.vhive-serverless.io/owner"
 )
 
-type knativeDeployer struct{}
+type knativeDeployer struct {
+	client dynamic.Interface
+	// namespace Namespace of the current context of the kubeconfig, which services are deployed in by default
+	namespace string
+
+	// services Services created by the deployer, which are the only ones Clean deletes
+	services      []DeployedService
+	servicesMutex sync.Mutex
+}
 
 type knativeDeploymentConfiguration struct {
 	YamlPath          string
 	IsPartiallyPanic  bool
 	EndpointPort      int
 	AutoscalingMetric string
+	ReadyTimeout      time.Duration
+	Namespace         string
+	RunID             string
+	// Owner User and host the loader runs on
+	Owner string
 }
 
 func newKnativeDeployer() *knativeDeployer {
@@ -37,17 +64,57 @@ func newKnativeDeployer() *knativeDeployer {
 }
 
 func newKnativeDeployerConfiguration(cfg *config.Configuration) knativeDeploymentConfiguration {
+	readyTimeout := defaultKnativeReadyTimeout
+	if cfg.LoaderConfiguration.KnativeReadyTimeoutSeconds > 0 {
+		readyTimeout = time.Duration(cfg.LoaderConfiguration.KnativeReadyTimeoutSeconds) * time.Second
+	}
+
 	return knativeDeploymentConfiguration{
 		YamlPath:          cfg.YAMLPath,
 		IsPartiallyPanic:  cfg.LoaderConfiguration.IsPartiallyPanic,
 		EndpointPort:      cfg.LoaderConfiguration.EndpointPort,
 		AutoscalingMetric: cfg.LoaderConfiguration.AutoscalingMetric,
+		ReadyTimeout:      readyTimeout,
+		Namespace:         cfg.LoaderConfiguration.Namespace,
+		RunID:             cfg.LoaderConfiguration.RunID,
+		Owner:             loaderOwner(),
 	}
 }
 
-func (*knativeDeployer) Deploy(cfg *config.Configuration) {
+// loaderOwner returns the user and host the loader runs on, so that services left behind can be traced back
+func loaderOwner() string {
+	owner := "unknown"
+	if current, err := user.Current(); err == nil {
+		owner = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		owner += "@" + host
+	}
+
+	return owner
+}
+
+func (d *knativeDeployer) Deploy(cfg *config.Configuration) {
 	knativeConfig := newKnativeDeployerConfiguration(cfg)
 
+	if err := d.connect(); err != nil {
+		if cfg.LoaderConfiguration.ReadinessTimeoutSeconds == 0 {
+			log.Fatalf("Failed to create a Kubernetes client - %v", err)
+		}
+
+		// the readiness gate reports the functions as not deployed and applies the readiness policy
+		log.Errorf("Failed to create a Kubernetes client, no function deployed - %v", err)
+		return
+	}
+	if knativeConfig.Namespace == "" {
+		knativeConfig.Namespace = d.namespace
+	}
+
+	serviceTemplate, err := template.ParseFiles(knativeConfig.YamlPath)
+	if err != nil {
+		log.Fatalf("Failed to parse the service YAML %s - %v", knativeConfig.YamlPath, err)
+	}
+
 	queue := make(chan struct{}, runtime.NumCPU()) // message queue as a sync method
 	deployed := sync.WaitGroup{}
 	deployed.Add(len(cfg.Functions))
@@ -59,84 +126,225 @@ func (*knativeDeployer) Deploy(cfg *config.Configuration) {
 			defer deployed.Done()
 			defer func() { <-queue }()
 
-			knativeDeploySingleFunction(
-				cfg.Functions[i],
-				knativeConfig.YamlPath,
-				knativeConfig.IsPartiallyPanic,
-				knativeConfig.EndpointPort,
-				knativeConfig.AutoscalingMetric,
-			)
+			d.deploySingleFunction(cfg.Functions[i], serviceTemplate, &knativeConfig)
 		}()
 	}
 
 	deployed.Wait()
 }
 
-func (*knativeDeployer) Clean() {
-	cmd := exec.Command("kn", "service", "delete", "--all")
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
+func (d *knativeDeployer) connect() error {
+	if d.client != nil {
+		return nil
+	}
 
-	if err := cmd.Run(); err != nil {
-		log.Errorf("Unable to delete Knative services - %s", err)
+	client, contextNamespace, err := newKubernetesClient()
+	if err != nil {
+		return err
 	}
+
+	d.client, d.namespace = client, contextNamespace
+	return nil
 }
 
-func knativeDeploySingleFunction(function *common.Function, yamlPath string, isPartiallyPanic bool, endpointPort int, autoscalingMetric string) bool {
-	panicWindow := "\"10.0\""
-	panicThreshold := "\"200.0\""
-	if isPartiallyPanic {
-		panicWindow = "\"100.0\""
-		panicThreshold = "\"1000.0\""
+// Clean deletes the services created by the deployer, leaving the other services of the namespace untouched
+func (d *knativeDeployer) Clean() {
+	d.servicesMutex.Lock()
+	defer d.servicesMutex.Unlock()
+
+	if len(d.services) == 0 {
+		return
+	} else if err := d.connect(); err != nil {
+		log.Errorf("Failed to create a Kubernetes client, no service deleted - %v", err)
+		return
 	}
-	autoscalingTarget := 100 // default for concurrency
-	if autoscalingMetric == "rps" {
-		autoscalingTarget = int(math.Round(1000.0 / function.RuntimeStats.Average))
-		// for rps mode use the average runtime in milliseconds to determine how many requests a pod can process per
-		// second, then round to an integer as that is what the knative config expects
+
+	for _, service := range d.services {
+		if service.Namespace == "" {
+			service.Namespace = d.namespace
+		}
+
+		err := d.client.Resource(knativeServiceResource).Namespace(service.Namespace).Delete(context.Background(), service.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Errorf("Unable to delete Knative service %s - %v", service.Name, err)
+		}
 	}
 
-	cmd := exec.Command(
-		"bash",
-		"./pkg/driver/deployment/knative.sh",
-		yamlPath,
-		function.Name,
+	log.Infof("Deleted %d Knative services.", len(d.services))
+	d.services = nil
+}
+
+func (d *knativeDeployer) Deployed() []DeployedService {
+	d.servicesMutex.Lock()
+	defer d.servicesMutex.Unlock()
 
-		strconv.Itoa(function.CPURequestsMilli)+"m",
-		strconv.Itoa(function.CPULimitsMilli)+"m",
-		strconv.Itoa(function.MemoryRequestsMiB)+"Mi",
-		strconv.Itoa(function.InitialScale),
-		panicWindow,
-		panicThreshold,
+	return append([]DeployedService(nil), d.services...)
+}
 
-		wrapString(autoscalingMetric),
-		wrapString(strconv.Itoa(autoscalingTarget)),
+// Restore makes Clean delete the services, which are in the namespace of the current context if they have none
+func (d *knativeDeployer) Restore(services []DeployedService) {
+	d.servicesMutex.Lock()
+	defer d.servicesMutex.Unlock()
 
-		wrapString(strconv.Itoa(function.ColdStartBusyLoopMs)),
-	)
+	d.services = append([]DeployedService(nil), services...)
+}
+
+func (d *knativeDeployer) deploySingleFunction(function *common.Function, serviceTemplate *template.Template, knativeConfig *knativeDeploymentConfiguration) bool {
+	service, err := renderKnativeService(function, serviceTemplate, knativeConfig)
+	if err != nil {
+		log.Warnf("Failed to render the service of function %s - %v", function.Name, err)
+		return false
+	}
 
-	stdoutStderr, err := cmd.CombinedOutput()
-	log.Debug("CMD response: ", string(stdoutStderr))
+	services := d.client.Resource(knativeServiceResource).Namespace(service.GetNamespace())
+	_, err = services.Create(context.Background(), service, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		// replace the service left by an earlier attempt of the run, as `kn service apply` would, but never the
+		// service of another run
+		var existing *unstructured.Unstructured
+		if existing, err = services.Get(context.Background(), service.GetName(), metav1.GetOptions{}); err == nil {
+			if runID := existing.GetLabels()[RunIDLabel]; runID != knativeConfig.RunID {
+				err = fmt.Errorf("service already exists and belongs to run '%s'", runID)
+			} else {
+				service.SetResourceVersion(existing.GetResourceVersion())
+				_, err = services.Update(context.Background(), service, metav1.UpdateOptions{})
+			}
+		}
+	}
 	if err != nil {
-		// TODO: there should be a toggle to turn off deployment because if this is fatal then we cannot test the thing locally
-		log.Warnf("Failed to deploy function %s: %v\n%s\n", function.Name, err, stdoutStderr)
+		log.Warnf("Failed to deploy function %s - %v", function.Name, err)
 		return false
 	}
-	if endpoint := urlRegex.FindStringSubmatch(string(stdoutStderr))[1]; endpoint != function.Endpoint {
-		// TODO: check when this situation happens
-		log.Debugf("Update function endpoint to %s\n", endpoint)
+
+	d.servicesMutex.Lock()
+	d.services = append(d.services, DeployedService{Name: service.GetName(), Namespace: service.GetNamespace()})
+	d.servicesMutex.Unlock()
+
+	url, err := d.waitForReady(service, knativeConfig.ReadyTimeout)
+	if err != nil {
+		log.Warnf("Function %s not ready - %v", function.Name, err)
+		return false
+	}
+
+	if endpoint := strings.TrimPrefix(strings.TrimPrefix(url, "http://"), "https://"); endpoint != "" {
 		function.Endpoint = endpoint
 	} else {
-		function.Endpoint = fmt.Sprintf("%s.%s.%s", function.Name, namespace, bareMetalLbGateway)
+		function.Endpoint = fmt.Sprintf("%s.%s.%s", function.Name, service.GetNamespace(), bareMetalLbGateway)
 	}
 	// adding port to the endpoint
-	function.Endpoint = fmt.Sprintf("%s:%d", function.Endpoint, endpointPort)
+	function.Endpoint = fmt.Sprintf("%s:%d", function.Endpoint, knativeConfig.EndpointPort)
 	log.Debugf("Deployed function on %s\n", function.Endpoint)
 
 	return true
 }
 
-func wrapString(value string) string {
-	return "\"" + value + "\""
+// renderKnativeService executes the service YAML template for the function and sets its autoscaling annotations
+func renderKnativeService(function *common.Function, serviceTemplate *template.Template, knativeConfig *knativeDeploymentConfiguration) (*unstructured.Unstructured, error) {
+	rendered := &bytes.Buffer{}
+	if err := serviceTemplate.Execute(rendered, function); err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.YAMLToJSON(rendered.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	service := &unstructured.Unstructured{}
+	if err = service.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	// the namespace of the experiment takes precedence over the one of the YAML, so that runs sharing a cluster can be
+	// kept apart without editing the workloads
+	service.SetNamespace(knativeConfig.Namespace)
+
+	labels := service.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[managedByLabel] = managedBy
+	annotations := service.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ownerAnnotation] = knativeConfig.Owner
+
+	panicWindow, panicThreshold := "10.0", "200.0"
+	if knativeConfig.IsPartiallyPanic {
+		panicWindow, panicThreshold = "100.0", "1000.0"
+	}
+	autoscalingTarget := 100 // default for concurrency
+	if knativeConfig.AutoscalingMetric == "rps" {
+		autoscalingTarget = int(math.Round(1000.0 / function.RuntimeStats.Average))
+		// for rps mode use the average runtime in milliseconds to determine how many requests a pod can process per
+		// second, then round to an integer as that is what the knative config expects
+	}
+
+	if _, found, _ := unstructured.NestedMap(service.Object, "spec", "template"); !found {
+		return nil, fmt.Errorf("no revision template in the service YAML")
+	}
+	revisionLabels, _, err := unstructured.NestedStringMap(service.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return nil, err
+	}
+	revisionAnnotations, _, err := unstructured.NestedStringMap(service.Object, "spec", "template", "metadata", "annotations")
+	if err != nil {
+		return nil, err
+	} else if revisionAnnotations == nil {
+		revisionAnnotations = make(map[string]string)
+	}
+
+	if knativeConfig.RunID != "" {
+		labels[RunIDLabel] = knativeConfig.RunID
+
+		// revisions and pods of the service are labelled with the run too
+		if revisionLabels == nil {
+			revisionLabels = make(map[string]string)
+		}
+		revisionLabels[RunIDLabel] = knativeConfig.RunID
+	}
+
+	revisionAnnotations["autoscaling.knative.dev/initial-scale"] = strconv.Itoa(function.InitialScale)
+	revisionAnnotations["autoscaling.knative.dev/panic-window-percentage"] = panicWindow
+	revisionAnnotations["autoscaling.knative.dev/panic-threshold-percentage"] = panicThreshold
+	revisionAnnotations["autoscaling.knative.dev/metric"] = knativeConfig.AutoscalingMetric
+	revisionAnnotations["autoscaling.knative.dev/target"] = strconv.Itoa(autoscalingTarget)
+
+	service.SetLabels(labels)
+	service.SetAnnotations(annotations)
+	if revisionLabels != nil {
+		if err = unstructured.SetNestedStringMap(service.Object, revisionLabels, "spec", "template", "metadata", "labels"); err != nil {
+			return nil, err
+		}
+	}
+	if err = unstructured.SetNestedStringMap(service.Object, revisionAnnotations, "spec", "template", "metadata", "annotations"); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// waitForReady polls the service until it is ready and returns its URL
+func (d *knativeDeployer) waitForReady(service *unstructured.Unstructured, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	services := d.client.Resource(knativeServiceResource).Namespace(service.GetNamespace())
+
+	for {
+		current, err := services.Get(context.Background(), service.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+
+		ready, err := knativeServiceReady(current)
+		if ready {
+			url, _, _ := unstructured.NestedString(current.Object, "status", "url")
+			return url, nil
+		} else if err != nil {
+			return "", err
+		} else if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out after %v", timeout)
+		}
+
+		time.Sleep(knativeReadyPollInterval)
+	}
 }
@@ -0,0 +1,63 @@
+package deployment
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	// authentication plugins of the auth-provider entries of kubeconfigs
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// knativeServiceResource Knative services, which are managed through the dynamic client as unstructured objects with
+// the specification kept as rendered from the service YAML
+var knativeServiceResource = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+
+// newKubernetesClient connects to the cluster of the current context of the kubeconfig in $KUBECONFIG or
+// ~/.kube/config, or, if there is none, to the cluster the loader runs in, as kubectl does. It returns the namespace of
+// the current context too.
+func newKubernetesClient() (dynamic.Interface, string, error) {
+	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	)
+
+	restConfig, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	contextNamespace, _, err := kubeconfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, contextNamespace, nil
+}
+
+// knativeServiceReady reports whether the service is ready, and the reason if it failed
+func knativeServiceReady(service *unstructured.Unstructured) (bool, error) {
+	conditions, _, _ := unstructured.NestedSlice(service.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		if condition["type"] != "Ready" {
+			continue
+		}
+
+		switch condition["status"] {
+		case "True":
+			return true, nil
+		case "False":
+			return false, fmt.Errorf("%v - %v", condition["reason"], condition["message"])
+		}
+	}
+
+	return false, nil
+}
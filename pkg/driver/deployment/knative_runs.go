@@ -0,0 +1,106 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// KnativeRun summarizes the Knative services a run of the loader has left on the cluster
+type KnativeRun struct {
+	RunID    string
+	Owner    string
+	Services int
+	// Created Creation time of the oldest service of the run
+	Created string
+}
+
+// ListKnativeRuns returns the runs of the loader with services in the namespace, or in all namespaces if empty
+func ListKnativeRuns(namespace string) ([]KnativeRun, error) {
+	client, _, err := newKubernetesClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return listKnativeRuns(client, namespace)
+}
+
+// CleanKnativeRun deletes the services of the run in the namespace, or in all namespaces if empty, and returns how
+// many were deleted
+func CleanKnativeRun(namespace string, runID string) (int, error) {
+	client, _, err := newKubernetesClient()
+	if err != nil {
+		return 0, err
+	}
+
+	return cleanKnativeRun(client, namespace, runID)
+}
+
+func listKnativeRuns(client dynamic.Interface, namespace string) ([]KnativeRun, error) {
+	services, err := client.Resource(knativeServiceResource).Namespace(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: RunIDLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make(map[string]*KnativeRun)
+	for _, service := range services.Items {
+		runID := service.GetLabels()[RunIDLabel]
+
+		run, ok := runs[runID]
+		if !ok {
+			run = &KnativeRun{RunID: runID, Owner: service.GetAnnotations()[ownerAnnotation]}
+			runs[runID] = run
+		}
+
+		run.Services++
+		created := service.GetCreationTimestamp().UTC().Format(time.RFC3339)
+		if run.Created == "" || created < run.Created {
+			run.Created = created
+		}
+	}
+
+	var result []KnativeRun
+	for _, run := range runs {
+		result = append(result, *run)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created < result[j].Created
+	})
+
+	return result, nil
+}
+
+func cleanKnativeRun(client dynamic.Interface, namespace string, runID string) (int, error) {
+	if runID == "" {
+		return 0, fmt.Errorf("no run ID given")
+	}
+
+	services, err := client.Resource(knativeServiceResource).Namespace(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: RunIDLabel + "=" + runID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	var errs []error
+	for _, service := range services.Items {
+		err = client.Resource(knativeServiceResource).Namespace(service.GetNamespace()).Delete(context.Background(), service.GetName(), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("service %s - %v", service.GetName(), err))
+			continue
+		}
+
+		deleted++
+	}
+
+	return deleted, errors.Join(errs...)
+}
@@ -0,0 +1,301 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "default"
+
+// newFakeKnativeClient returns a fake client holding the services, which makes services ready once they are created or
+// updated, unless their name is in failing
+func newFakeKnativeClient(failing map[string]bool, services ...*unstructured.Unstructured) *dynamicfake.FakeDynamicClient {
+	objects := make([]runtime.Object, len(services))
+	for i, service := range services {
+		objects[i] = service
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{knativeServiceResource: "ServiceList"}, objects...)
+
+	client.PrependReactor("*", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		// update actions carry the object as create actions do
+		write, ok := action.(k8stesting.CreateAction)
+		if !ok || (action.GetVerb() != "create" && action.GetVerb() != "update") {
+			return false, nil, nil
+		}
+
+		service := write.GetObject().(*unstructured.Unstructured)
+		status := map[string]interface{}{
+			"url":        fmt.Sprintf("http://%s.%s.example.com", service.GetName(), service.GetNamespace()),
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		}
+		if failing[service.GetName()] {
+			status = map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False", "reason": "RevisionFailed"}},
+			}
+		}
+
+		// not handled, so that the service with its status is stored by the client
+		return false, nil, unstructured.SetNestedField(service.Object, status, "status")
+	})
+
+	return client
+}
+
+func newTestKnativeService(name string, labels map[string]string, annotations map[string]string) *unstructured.Unstructured {
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("serving.knative.dev/v1")
+	service.SetKind("Service")
+	service.SetName(name)
+	service.SetNamespace(testNamespace)
+	service.SetLabels(labels)
+	service.SetAnnotations(annotations)
+
+	return service
+}
+
+// listFakeServices returns the services of the fake client by name
+func listFakeServices(t *testing.T, client dynamic.Interface) map[string]*unstructured.Unstructured {
+	list, err := client.Resource(knativeServiceResource).Namespace("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := make(map[string]*unstructured.Unstructured)
+	for i := range list.Items {
+		services[list.Items[i].GetName()] = &list.Items[i]
+	}
+
+	return services
+}
+
+func TestKnativeDeployer(t *testing.T) {
+	existing := newTestKnativeService("trace-func-1", map[string]string{RunIDLabel: "run"}, nil)
+	existing.SetResourceVersion("7")
+	client := newFakeKnativeClient(map[string]bool{"trace-func-2": true},
+		newTestKnativeService("unrelated", nil, nil),
+		existing,
+		newTestKnativeService("trace-func-3", map[string]string{RunIDLabel: "other-run"}, nil),
+	)
+
+	functions := make([]*common.Function, 4)
+	for i := range functions {
+		functions[i] = &common.Function{
+			Name:                fmt.Sprintf("trace-func-%d", i),
+			InitialScale:        i,
+			ColdStartBusyLoopMs: 5,
+			CPURequestsMilli:    100,
+			CPULimitsMilli:      1000,
+			MemoryRequestsMiB:   256,
+			RuntimeStats:        &common.FunctionRuntimeStats{Average: 100},
+		}
+	}
+
+	deployer := &knativeDeployer{client: client, namespace: testNamespace}
+	deployer.Deploy(&config.Configuration{
+		LoaderConfiguration: &config.LoaderConfiguration{
+			EndpointPort:      80,
+			AutoscalingMetric: "rps",
+			RunID:             "run",
+		},
+		Functions: functions,
+		YAMLPath:  "../../../workloads/container/trace_func_go.yaml",
+	})
+
+	if functions[0].Endpoint != "trace-func-0.default.example.com:80" || functions[1].Endpoint != "trace-func-1.default.example.com:80" {
+		t.Errorf("Unexpected endpoints %s and %s.", functions[0].Endpoint, functions[1].Endpoint)
+	}
+	if functions[2].Endpoint != "" {
+		t.Errorf("Function that never became ready should have no endpoint, got %s.", functions[2].Endpoint)
+	}
+
+	services := listFakeServices(t, client)
+	if functions[3].Endpoint != "" || services["trace-func-3"].GetLabels()[RunIDLabel] != "other-run" {
+		t.Errorf("Service of another run should not be replaced.")
+	}
+	if labels := services["trace-func-0"].GetLabels(); labels[RunIDLabel] != "run" || labels[managedByLabel] != managedBy {
+		t.Errorf("Unexpected labels %v.", labels)
+	}
+
+	var updated []string
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" {
+			service := action.(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+			updated = append(updated, service.GetName()+"@"+service.GetResourceVersion())
+		}
+	}
+	if len(updated) != 1 || updated[0] != "trace-func-1@7" {
+		t.Errorf("Expected the existing service to be updated, got %v.", updated)
+	}
+
+	spec := services["trace-func-0"].Object["spec"].(map[string]interface{})["template"].(map[string]interface{})
+	annotations := spec["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if annotations["autoscaling.knative.dev/initial-scale"] != "0" || annotations["autoscaling.knative.dev/metric"] != "rps" ||
+		annotations["autoscaling.knative.dev/target"] != "10" || annotations["autoscaling.knative.dev/panic-window-percentage"] != "10.0" {
+
+		t.Errorf("Unexpected annotations %v.", annotations)
+	}
+
+	container := spec["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	resources := container["resources"].(map[string]interface{})
+	if resources["limits"].(map[string]interface{})["cpu"] != "1000m" || resources["requests"].(map[string]interface{})["memory"] != "256Mi" {
+		t.Errorf("Unexpected resources %v.", resources)
+	}
+
+	deployer.Clean()
+	services = listFakeServices(t, client)
+	if len(services) != 2 || services["unrelated"] == nil || services["trace-func-3"] == nil {
+		t.Errorf("Expected only the services of the deployer to be deleted, %d services left.", len(services))
+	}
+}
+
+func TestKnativeRuns(t *testing.T) {
+	services := []*unstructured.Unstructured{newTestKnativeService("unrelated", nil, nil)}
+	for i, runID := range []string{"old", "old", "new"} {
+		service := newTestKnativeService(fmt.Sprintf("trace-func-%s-%d", runID, i),
+			map[string]string{RunIDLabel: runID}, map[string]string{ownerAnnotation: "user@host"})
+		service.SetCreationTimestamp(metav1.Date(2026, 10, 10+i, 0, 0, 0, 0, time.UTC))
+
+		services = append(services, service)
+	}
+	client := newFakeKnativeClient(nil, services...)
+
+	runs, err := listKnativeRuns(client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].RunID != "old" || runs[0].Services != 2 || runs[0].Created != "2026-10-10T00:00:00Z" ||
+		runs[1].RunID != "new" || runs[1].Owner != "user@host" {
+
+		t.Errorf("Unexpected runs %+v.", runs)
+	}
+
+	deleted, err := cleanKnativeRun(client, testNamespace, "old")
+	if err != nil || deleted != 2 {
+		t.Errorf("Expected the 2 services of the old run to be deleted, got %d - %v.", deleted, err)
+	}
+	if left := listFakeServices(t, client); len(left) != 2 || left["unrelated"] == nil || left["trace-func-new-2"] == nil {
+		t.Errorf("Expected the services of other runs to be left, got %d services.", len(left))
+	}
+}
+
+func TestKnativeDeployerRestore(t *testing.T) {
+	client := newFakeKnativeClient(nil, newTestKnativeService("unrelated", nil, nil), newTestKnativeService("trace-func-0", nil, nil))
+
+	deployer := &knativeDeployer{client: client, namespace: testNamespace}
+	deployer.Restore([]DeployedService{{Name: "trace-func-0"}})
+
+	if deployed := deployer.Deployed(); len(deployed) != 1 || deployed[0].Name != "trace-func-0" {
+		t.Errorf("Expected the restored service, got %v.", deployed)
+	}
+
+	deployer.Clean()
+	if left := listFakeServices(t, client); len(left) != 1 || left["unrelated"] == nil {
+		t.Errorf("Expected only the restored service to be deleted in the namespace of the context, %d services left.", len(left))
+	}
+}
+
+func TestKubernetesClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := `
+apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context: {cluster: cluster, user: user, namespace: experiments}
+clusters:
+- name: cluster
+  cluster: {server: "https://127.0.0.1:6443"}
+users:
+- name: user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: credential-helper
+      interactiveMode: Never
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+
+	client, contextNamespace, err := newKubernetesClient()
+	if err != nil || client == nil {
+		t.Fatalf("Expected a client for the kubeconfig with an exec plugin, got %v.", err)
+	}
+	if contextNamespace != "experiments" {
+		t.Errorf("Expected the namespace of the current context, got %s.", contextNamespace)
+	}
+}
+
+func TestKnativeDeployerWithoutCluster(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	functions := []*common.Function{{Name: "trace-func-0"}}
+
+	// with the readiness gate, the functions are left without an endpoint instead of exiting
+	deployer := newKnativeDeployer()
+	deployer.Deploy(&config.Configuration{
+		LoaderConfiguration: &config.LoaderConfiguration{ReadinessTimeoutSeconds: 1},
+		Functions:           functions,
+		YAMLPath:            "../../../workloads/container/trace_func_go.yaml",
+	})
+
+	if functions[0].Endpoint != "" || len(deployer.Deployed()) != 0 {
+		t.Errorf("Expected no function to be deployed without a cluster.")
+	}
+}
+
+func TestKnativeDeployerNamespace(t *testing.T) {
+	for _, workload := range []string{"container", "firecracker"} {
+		for _, namespace := range []string{"experiments", ""} {
+			client := newFakeKnativeClient(nil)
+			functions := []*common.Function{{Name: "trace-func-0", RuntimeStats: &common.FunctionRuntimeStats{Average: 100}}}
+
+			// the namespace of the current context is used if the configuration has none
+			deployer := &knativeDeployer{client: client, namespace: "team"}
+			deployer.Deploy(&config.Configuration{
+				LoaderConfiguration: &config.LoaderConfiguration{
+					EndpointPort:      80,
+					AutoscalingMetric: "rps",
+					Namespace:         namespace,
+				},
+				Functions: functions,
+				YAMLPath:  fmt.Sprintf("../../../workloads/%s/trace_func_go.yaml", workload),
+			})
+
+			expected := namespace
+			if expected == "" {
+				expected = "team"
+			}
+
+			service := listFakeServices(t, client)["trace-func-0"]
+			if service == nil || service.GetNamespace() != expected {
+				t.Fatalf("Expected the %s service to be deployed in the namespace %s.", workload, expected)
+			}
+			if endpoint := fmt.Sprintf("trace-func-0.%s.example.com:80", expected); functions[0].Endpoint != endpoint {
+				t.Errorf("Expected the endpoint %s, got %s.", endpoint, functions[0].Endpoint)
+			}
+			if deployed := deployer.Deployed(); len(deployed) != 1 || deployed[0].Namespace != expected {
+				t.Errorf("Expected the service to be cleaned up in the namespace %s, got %v.", expected, deployed)
+			}
+		}
+	}
+}
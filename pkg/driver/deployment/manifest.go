@@ -0,0 +1,79 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// DeployedService identifies a service created on the platform
+type DeployedService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ManifestFunction is a function of the experiment and the endpoint it is invoked on. The function is identified by the
+// hashes of its row in the trace, as its name in the trace changes every time the trace is parsed.
+type ManifestFunction struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	// TraceName Name of the function in the trace of the deploying run
+	TraceName    string `json:"traceName,omitempty"`
+	HashOwner    string `json:"hashOwner,omitempty"`
+	HashApp      string `json:"hashApp,omitempty"`
+	HashFunction string `json:"hashFunction,omitempty"`
+}
+
+// EndpointManifest records a deployment, so that later runs of the loader can invoke its functions or delete it
+type EndpointManifest struct {
+	Platform string `json:"platform"`
+	RunID    string `json:"runID"`
+	// Functions Functions of the experiment in the order of the trace, which can share a service with DeployPerApp
+	Functions []ManifestFunction `json:"functions"`
+	// Services Services created on the platform, which clean-up deletes
+	Services []DeployedService `json:"services"`
+}
+
+func WriteEndpointManifest(path string, manifest *EndpointManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func ReadEndpointManifest(path string) (*EndpointManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &EndpointManifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid endpoint manifest %s - %v", path, err)
+	}
+
+	return manifest, nil
+}
+
+// functionServices returns the services of platforms deploying a service per function under the name of the function
+func functionServices(functions []*common.Function) []DeployedService {
+	services := make([]DeployedService, len(functions))
+	for i, function := range functions {
+		services[i] = DeployedService{Name: function.Name}
+	}
+
+	return services
+}
+
+func serviceFunctions(services []DeployedService) []*common.Function {
+	functions := make([]*common.Function, len(services))
+	for i, service := range services {
+		functions[i] = &common.Function{Name: service.Name}
+	}
+
+	return functions
+}
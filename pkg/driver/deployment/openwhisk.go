@@ -48,6 +48,14 @@ func (owd *openWhiskDeployer) Deploy(cfg *config.Configuration) {
 	}
 }
 
+func (owd *openWhiskDeployer) Deployed() []DeployedService {
+	return functionServices(owd.functions)
+}
+
+func (owd *openWhiskDeployer) Restore(services []DeployedService) {
+	owd.functions = serviceFunctions(services)
+}
+
 func (owd *openWhiskDeployer) Clean() {
 	for i := 0; i < len(owd.functions); i++ {
 		// TODO: check if there is a command such as "... delete --all"
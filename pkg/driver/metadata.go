@@ -0,0 +1,112 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// ExperimentMetadata describes how the workload of an experiment has been generated. It is written next to the
+// experiment results so that the results can be interpreted without the original configuration.
+type ExperimentMetadata struct {
+	Seed      int64  `json:"Seed"`
+	SeedMode  string `json:"SeedMode"`
+	TracePath string `json:"TracePath"`
+	// TraceStartMinute Minute of the trace the experiment starts at, i.e., the offset of the traceMinute column
+	TraceStartMinute int    `json:"TraceStartMinute"`
+	IATDistribution  string `json:"IATDistribution"`
+
+	RunID string `json:"RunID"`
+	// FunctionNames Names of the functions in the trace by the run-scoped names they are deployed under
+	FunctionNames map[string]string `json:"FunctionNames,omitempty"`
+
+	FunctionOverrides []AppliedFunctionOverride `json:"FunctionOverrides"`
+}
+
+type AppliedFunctionOverride struct {
+	Function     string                   `json:"Function"`
+	HashFunction string                   `json:"HashFunction"`
+	Override     *common.FunctionOverride `json:"Override"`
+}
+
+func (d *Driver) metadataFilename() string {
+	return fmt.Sprintf("%s_metadata_%d.json", d.Configuration.LoaderConfiguration.OutputPathPrefix, d.Configuration.TraceDuration)
+}
+
+func (d *Driver) composeExperimentMetadata() *ExperimentMetadata {
+	metadata := &ExperimentMetadata{
+		Seed:              d.Configuration.LoaderConfiguration.Seed,
+		SeedMode:          d.Configuration.LoaderConfiguration.SeedMode,
+		TracePath:         d.Configuration.LoaderConfiguration.TracePath,
+		TraceStartMinute:  d.Configuration.LoaderConfiguration.TraceStartMinute,
+		IATDistribution:   d.Configuration.LoaderConfiguration.IATDistribution,
+		RunID:             d.Configuration.LoaderConfiguration.RunID,
+		FunctionOverrides: []AppliedFunctionOverride{},
+	}
+
+	for _, function := range d.Configuration.Functions {
+		if function.TraceName != "" {
+			if metadata.FunctionNames == nil {
+				metadata.FunctionNames = make(map[string]string)
+			}
+
+			metadata.FunctionNames[function.Name] = function.TraceName
+		}
+
+		if function.Override == nil {
+			continue
+		}
+
+		var hashFunction string
+		if function.InvocationStats != nil {
+			hashFunction = function.InvocationStats.HashFunction
+		}
+
+		metadata.FunctionOverrides = append(metadata.FunctionOverrides, AppliedFunctionOverride{
+			Function:     function.Name,
+			HashFunction: hashFunction,
+			Override:     function.Override,
+		})
+	}
+
+	return metadata
+}
+
+func (d *Driver) writeExperimentMetadata() {
+	data, err := json.MarshalIndent(d.composeExperimentMetadata(), "", "  ")
+	if err != nil {
+		log.Errorf("Failed to serialize experiment metadata - %v", err)
+		return
+	}
+
+	if err = os.WriteFile(d.metadataFilename(), data, 0644); err != nil {
+		log.Errorf("Failed to write experiment metadata - %v", err)
+	}
+}
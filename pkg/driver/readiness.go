@@ -0,0 +1,232 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/driver/deployment"
+	mc "github.com/vhive-serverless/loader/pkg/metric"
+)
+
+const (
+	// AbortReadinessPolicy aborts the experiment if any function is not ready by the deadline
+	AbortReadinessPolicy = "abort"
+	// ExcludeReadinessPolicy runs the experiment without the functions not ready by the deadline
+	ExcludeReadinessPolicy = "exclude"
+
+	readinessProbeInterval = time.Second
+	// readinessProbeConcurrency Number of functions probed at the same time
+	readinessProbeConcurrency = 64
+)
+
+// probeSpecification is the lightweight invocation probing whether a function is ready
+var probeSpecification = common.RuntimeSpecification{Runtime: 1, Memory: 1}
+
+// awaitReadiness probes the deployed functions until they respond or the deadline passes, records the outcome of the
+// probes per function, and returns the functions that are not ready
+func (d *Driver) awaitReadiness(deploymentStart time.Time, timeout time.Duration) []*common.Function {
+	deadline := time.Now().Add(timeout)
+	records := make([]*mc.DeploymentRecord, len(d.Configuration.Functions))
+
+	queue := make(chan struct{}, readinessProbeConcurrency)
+	probed := sync.WaitGroup{}
+	probed.Add(len(d.Configuration.Functions))
+
+	for i, function := range d.Configuration.Functions {
+		go func() {
+			queue <- struct{}{}
+
+			defer probed.Done()
+			defer func() { <-queue }()
+
+			records[i] = d.probeFunction(function, deploymentStart, deadline)
+		}()
+	}
+
+	probed.Wait()
+
+	var notReady []*common.Function
+	for i, record := range records {
+		if !record.Ready {
+			notReady = append(notReady, d.Configuration.Functions[i])
+		}
+	}
+
+	d.writeDeploymentRecords(records)
+	log.Infof("%d out of %d functions are ready.", len(records)-len(notReady), len(records))
+
+	return notReady
+}
+
+func (d *Driver) probeFunction(function *common.Function, deploymentStart time.Time, deadline time.Time) *mc.DeploymentRecord {
+	record := &mc.DeploymentRecord{
+		Function: function.Name,
+		Endpoint: function.Endpoint,
+	}
+
+	if function.Endpoint == "" {
+		record.Error = "not deployed"
+		record.DeployLatency = time.Since(deploymentStart).Milliseconds()
+
+		return record
+	}
+
+	for {
+		record.Probes++
+		success, execution := d.Invoker.Invoke(function, &probeSpecification)
+		record.DeployLatency = time.Since(deploymentStart).Milliseconds()
+
+		if success {
+			record.Ready = true
+			record.Error = ""
+
+			return record
+		}
+
+		switch {
+		case execution == nil:
+			record.Error = "probe failed"
+		case execution.ConnectionTimeout:
+			record.Error = "connection failed"
+		default:
+			record.Error = "invocation failed"
+		}
+
+		if time.Now().Add(readinessProbeInterval).After(deadline) {
+			log.Warnf("Function %s not ready after %d probes - %s", function.Name, record.Probes, record.Error)
+			return record
+		}
+
+		time.Sleep(readinessProbeInterval)
+	}
+}
+
+func (d *Driver) writeDeploymentRecords(deploymentRecords []*mc.DeploymentRecord) {
+	records := make(chan interface{}, 100)
+	writerDone := sync.WaitGroup{}
+	writerDone.Add(1)
+	go mc.RunCSVWriter(records, d.outputFilename("deployment"), &writerDone)
+
+	for _, record := range deploymentRecords {
+		records <- record
+	}
+
+	close(records)
+	writerDone.Wait()
+}
+
+// excludeFunctions removes the functions from the experiment, together with the explicitly defined workflows
+// containing any of them, and reports whether anything is left to run
+func (d *Driver) excludeFunctions(excluded []*common.Function) bool {
+	isExcluded := make(map[*common.Function]bool)
+	for _, function := range excluded {
+		isExcluded[function] = true
+	}
+
+	var functions []*common.Function
+	for _, function := range d.Configuration.Functions {
+		if !isExcluded[function] {
+			functions = append(functions, function)
+		}
+	}
+
+	var workflows []*list.List
+	for _, workflow := range d.Configuration.Workflows {
+		if !workflowInvokes(workflow, isExcluded, make(map[*list.List]bool)) {
+			workflows = append(workflows, workflow)
+		}
+	}
+
+	if len(functions) == 0 || (len(d.Configuration.Workflows) > 0 && len(workflows) == 0) {
+		return false
+	}
+
+	log.Warnf("Excluding %d functions that are not ready from the experiment.", len(excluded))
+	if dropped := len(d.Configuration.Workflows) - len(workflows); dropped > 0 {
+		log.Warnf("Excluding %d workflows with functions that are not ready from the experiment.", dropped)
+	}
+	d.Configuration.Functions, d.Configuration.Workflows = functions, workflows
+
+	return true
+}
+
+// workflowInvokes reports whether any node of the workflow invokes one of the functions. Joins are reached through
+// several parents, so visited keeps the node lists already searched.
+func workflowInvokes(nodes *list.List, functions map[*common.Function]bool, visited map[*list.List]bool) bool {
+	if visited[nodes] {
+		return false
+	}
+	visited[nodes] = true
+
+	for element := nodes.Front(); element != nil; element = element.Next() {
+		node := element.Value.(*common.Node)
+		if functions[node.Function] {
+			return true
+		}
+
+		for _, branch := range node.Branches {
+			if workflowInvokes(branch, functions, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// gateOnReadiness waits for the deployed functions to become ready, and either aborts the experiment or excludes the
+// functions that are not ready by the deadline, according to the readiness policy
+func (d *Driver) gateOnReadiness(deployer deployment.FunctionDeployer, deploymentStart time.Time, timeout time.Duration) {
+	platform := d.Configuration.LoaderConfiguration.Platform
+	if platform == "AWSLambda" || platform == "OpenWhisk" {
+		log.Warnf("Readiness probes are not supported on %s, starting the experiment without them.", platform)
+		return
+	}
+
+	notReady := d.awaitReadiness(deploymentStart, timeout)
+	if len(notReady) == 0 {
+		return
+	}
+
+	if d.Configuration.LoaderConfiguration.ReadinessPolicy == ExcludeReadinessPolicy && d.excludeFunctions(notReady) {
+		return
+	}
+
+	var names []string
+	for _, function := range notReady {
+		names = append(names, function.Name)
+	}
+
+	if deployer != nil {
+		deployer.Clean()
+	}
+	log.Fatalf("%d functions not ready after %v: %s", len(notReady), timeout, strings.Join(names, ", "))
+}
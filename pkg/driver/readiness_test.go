@@ -0,0 +1,143 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/metric"
+)
+
+// flakyInvoker fails the first invocations of each function, as many as set in failures
+type flakyInvoker struct {
+	mutex    sync.Mutex
+	failures map[string]int
+}
+
+func (i *flakyInvoker) Invoke(function *common.Function, _ *common.RuntimeSpecification) (bool, *metric.ExecutionRecord) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.failures[function.Name] > 0 {
+		i.failures[function.Name]--
+		return false, &metric.ExecutionRecord{ExecutionRecordBase: metric.ExecutionRecordBase{ConnectionTimeout: true}}
+	}
+
+	return true, &metric.ExecutionRecord{}
+}
+
+func TestReadinessGate(t *testing.T) {
+	testDriver := createTestDriver([]int{1})
+	testDriver.Configuration.Functions = []*common.Function{
+		{Name: "ready", Endpoint: "ready:80"},
+		{Name: "flaky", Endpoint: "flaky:80"},
+		{Name: "unreachable", Endpoint: "unreachable:80"},
+		{Name: "undeployed"},
+	}
+	testDriver.Invoker = &flakyInvoker{failures: map[string]int{"flaky": 1, "unreachable": 1000}}
+
+	notReady := testDriver.awaitReadiness(time.Now(), 3*time.Second)
+	defer os.Remove(testDriver.outputFilename("deployment"))
+
+	if len(notReady) != 2 || notReady[0].Name != "unreachable" || notReady[1].Name != "undeployed" {
+		t.Fatalf("Expected the unreachable and undeployed functions not to be ready, got %v.", notReady)
+	}
+
+	file, err := os.Open(testDriver.outputFilename("deployment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []metric.DeploymentRecord
+	if err = gocsv.UnmarshalFile(file, &records); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		ready  bool
+		probes int
+		err    string
+	}{
+		{ready: true, probes: 1},
+		{ready: true, probes: 2},
+		{ready: false, probes: 3, err: "connection failed"},
+		{ready: false, probes: 0, err: "not deployed"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d deployment records, got %d.", len(expected), len(records))
+	}
+	for i, record := range records {
+		if record.Ready != expected[i].ready || record.Probes != expected[i].probes || record.Error != expected[i].err {
+			t.Errorf("Unexpected deployment record %+v.", record)
+		}
+	}
+	if records[1].DeployLatency < records[0].DeployLatency || records[1].DeployLatency < readinessProbeInterval.Milliseconds() {
+		t.Errorf("Expected the latency of the flaky function to include the probe interval, got %d.", records[1].DeployLatency)
+	}
+
+	if !testDriver.excludeFunctions(notReady) {
+		t.Fatal("Expected the ready functions to be left to run.")
+	}
+	if len(testDriver.Configuration.Functions) != 2 || testDriver.Configuration.Functions[1].Name != "flaky" {
+		t.Errorf("Expected only the ready functions to remain, got %d.", len(testDriver.Configuration.Functions))
+	}
+}
+
+func TestExcludeFunctionsOfWorkflows(t *testing.T) {
+	functions := []*common.Function{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+
+	// a -> (b, c) -> d, with d joining both branches, and a workflow of a alone
+	join := list.New()
+	join.PushBack(&common.Node{Name: "d", Function: functions[3], Parents: 2})
+	left, right := list.New(), list.New()
+	left.PushBack(&common.Node{Name: "b", Function: functions[1], Branches: []*list.List{join}})
+	right.PushBack(&common.Node{Name: "c", Function: functions[2], Branches: []*list.List{join}})
+	diamond := list.New()
+	diamond.PushBack(&common.Node{Name: "a", Function: functions[0], Branches: []*list.List{left, right}})
+	single := list.New()
+	single.PushBack(&common.Node{Name: "a", Function: functions[0]})
+
+	testDriver := createTestDriver([]int{1})
+	testDriver.Configuration.Functions = functions
+	testDriver.Configuration.Workflows = []*list.List{diamond, single}
+
+	if !testDriver.excludeFunctions([]*common.Function{functions[3]}) {
+		t.Fatal("Expected the workflow without the excluded function to be left to run.")
+	}
+	if len(testDriver.Configuration.Workflows) != 1 || testDriver.Configuration.Workflows[0] != single {
+		t.Errorf("Expected only the workflow without the excluded join to remain, got %d.", len(testDriver.Configuration.Workflows))
+	}
+
+	if testDriver.excludeFunctions([]*common.Function{functions[0]}) || len(testDriver.Configuration.Workflows) != 1 {
+		t.Error("Expected nothing to be excluded once no workflow would be left.")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"strings"
+
+	"github.com/vhive-serverless/loader/pkg/config"
+	"github.com/vhive-serverless/loader/pkg/driver/deployment"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DeployOnlyRunMode deploys the functions and writes the endpoint manifest without invoking them
+	DeployOnlyRunMode = "deploy-only"
+	// RunOnlyRunMode invokes the functions of the endpoint manifest without deploying or deleting them
+	RunOnlyRunMode = "run-only"
+	// CleanOnlyRunMode deletes the services of the endpoint manifest
+	CleanOnlyRunMode = "clean-only"
+)
+
+func (d *Driver) writeEndpointManifest(deployer deployment.FunctionDeployer) {
+	if d.Configuration.EndpointManifestPath == "" {
+		return
+	}
+
+	manifest := &deployment.EndpointManifest{
+		Platform: d.Configuration.LoaderConfiguration.Platform,
+		RunID:    d.Configuration.LoaderConfiguration.RunID,
+		Services: deployer.Deployed(),
+	}
+	for _, function := range d.Configuration.Functions {
+		entry := deployment.ManifestFunction{
+			Name:      function.Name,
+			Endpoint:  function.Endpoint,
+			TraceName: function.TraceName,
+		}
+		if entry.TraceName == "" {
+			entry.TraceName = function.Name
+		}
+		if function.InvocationStats != nil {
+			entry.HashOwner = function.InvocationStats.HashOwner
+			entry.HashApp = function.InvocationStats.HashApp
+			entry.HashFunction = function.InvocationStats.HashFunction
+		}
+
+		manifest.Functions = append(manifest.Functions, entry)
+	}
+
+	if err := deployment.WriteEndpointManifest(d.Configuration.EndpointManifestPath, manifest); err != nil {
+		log.Errorf("Failed to write the endpoint manifest - %v", err)
+		return
+	}
+
+	log.Infof("Deployed %d services, listed in %s.", len(manifest.Services), d.Configuration.EndpointManifestPath)
+}
+
+// useEndpointManifest assigns the functions the names and endpoints of the functions deployed for the same trace by a
+// run in the deploy-only mode
+func (d *Driver) useEndpointManifest() {
+	manifest := readEndpointManifest(d.Configuration)
+
+	deployed := make(map[traceFunctionKey]deployment.ManifestFunction)
+	deployedKeys := make(traceFunctionKeys)
+	for _, function := range manifest.Functions {
+		deployed[deployedKeys.next(function.HashOwner, function.HashApp, function.HashFunction)] = function
+	}
+
+	var missing []string
+	experimentKeys := functionKeys(d.Configuration.Functions)
+	for i, function := range d.Configuration.Functions {
+		entry, ok := deployed[experimentKeys[i]]
+		if !ok {
+			missing = append(missing, function.Name)
+			continue
+		}
+
+		function.TraceName = function.Name
+		function.Name = entry.Name
+		function.Endpoint = entry.Endpoint
+	}
+
+	if len(missing) > 0 {
+		log.Fatalf("The endpoint manifest lists no deployed function for %d functions of the experiment: %s",
+			len(missing), strings.Join(missing, ", "))
+	}
+
+	d.Configuration.LoaderConfiguration.RunID = manifest.RunID
+	log.Infof("Invoking %d functions deployed earlier by run %s.", len(d.Configuration.Functions), manifest.RunID)
+}
+
+// CleanDeployment deletes the services listed in the endpoint manifest
+func CleanDeployment(cfg *config.Configuration) {
+	manifest := readEndpointManifest(cfg)
+
+	deployer := deployment.CreateDeployer(cfg)
+	deployer.Restore(manifest.Services)
+	deployer.Clean()
+}
+
+func readEndpointManifest(cfg *config.Configuration) *deployment.EndpointManifest {
+	manifest, err := deployment.ReadEndpointManifest(cfg.EndpointManifestPath)
+	if err != nil {
+		log.Fatalf("Failed to read the endpoint manifest - %v", err)
+	}
+
+	if manifest.Platform != cfg.LoaderConfiguration.Platform {
+		log.Fatalf("The endpoint manifest is of platform %s, not %s.", manifest.Platform, cfg.LoaderConfiguration.Platform)
+	}
+
+	return manifest
+}
@@ -0,0 +1,104 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/config"
+	"github.com/vhive-serverless/loader/pkg/driver/deployment"
+)
+
+// fakeDeployer sets the endpoint of every function to its name
+type fakeDeployer struct {
+	services []deployment.DeployedService
+}
+
+func (d *fakeDeployer) Deploy(cfg *config.Configuration) {
+	for _, function := range cfg.Functions {
+		function.Endpoint = function.Name + ":80"
+		d.services = append(d.services, deployment.DeployedService{Name: function.Name})
+	}
+}
+
+func (d *fakeDeployer) Clean() {
+	d.services = nil
+}
+
+func (d *fakeDeployer) Deployed() []deployment.DeployedService {
+	return d.services
+}
+
+func (d *fakeDeployer) Restore(services []deployment.DeployedService) {
+	d.services = services
+}
+
+func TestEndpointManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "endpoints.json")
+
+	deployDriver := createTestDriver([]int{1})
+	deployDriver.Configuration.EndpointManifestPath = manifestPath
+	deployDriver.Configuration.Functions = []*common.Function{
+		{Name: "trace-func-0-42", InvocationStats: &common.FunctionInvocationStats{HashFunction: "a"}},
+		{Name: "trace-func-1-7", InvocationStats: &common.FunctionInvocationStats{HashFunction: "b"}},
+		{Name: "trace-func-2-9", InvocationStats: &common.FunctionInvocationStats{HashFunction: "b"}},
+	}
+
+	deployer := &fakeDeployer{}
+	deployer.Deploy(deployDriver.Configuration)
+	deployDriver.writeEndpointManifest(deployer)
+
+	// function names are random, so the functions of another run of the same trace are named differently, and the
+	// functions are matched by their hashes, in order for the functions with the same hashes
+	runDriver := createTestDriver([]int{1})
+	runDriver.Configuration.EndpointManifestPath = manifestPath
+	runDriver.Configuration.Functions = []*common.Function{
+		{Name: "trace-func-0-3", InvocationStats: &common.FunctionInvocationStats{HashFunction: "b"}},
+		{Name: "trace-func-1-5", InvocationStats: &common.FunctionInvocationStats{HashFunction: "a"}},
+		{Name: "trace-func-2-1", InvocationStats: &common.FunctionInvocationStats{HashFunction: "b"}},
+	}
+	runDriver.useEndpointManifest()
+
+	for i, expected := range []int{1, 0, 2} {
+		function, deployed := runDriver.Configuration.Functions[i], deployDriver.Configuration.Functions[expected]
+		if function.Name != deployed.Name || function.Endpoint != deployed.Endpoint {
+			t.Errorf("Expected function %s on %s, got %s on %s.", deployed.Name, deployed.Endpoint, function.Name, function.Endpoint)
+		}
+	}
+
+	manifest, err := deployment.ReadEndpointManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Platform != deployDriver.Configuration.LoaderConfiguration.Platform ||
+		len(manifest.Services) != 3 || manifest.Services[1].Name != "trace-func-1-7" ||
+		manifest.Functions[1].TraceName != "trace-func-1-7" || manifest.Functions[1].HashFunction != "b" {
+
+		t.Errorf("Unexpected manifest %+v.", manifest)
+	}
+}
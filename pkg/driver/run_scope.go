@@ -0,0 +1,71 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func (d *Driver) runScope() string {
+	if d.Configuration.LoaderConfiguration.RunID == "" {
+		return ""
+	}
+
+	return common.RunScope(d.Configuration.LoaderConfiguration.FunctionNamePrefix, d.Configuration.LoaderConfiguration.RunID)
+}
+
+// applyRunScope renames the functions after the run, so that runs sharing a cluster do not deploy functions of the
+// same name
+func (d *Driver) applyRunScope() {
+	scope := d.runScope()
+	if scope == "" {
+		return
+	}
+
+	for i, function := range d.Configuration.Functions {
+		function.TraceName = function.Name
+		function.Name = common.RunScopedName(scope, i)
+	}
+}
+
+// applyServiceRunScope renames the services shared by the functions of an application after the run
+func (d *Driver) applyServiceRunScope(services []*common.Function) {
+	scope := d.runScope()
+	if scope == "" {
+		return
+	}
+
+	renamed := make(map[string]string)
+	for i, service := range services {
+		renamed[service.Name] = common.RunScopedName(scope+"-app", i)
+		service.Name = renamed[service.Name]
+	}
+
+	for _, function := range d.Configuration.Functions {
+		if name, ok := renamed[function.ServiceName]; ok {
+			function.ServiceName = name
+		}
+	}
+}
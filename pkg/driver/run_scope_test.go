@@ -0,0 +1,63 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestRunScope(t *testing.T) {
+	testDriver := createTestDriver([]int{1})
+	testDriver.Configuration.LoaderConfiguration.RunID = "20261019-143046-k3x9"
+	testDriver.Configuration.LoaderConfiguration.FunctionNamePrefix = "alice"
+	testDriver.Configuration.Functions = []*common.Function{
+		{Name: "trace-func-0-2642643831809466437"},
+		{Name: "trace-func-1-5577006791947779410"},
+	}
+
+	testDriver.applyRunScope()
+
+	for i, function := range testDriver.Configuration.Functions {
+		if expected := common.RunScopedName("alice-20261019-143046-k3x9", i); function.Name != expected {
+			t.Errorf("Expected function %s, got %s.", expected, function.Name)
+		}
+		if common.GetName(function) != i {
+			t.Errorf("Expected index %d of function %s, got %d.", i, function.Name, common.GetName(function))
+		}
+	}
+
+	metadata := testDriver.composeExperimentMetadata()
+	if metadata.RunID != "20261019-143046-k3x9" || metadata.FunctionNames["alice-20261019-143046-k3x9-1"] != "trace-func-1-5577006791947779410" {
+		t.Errorf("Unexpected metadata %+v.", metadata)
+	}
+
+	testDriver.Configuration.Functions[1].ServiceName = "trace-func-0-app"
+	testDriver.applyServiceRunScope([]*common.Function{{Name: "trace-func-0-app"}})
+	if testDriver.Configuration.Functions[1].ServiceName != "alice-20261019-143046-k3x9-app-0" {
+		t.Errorf("Unexpected service %s.", testDriver.Configuration.Functions[1].ServiceName)
+	}
+}
@@ -26,10 +26,9 @@ package driver
 
 import (
 	"container/list"
-	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,16 +51,24 @@ type Driver struct {
 	Invoker                clients.Invoker
 
 	AsyncRecords          *common.LockFreeQueue[*mc.ExecutionRecord]
+	WorkflowRecords       *common.LockFreeQueue[*mc.WorkflowRecord]
 	readOpenWhiskMetadata sync.Mutex
 	allFunctionsInvoked   sync.WaitGroup
+	// payloadUnsupported Warns once that the invoker cannot send the payloads of workflow edges
+	payloadUnsupported sync.Once
 }
 
 func NewDriver(driverConfig *config.Configuration) *Driver {
+	specificationGenerator := generator.NewSpecificationGenerator(driverConfig.LoaderConfiguration.Seed).
+		WithExecutionSampling(driverConfig.ExecutionSampling, driverConfig.LoaderConfiguration.RuntimeMemoryCorrelation).
+		WithSeedMode(driverConfig.SeedMode)
+
 	d := &Driver{
 		Configuration:          driverConfig,
-		SpecificationGenerator: generator.NewSpecificationGenerator(driverConfig.LoaderConfiguration.Seed),
+		SpecificationGenerator: specificationGenerator,
 
 		AsyncRecords:          common.NewLockFreeQueue[*mc.ExecutionRecord](),
+		WorkflowRecords:       common.NewLockFreeQueue[*mc.WorkflowRecord](),
 		readOpenWhiskMetadata: sync.Mutex{},
 		allFunctionsInvoked:   sync.WaitGroup{},
 	}
@@ -88,6 +95,7 @@ type InvocationMetadata struct {
 
 	InvocationID string
 	IatIndex     int
+	TraceMinute  int
 
 	SuccessCount        *int64
 	FailedCount         *int64
@@ -95,6 +103,13 @@ type InvocationMetadata struct {
 	RecordOutputChannel chan *mc.ExecutionRecord
 	AnnounceDoneWG      *sync.WaitGroup
 	AnnounceDoneExe     *sync.WaitGroup
+
+	// Workflow State shared by the branches of the invocation of a DAG, nil outside DAG mode
+	Workflow *workflowInvocation
+	// parent Completion of the node that triggered the branch
+	parent *nodeCompletion
+	// payload Data sent along the edge from the parent, nil if the edge carries none
+	payload []byte
 }
 
 func composeInvocationID(timeGranularity common.TraceGranularity, minuteIndex int, invocationIndex int) string {
@@ -114,6 +129,9 @@ func composeInvocationID(timeGranularity common.TraceGranularity, minuteIndex in
 
 func (d *Driver) invokeFunction(metadata *InvocationMetadata) {
 	defer metadata.AnnounceDoneWG.Done()
+	if metadata.Workflow != nil {
+		defer d.completeWorkflowBranch(metadata.Workflow)
+	}
 
 	var success bool
 	node := metadata.RootFunction.Front()
@@ -121,20 +139,59 @@ func (d *Driver) invokeFunction(metadata *InvocationMetadata) {
 	var runtimeSpecifications *common.RuntimeSpecification
 	var branches []*list.List
 	var invocationRetries int
+	var retrying bool
+	var joinWait time.Duration
+	var nodeStart time.Time
 	for node != nil {
 		function := node.Value.(*common.Node).Function
+
+		// a join is invoked by the parent completing its quorum
+		if !retrying {
+			nodeStart = time.Now()
+			joinWait = 0
+			if node.Value.(*common.Node).Parents > 1 {
+				var resolution joinResolution
+				if resolution, joinWait = metadata.Workflow.arrive(node); resolution != joinInvoked {
+					break
+				}
+			}
+		}
+
 		runtimeSpecifications = &function.Specification.RuntimeSpecification[metadata.IatIndex]
 
-		success, record = d.Invoker.Invoke(function, runtimeSpecifications)
+		success, record = d.invoke(function, runtimeSpecifications, metadata.payload)
 
 		if !success && (d.Configuration.LoaderConfiguration.DAGMode && invocationRetries == 0) {
 			log.Debugf("Invocation with for function %s with ID %s failed. Retrying Invocation", function.Name, metadata.InvocationID)
 			invocationRetries += 1
+			retrying = true
 			continue
 		}
+		retrying = false
+
 		record.Phase = int(metadata.Phase)
+		record.Function = function.Name
+		record.JoinWait = joinWait.Microseconds()
+		record.PayloadBytes = len(metadata.payload)
 		record.Instance = fmt.Sprintf("%s%s", node.Value.(*common.Node).DAG, record.Instance)
 		record.InvocationID = metadata.InvocationID
+		record.TraceMinute = metadata.TraceMinute
+
+		outcome := &parentOutcome{
+			success:  success,
+			timeout:  record.ConnectionTimeout || record.FunctionTimeout,
+			response: record.ResponseBody,
+		}
+		record.ResponseBody = ""
+		metadata.payload = nil
+
+		if metadata.Workflow != nil {
+			if metadata.parent != nil {
+				record.Parent = metadata.parent.name
+			}
+
+			metadata.parent = metadata.Workflow.complete(nodeName(node.Value.(*common.Node)), record.ResponseTime, nodeStart, time.Now(), success, metadata.parent)
+		}
 
 		if !d.Configuration.LoaderConfiguration.AsyncMode || record.AsyncResponseID == "" {
 			metadata.RecordOutputChannel <- record
@@ -146,26 +203,84 @@ func (d *Driver) invokeFunction(metadata *InvocationMetadata) {
 		if !success {
 			log.Errorf("Invocation with for function %s with ID %s failed.", function.Name, metadata.InvocationID)
 			atomic.AddInt64(metadata.FailedCount, 1)
-			break
+		} else {
+			atomic.AddInt64(metadata.SuccessCount, 1)
 		}
-		atomic.AddInt64(metadata.SuccessCount, 1)
+
 		branches = node.Value.(*common.Node).Branches
-		for i := 0; i < len(branches); i++ {
+		edges := node.Value.(*common.Node).Edges
+		taken := d.takenBranches(metadata.Workflow, node.Value.(*common.Node), outcome)
+		if metadata.Workflow != nil {
+			metadata.Workflow.skipUntaken(branches, taken)
+		}
+		for _, i := range taken {
 			newMetadataValue := *metadata
 			newMetadata := &newMetadataValue
 			newMetadata.RootFunction = branches[i]
+			if i < len(edges) {
+				newMetadata.payload = metadata.Workflow.edgePayload(d.Configuration.LoaderConfiguration.Seed, &edges[i], outcome.response)
+			}
 			newMetadata.AnnounceDoneWG.Add(1)
+			if newMetadata.Workflow != nil {
+				newMetadata.Workflow.branchStarted()
+			}
 			go d.invokeFunction(newMetadata)
 		}
 
+		if !success {
+			if metadata.Workflow != nil {
+				metadata.Workflow.skip(node.Next())
+			}
+			break
+		}
 		node = node.Next()
 	}
 }
 
+// invoke sends the payload along with the invocation if there is one and the invoker supports it
+func (d *Driver) invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte) (bool, *mc.ExecutionRecord) {
+	if payload == nil {
+		return d.Invoker.Invoke(function, runtimeSpec)
+	}
+
+	payloadInvoker, ok := d.Invoker.(clients.PayloadInvoker)
+	if !ok {
+		d.payloadUnsupported.Do(func() {
+			log.Warnf("Platform %s does not support payloads, invoking workflow nodes without them.", d.Configuration.LoaderConfiguration.Platform)
+		})
+
+		return d.Invoker.Invoke(function, runtimeSpec)
+	}
+
+	return payloadInvoker.InvokeWithPayload(function, runtimeSpec, payload)
+}
+
+// completeWorkflowBranch records the invocation of the workflow once its last branch finishes
+func (d *Driver) completeWorkflowBranch(workflow *workflowInvocation) {
+	if workflow.branchDone() {
+		d.WorkflowRecords.Enqueue(workflow.record())
+	}
+}
+
+func (d *Driver) writeWorkflowRecords() {
+	records := make(chan interface{}, 100)
+	writerDone := sync.WaitGroup{}
+	writerDone.Add(1)
+	go mc.RunCSVWriter(records, d.outputFilename("workflow"), &writerDone)
+
+	for d.WorkflowRecords.Length() > 0 {
+		records <- d.WorkflowRecords.Dequeue()
+	}
+
+	close(records)
+	writerDone.Wait()
+}
+
 func (d *Driver) functionsDriver(functionLinkedList *list.List, announceFunctionDone *sync.WaitGroup, addInvocationsToGroup *sync.WaitGroup, totalSuccessful *int64, totalFailed *int64, totalIssued *int64, recordOutputChannel chan *mc.ExecutionRecord) {
 	defer announceFunctionDone.Done()
 
 	function := functionLinkedList.Front().Value.(*common.Node).Function
+	workflowName := strings.TrimSuffix(functionLinkedList.Front().Value.(*common.Node).DAG, ",")
 	invocationCount := len(function.Specification.IAT)
 	addInvocationsToGroup.Add(invocationCount)
 
@@ -213,18 +328,27 @@ func (d *Driver) functionsDriver(functionLinkedList *list.List, announceFunction
 		previousIATSum += iat.Microseconds()
 
 		if !d.Configuration.TestMode {
+			invocationID := composeInvocationID(d.Configuration.TraceGranularity, minuteIndex, invocationSinceTheBeginningOfMinute)
+
+			var workflow *workflowInvocation
+			if d.Configuration.LoaderConfiguration.DAGMode {
+				workflow = newWorkflowInvocation(workflowName, currentPhase, invocationID)
+			}
+
 			waitForInvocations.Add(1)
 			go d.invokeFunction(&InvocationMetadata{
 				RootFunction:        functionLinkedList,
 				Phase:               currentPhase,
-				InvocationID:        composeInvocationID(d.Configuration.TraceGranularity, minuteIndex, invocationSinceTheBeginningOfMinute),
+				InvocationID:        invocationID,
 				IatIndex:            iatIndex,
+				TraceMinute:         d.Configuration.LoaderConfiguration.TraceStartMinute + minuteIndex,
 				SuccessCount:        &successfulInvocations,
 				FailedCount:         &failedInvocations,
 				FunctionsInvoked:    &functionsInvoked,
 				RecordOutputChannel: recordOutputChannel,
 				AnnounceDoneWG:      &waitForInvocations,
 				AnnounceDoneExe:     addInvocationsToGroup,
+				Workflow:            workflow,
 			})
 		} else {
 			// To be used from within the Golang testing framework
@@ -237,6 +361,8 @@ func (d *Driver) functionsDriver(functionLinkedList *list.List, announceFunction
 					InvocationID: invocationID,
 					StartTime:    time.Now().UnixNano(),
 				},
+				TraceMinute: d.Configuration.LoaderConfiguration.TraceStartMinute + minuteIndex,
+				Function:    function.Name,
 			}
 			functionsInvoked++
 			successfulInvocations++
@@ -372,8 +498,10 @@ func (d *Driver) internalRun() {
 	backgroundProcessesInitializationBarrier.Wait()
 
 	if d.Configuration.LoaderConfiguration.DAGMode {
-		functions := d.Configuration.Functions
-		dagLists := generator.GenerateDAGs(d.Configuration.LoaderConfiguration, functions, false)
+		dagLists := d.Configuration.Workflows
+		if len(dagLists) == 0 {
+			dagLists = generator.GenerateDAGs(d.Configuration.LoaderConfiguration, d.Configuration.Functions, false)
+		}
 		log.Infof("Starting DAG invocation driver\n")
 		for i := range len(dagLists) {
 			allIndividualDriversCompleted.Add(1)
@@ -405,6 +533,9 @@ func (d *Driver) internalRun() {
 		}
 	}
 	allIndividualDriversCompleted.Wait()
+	if d.Configuration.LoaderConfiguration.DAGMode {
+		d.writeWorkflowRecords()
+	}
 	if atomic.LoadInt64(&successfulInvocations)+atomic.LoadInt64(&failedInvocations) != 0 {
 		log.Debugf("Waiting for all the invocations record to be written.\n")
 
@@ -433,13 +564,21 @@ func (d *Driver) internalRun() {
 }
 
 func (d *Driver) GenerateSpecification() {
+	if d.Configuration.LoaderConfiguration.ExactReplay {
+		log.Info("Replaying the IATs and runtime specifications of the per-invocation trace")
+		return
+	}
+
 	log.Info("Generating IAT and runtime specifications for all the functions")
 
 	for i, function := range d.Configuration.Functions {
-		// Equalising all the InvocationStats to the first function
-		if d.Configuration.LoaderConfiguration.DAGMode {
+		// Equalising all the InvocationStats to the first function, as the nodes of explicitly defined workflows
+		// already follow their workflow
+		if d.Configuration.LoaderConfiguration.DAGMode && len(d.Configuration.Workflows) == 0 {
 			function.InvocationStats.Invocations = d.Configuration.Functions[0].InvocationStats.Invocations
 		}
+		function.Override = common.FindFunctionOverride(function, d.Configuration.FunctionOverrides)
+
 		spec := d.SpecificationGenerator.GenerateInvocationData(
 			function,
 			d.Configuration.IATDistribution,
@@ -451,41 +590,57 @@ func (d *Driver) GenerateSpecification() {
 	}
 }
 
-func (d *Driver) outputIATsToFile() {
-	for i, function := range d.Configuration.Functions {
-		file, _ := json.MarshalIndent(function.Specification, "", " ")
-		err := os.WriteFile("iat"+strconv.Itoa(i)+".json", file, 0644)
-		if err != nil {
-			log.Fatalf("Writing the loader config file failed: %s", err)
-		}
-	}
-}
-
 func (d *Driver) ReadOrWriteFileSpecification(writeIATsToFile bool, readIATsFromFile bool) {
 	if writeIATsToFile && readIATsFromFile {
 		log.Fatal("Invalid loader configuration. No point to read and write IATs within the same run.")
 	}
 
 	if writeIATsToFile {
-		d.outputIATsToFile()
+		if err := d.writeWorkloadSpecification(d.workloadSpecPath()); err != nil {
+			log.Fatalf("Writing the workload specification failed: %s", err)
+		}
 
-		log.Info("IATs have been generated. The program has exited.")
+		log.Infof("IATs have been generated into %s. The program has exited.", d.workloadSpecPath())
 		os.Exit(0)
 	}
 
 	if readIATsFromFile {
-		for i := range d.Configuration.Functions {
-			var spec common.FunctionSpecification
+		if err := d.readWorkloadSpecification(d.workloadSpecPath()); err != nil {
+			log.Fatalf("Failed to load the workload specification: %s", err)
+		}
+	}
+}
 
-			iatFile, _ := os.ReadFile("iat" + strconv.Itoa(i) + ".json")
-			err := json.Unmarshal(iatFile, &spec)
-			if err != nil {
-				log.Fatalf("Failed to unmarshal IAT file: %s", err)
-			}
+// deployPerApp deploys one service per application of the trace and routes the functions of the application to it
+func (d *Driver) deployPerApp(deployer deployment.FunctionDeployer) {
+	services := trace.GroupByApp(d.Configuration.Functions, d.Configuration.AppMemoryStats)
+	trace.ApplyResourceLimits(services, d.Configuration.LoaderConfiguration.CPULimit)
+	d.applyServiceRunScope(services)
+
+	serviceByName := make(map[string]*common.Function)
+	for _, service := range services {
+		serviceByName[service.Name] = service
+	}
+
+	// functions without an application are deployed on their own
+	deployed := services
+	for _, function := range d.Configuration.Functions {
+		if function.ServiceName == "" {
+			deployed = append(deployed, function)
+		}
+	}
+
+	serviceConfiguration := *d.Configuration
+	serviceConfiguration.Functions = deployed
+	deployer.Deploy(&serviceConfiguration)
 
-			d.Configuration.Functions[i].Specification = &spec
+	for _, function := range d.Configuration.Functions {
+		if service, ok := serviceByName[function.ServiceName]; ok {
+			function.Endpoint = service.Endpoint
 		}
 	}
+
+	log.Infof("Deployed %d functions as %d services.", len(d.Configuration.Functions), len(deployed))
 }
 
 func (d *Driver) RunExperiment() {
@@ -494,9 +649,37 @@ func (d *Driver) RunExperiment() {
 	}
 
 	trace.ApplyResourceLimits(d.Configuration.Functions, d.Configuration.LoaderConfiguration.CPULimit)
+	if d.Configuration.RunMode == RunOnlyRunMode {
+		d.useEndpointManifest()
+	} else {
+		d.applyRunScope()
+	}
+	d.writeExperimentMetadata()
+
+	deploymentStart := time.Now()
+	// deployer is nil in the run-only mode, as the functions are neither deployed nor deleted by the experiment, and in
+	// the test mode, as the invocations of the test mode do not reach the functions
+	var deployer deployment.FunctionDeployer
+	if d.Configuration.RunMode != RunOnlyRunMode && !d.Configuration.TestMode {
+		deployer = deployment.CreateDeployer(d.Configuration)
+		if d.Configuration.LoaderConfiguration.DeployPerApp {
+			d.deployPerApp(deployer)
+		} else {
+			deployer.Deploy(d.Configuration)
+		}
+	}
 
-	deployer := deployment.CreateDeployer(d.Configuration)
-	deployer.Deploy(d.Configuration)
+	if timeout := d.Configuration.LoaderConfiguration.ReadinessTimeoutSeconds; timeout > 0 {
+		d.gateOnReadiness(deployer, deploymentStart, time.Duration(timeout)*time.Second)
+	}
+
+	if deployer != nil {
+		// written by every run that deploys, so that the deployment can be cleaned up if the loader crashes
+		d.writeEndpointManifest(deployer)
+	}
+	if d.Configuration.RunMode == DeployOnlyRunMode {
+		return
+	}
 
 	go failure.ScheduleFailure(d.Configuration.LoaderConfiguration.Platform, d.Configuration.FailureConfiguration)
 
@@ -504,5 +687,7 @@ func (d *Driver) RunExperiment() {
 	d.internalRun()
 
 	// Clean up
-	deployer.Clean()
+	if deployer != nil {
+		deployer.Clean()
+	}
 }
@@ -29,6 +29,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -39,6 +41,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vhive-serverless/loader/pkg/common"
 	"github.com/vhive-serverless/loader/pkg/metric"
+	"github.com/vhive-serverless/loader/pkg/trace"
 	"github.com/vhive-serverless/loader/pkg/workload/standard"
 )
 
@@ -412,6 +415,8 @@ func TestDriverCompletely(t *testing.T) {
 			logrus.SetFormatter(&logrus.TextFormatter{TimestampFormat: time.StampMilli, FullTimestamp: true})
 
 			driver := createTestDriver(test.invocationStats)
+			// the experiment metadata and results are written next to the output path prefix
+			driver.Configuration.LoaderConfiguration.OutputPathPrefix = filepath.Join(t.TempDir(), "test")
 
 			if test.withWarmup {
 				if test.traceGranularity == common.MinuteGranularity {
@@ -519,3 +524,118 @@ func TestRequestedVsIssued(t *testing.T) {
 		t.Error("Unexpected value received.")
 	}
 }
+
+func TestWorkloadSpecification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workload_spec.jsonl.gz")
+
+	writer := createTestDriver([]int{3})
+	writer.Configuration.Functions[0].Specification = &common.FunctionSpecification{
+		IAT:                  []float64{0, 20_000_000, 20_000_000},
+		PerMinuteCount:       []int{3},
+		RuntimeSpecification: []common.RuntimeSpecification{{Runtime: 10, Memory: 128}, {Runtime: 20, Memory: 256}, {Runtime: 30, Memory: 512}},
+	}
+	if err := writer.writeWorkloadSpecification(path); err != nil {
+		t.Fatalf("Failed to write the workload specification - %v", err)
+	}
+
+	reader := createTestDriver([]int{3})
+	if err := reader.readWorkloadSpecification(path); err != nil {
+		t.Fatalf("Failed to read the workload specification - %v", err)
+	}
+	if !reflect.DeepEqual(reader.Configuration.Functions[0].Specification, writer.Configuration.Functions[0].Specification) {
+		t.Error("Specification changed after writing and reading it back.")
+	}
+
+	mismatchedSeed := createTestDriver([]int{3})
+	mismatchedSeed.Configuration.LoaderConfiguration.Seed++
+	if err := mismatchedSeed.readWorkloadSpecification(path); err == nil {
+		t.Error("Specification generated with a different seed should be refused.")
+	}
+
+	rescoped := createTestDriver([]int{3})
+	rescoped.Configuration.LoaderConfiguration.RunID = "other-run"
+	rescoped.Configuration.LoaderConfiguration.OutputPathPrefix = "other"
+	if err := rescoped.readWorkloadSpecification(path); err != nil {
+		t.Errorf("Run ID and output path should not invalidate the specification - %v", err)
+	}
+
+	otherFunction := createTestDriver([]int{3})
+	otherFunction.Configuration.Functions[0].InvocationStats.HashFunction = "other-function"
+	if err := otherFunction.readWorkloadSpecification(path); err == nil {
+		t.Error("Specification of a different function should be refused.")
+	}
+	if otherFunction.Configuration.Functions[0].Specification.IAT != nil {
+		t.Error("Functions should not be modified when loading is refused.")
+	}
+
+	dagMode := createTestDriver([]int{3})
+	dagMode.Configuration.LoaderConfiguration.DAGMode = true
+	if err := dagMode.readWorkloadSpecification(path); err == nil {
+		t.Error("Specification generated outside of the DAG mode should be refused.")
+	}
+
+	workflowsPath := filepath.Join(t.TempDir(), "workflows.json")
+	if err := os.WriteFile(workflowsPath, []byte(`{"Workflows": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withWorkflows := createTestDriver([]int{3})
+	withWorkflows.Configuration.LoaderConfiguration.WorkflowDefinitionPath = workflowsPath
+	if err := withWorkflows.readWorkloadSpecification(path); err == nil {
+		t.Error("Specification generated without workflow definitions should be refused.")
+	}
+}
+
+func TestWorkloadSpecificationOfReparsedTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workload_spec.jsonl.gz")
+
+	parse := func() *Driver {
+		functions, err := trace.NewAzureParser("../trace/test_data", 1).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		driver := createTestDriver([]int{1})
+		driver.Configuration.Functions = functions
+		for i, function := range functions {
+			function.Specification = &common.FunctionSpecification{IAT: []float64{float64(i)}}
+		}
+
+		return driver
+	}
+
+	writer := parse()
+	if err := writer.writeWorkloadSpecification(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// function names are random, so they differ every time the trace is parsed
+	reader := parse()
+	for _, function := range reader.Configuration.Functions {
+		function.Specification = nil
+	}
+	if err := reader.readWorkloadSpecification(path); err != nil {
+		t.Fatalf("Specification should be loaded for the same trace parsed again - %v", err)
+	}
+
+	for i, function := range reader.Configuration.Functions {
+		if function.Specification == nil || function.Specification.IAT[0] != float64(i) {
+			t.Errorf("Function %d of the trace got the specification of another function.", i)
+		}
+	}
+
+	// functions are matched by their hashes, irrespective of the order of the rows of the trace
+	reordered := parse()
+	functions := reordered.Configuration.Functions
+	for i, j := 0, len(functions)-1; i < j; i, j = i+1, j-1 {
+		functions[i], functions[j] = functions[j], functions[i]
+	}
+	if err := reordered.readWorkloadSpecification(path); err != nil {
+		t.Fatalf("Specification should be loaded for the trace with its rows reordered - %v", err)
+	}
+
+	for i, function := range functions {
+		if function.Specification.IAT[0] != float64(len(functions)-1-i) {
+			t.Errorf("Function %d of the reordered trace got the specification of another function.", i)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// random returns a random number generator of the invocation for the keys, e.g., an edge. It is seeded by the
+// experiment seed, the invocation and the keys, so that an experiment samples the same payloads and branches
+// irrespective of the order in which the concurrent branches of the workflow complete.
+func (w *workflowInvocation) random(seed int64, keys ...string) *rand.Rand {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(w.workflow + "/" + w.invocationID + "/" + strings.Join(keys, "/")))
+
+	return rand.New(rand.NewSource(seed ^ int64(hash.Sum64())))
+}
+
+// edgePayload returns the payload sent along the edge, which is either the response of the parent or a synthetic
+// payload with the size sampled from the distribution of the edge, and nil if the edge carries no data
+func (w *workflowInvocation) edgePayload(seed int64, edge *common.WorkflowEdge, response string) []byte {
+	if edge.ForwardResponse {
+		return []byte(response)
+	} else if edge.PayloadBytes == 0 {
+		return nil
+	}
+
+	var size int
+	switch edge.PayloadDistribution {
+	case common.UniformPayload:
+		size = w.random(seed, "payload", edge.From, edge.To).Intn(2*edge.PayloadBytes + 1)
+	case common.ExponentialPayload:
+		size = int(w.random(seed, "payload", edge.From, edge.To).ExpFloat64() * float64(edge.PayloadBytes))
+	default:
+		size = edge.PayloadBytes
+	}
+
+	return bytes.Repeat([]byte{'a'}, size)
+}
+
+// parentOutcome is how the invocation of a node completed, which decides the edges taken to its children
+type parentOutcome struct {
+	success  bool
+	timeout  bool
+	response string
+}
+
+// responseRegexes Compiled ResponseRegex of the edges, which are validated when reading the workflow definitions
+var responseRegexes sync.Map
+
+func (o *parentOutcome) meets(edge *common.WorkflowEdge) bool {
+	switch edge.Condition {
+	case common.FailureCondition:
+		return !o.success
+	case common.TimeoutCondition:
+		return !o.success && o.timeout
+	case common.AlwaysCondition:
+		return true
+	}
+
+	if !o.success {
+		return false
+	} else if edge.ResponseRegex == "" {
+		return true
+	}
+
+	regex, ok := responseRegexes.Load(edge.ResponseRegex)
+	if !ok {
+		regex, _ = responseRegexes.LoadOrStore(edge.ResponseRegex, regexp.MustCompile(edge.ResponseRegex))
+	}
+
+	return regex.(*regexp.Regexp).MatchString(o.response)
+}
+
+// takenBranches returns the indices of the branches of the node to invoke once it completed with the outcome. Without
+// edges, as in generated DAGs, all branches are taken if the node succeeded.
+func (d *Driver) takenBranches(workflow *workflowInvocation, node *common.Node, outcome *parentOutcome) []int {
+	if workflow == nil || len(node.Edges) == 0 {
+		if !outcome.success {
+			return nil
+		}
+
+		taken := make([]int, len(node.Branches))
+		for i := range taken {
+			taken[i] = i
+		}
+
+		return taken
+	}
+
+	return workflow.takenEdges(d.Configuration.LoaderConfiguration.Seed, node.Edges, outcome)
+}
+
+// takenEdges selects the edges taken after their parent completed with the outcome and adds them to the path of the
+// invocation. Of the edges in a group, the one selected by a single draw is taken, if any, and only if it meets its
+// condition, so that the probabilities of the edges in the group do not depend on the outcome.
+func (w *workflowInvocation) takenEdges(seed int64, edges []common.WorkflowEdge, outcome *parentOutcome) []int {
+	var taken []int
+	// draws Draw of each group, less the probabilities of the edges of the group before
+	draws := make(map[string]float64)
+	for i := range edges {
+		edge := &edges[i]
+
+		selected := true
+		if edge.Group != "" {
+			draw, ok := draws[edge.Group]
+			if !ok {
+				draw = w.random(seed, "group", edge.From, edge.Group).Float64()
+			}
+
+			selected = draw >= 0 && draw < edge.Probability
+			draws[edge.Group] = draw - edge.Probability
+		} else if edge.Probability > 0 {
+			selected = w.random(seed, "edge", edge.From, edge.To).Float64() < edge.Probability
+		}
+
+		if selected && outcome.meets(edge) {
+			taken = append(taken, i)
+		}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for _, i := range taken {
+		w.path = append(w.path, fmt.Sprintf("%s->%s", edges[i].From, edges[i].To))
+	}
+
+	return taken
+}
@@ -0,0 +1,293 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"container/list"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	mc "github.com/vhive-serverless/loader/pkg/metric"
+)
+
+// workflowInvocation holds the state shared by the branches of one invocation of a workflow
+type workflowInvocation struct {
+	mutex sync.Mutex
+	joins map[*common.Node]*joinState
+
+	workflow     string
+	phase        common.ExperimentPhase
+	invocationID string
+
+	// branches Number of branches still running
+	branches int
+	start    time.Time
+	// last Node completing last, whose chain of triggering parents is the critical path
+	last    *nodeCompletion
+	invoked int
+	failed  int
+	// skipped Nodes not invoked, as no edge to them was taken or a join did not reach its quorum
+	skipped []string
+	// path Edges taken in a workflow definition
+	path []string
+}
+
+type joinState struct {
+	arrivals int
+	// settled Parents that either took their edge to the join or will not take it anymore
+	settled      int
+	firstArrival time.Time
+	// resolved Whether the join has been invoked or skipped, after which the parents settling later are ignored
+	resolved bool
+}
+
+// joinResolution is the outcome of a parent of a join settling its edge to the join
+type joinResolution int
+
+const (
+	// joinPending Parents of the join are yet to settle
+	joinPending joinResolution = iota
+	// joinInvoked The parent completed the quorum of the join and invokes it
+	joinInvoked
+	// joinSkipped The quorum of the join cannot be reached anymore, so it and the nodes it leads to are skipped
+	joinSkipped
+)
+
+// nodeCompletion is a node invoked as part of a workflow invocation, linked to the parent that triggered it
+type nodeCompletion struct {
+	name string
+	// duration Response time in microseconds
+	duration int64
+	end      time.Time
+	parent   *nodeCompletion
+}
+
+func newWorkflowInvocation(workflow string, phase common.ExperimentPhase, invocationID string) *workflowInvocation {
+	return &workflowInvocation{
+		joins: make(map[*common.Node]*joinState),
+
+		workflow:     workflow,
+		phase:        phase,
+		invocationID: invocationID,
+
+		branches: 1,
+	}
+}
+
+// nodeName returns the name of the node in the workflow definition, or the name of its function in generated DAGs
+func nodeName(node *common.Node) string {
+	if node.Name != "" {
+		return node.Name
+	}
+
+	return node.Function.Name
+}
+
+// arrive registers a parent of the join taking its edge to the join. It reports whether the parent completes the quorum
+// of the join, in which case the caller invokes the join, and how long the join waited for the quorum since the first
+// of its parents completed. The parents completing after the quorum do not invoke the join again.
+func (w *workflowInvocation) arrive(element *list.Element) (joinResolution, time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	node := element.Value.(*common.Node)
+	resolution := w.settleLocked(node, true)
+	switch resolution {
+	case joinInvoked:
+		return resolution, time.Since(w.joins[node].firstArrival)
+	case joinSkipped:
+		w.markSkippedLocked(element)
+	}
+
+	return resolution, 0
+}
+
+// skip registers that the nodes from the element on, and the nodes only they lead to, are not invoked
+func (w *workflowInvocation) skip(element *list.Element) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.skipLocked(element)
+}
+
+// skipUntaken registers the branches of a node not taken after it completed as skipped, which lets the joins they lead
+// to resolve once all of their parents settled
+func (w *workflowInvocation) skipUntaken(branches []*list.List, taken []int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	isTaken := make(map[int]bool)
+	for _, i := range taken {
+		isTaken[i] = true
+	}
+
+	for i, branch := range branches {
+		if !isTaken[i] {
+			w.skipLocked(branch.Front())
+		}
+	}
+}
+
+func (w *workflowInvocation) skipLocked(element *list.Element) {
+	if element == nil {
+		return
+	}
+
+	// a join is reached through other parents too, so it is only skipped once all of them settled without a quorum
+	if node := element.Value.(*common.Node); node.Parents > 1 && w.settleLocked(node, false) != joinSkipped {
+		return
+	}
+
+	w.markSkippedLocked(element)
+}
+
+func (w *workflowInvocation) markSkippedLocked(element *list.Element) {
+	node := element.Value.(*common.Node)
+	w.skipped = append(w.skipped, nodeName(node))
+
+	for _, branch := range node.Branches {
+		w.skipLocked(branch.Front())
+	}
+	w.skipLocked(element.Next())
+}
+
+func (w *workflowInvocation) settleLocked(node *common.Node, arrived bool) joinResolution {
+	join, ok := w.joins[node]
+	if !ok {
+		join = &joinState{}
+		w.joins[node] = join
+	}
+	if join.resolved {
+		return joinPending
+	}
+
+	join.settled++
+	if arrived {
+		if join.arrivals == 0 {
+			join.firstArrival = time.Now()
+		}
+		join.arrivals++
+	}
+
+	if join.arrivals == node.Quorum {
+		join.resolved = true
+		return joinInvoked
+	} else if join.settled == node.Parents {
+		join.resolved = true
+		return joinSkipped
+	}
+
+	return joinPending
+}
+
+func (w *workflowInvocation) branchStarted() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.branches++
+}
+
+// branchDone reports whether the last running branch of the invocation has finished
+func (w *workflowInvocation) branchDone() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.branches--
+	return w.branches == 0
+}
+
+// complete registers the invocation of a node triggered by the completion of parent, nil for the root
+func (w *workflowInvocation) complete(name string, duration int64, start time.Time, end time.Time, success bool, parent *nodeCompletion) *nodeCompletion {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.start.IsZero() || start.Before(w.start) {
+		w.start = start
+	}
+
+	w.invoked++
+	if !success {
+		w.failed++
+	}
+
+	completion := &nodeCompletion{
+		name:     name,
+		duration: duration,
+		end:      end,
+		parent:   parent,
+	}
+	if w.last == nil || end.After(w.last.end) {
+		w.last = completion
+	}
+
+	return completion
+}
+
+func (w *workflowInvocation) record() *mc.WorkflowRecord {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	record := &mc.WorkflowRecord{
+		Phase:        int(w.phase),
+		Workflow:     w.workflow,
+		InvocationID: w.invocationID,
+		NodesInvoked: w.invoked,
+		NodesFailed:  w.failed,
+		NodesSkipped: len(w.skipped),
+	}
+
+	path := append([]string(nil), w.path...)
+	sort.Strings(path)
+	record.Path = strings.Join(path, ";")
+
+	skipped := append([]string(nil), w.skipped...)
+	sort.Strings(skipped)
+	record.Skipped = strings.Join(skipped, ";")
+
+	if w.last == nil {
+		return record
+	}
+
+	var names, durations []string
+	var criticalPathDuration int64
+	for node := w.last; node != nil; node = node.parent {
+		names = append([]string{node.name}, names...)
+		durations = append([]string{strconv.FormatInt(node.duration, 10)}, durations...)
+		criticalPathDuration += node.duration
+	}
+
+	record.StartTime = w.start.UnixMicro()
+	record.EndTime = w.last.end.UnixMicro()
+	record.ResponseTime = record.EndTime - record.StartTime
+	record.CriticalPath = strings.Join(names, ";")
+	record.CriticalPathDurations = strings.Join(durations, ";")
+	record.OrchestrationOverhead = record.ResponseTime - criticalPathDuration
+
+	return record
+}
@@ -0,0 +1,417 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/generator"
+	"github.com/vhive-serverless/loader/pkg/metric"
+)
+
+// fakeInvoker fails the invocations of the functions in failing
+type fakeInvoker struct {
+	failing map[string]bool
+}
+
+func (i *fakeInvoker) Invoke(function *common.Function, _ *common.RuntimeSpecification) (bool, *metric.ExecutionRecord) {
+	return !i.failing[function.Name], &metric.ExecutionRecord{}
+}
+
+func TestWorkflowJoin(t *testing.T) {
+	tests := []struct {
+		testName      string
+		quorum        int
+		failing       string
+		expectInvoked []string
+		expectFailed  int64
+		expectSkipped []string
+	}{
+		{
+			testName:      "all_parents",
+			expectInvoked: []string{"root", "a", "b", "join", "end"},
+		},
+		{
+			testName:      "failed_parent",
+			failing:       "b",
+			expectInvoked: []string{"root", "a", "b"},
+			expectFailed:  1,
+			expectSkipped: []string{"end", "join"},
+		},
+		{
+			testName:      "failed_parent_quorum",
+			quorum:        1,
+			failing:       "b",
+			expectInvoked: []string{"root", "a", "b", "join", "end"},
+			expectFailed:  1,
+		},
+		{
+			testName:      "failed_root",
+			failing:       "root",
+			expectInvoked: []string{"root"},
+			expectFailed:  1,
+			expectSkipped: []string{"a", "b", "end", "join"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			definition := common.WorkflowDefinition{
+				Name:        "workflow",
+				ArrivalRate: 1,
+				Nodes: []common.WorkflowNode{
+					{Name: "root", RuntimeMilli: 10},
+					{Name: "a", RuntimeMilli: 10},
+					{Name: "b", RuntimeMilli: 10},
+					{Name: "join", RuntimeMilli: 10, Quorum: test.quorum},
+					{Name: "end", RuntimeMilli: 10},
+				},
+				Edges: []common.WorkflowEdge{
+					{From: "root", To: "a"},
+					{From: "root", To: "b"},
+					{From: "a", To: "join"},
+					{From: "b", To: "join"},
+					{From: "join", To: "end"},
+				},
+			}
+
+			functions, workflows, err := generator.CreateWorkflows([]common.WorkflowDefinition{definition}, nil, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nodeOf := make(map[string]string)
+			for i, node := range definition.Nodes {
+				nodeOf[functions[i].Name] = node.Name
+				functions[i].Specification = &common.FunctionSpecification{
+					RuntimeSpecification: []common.RuntimeSpecification{{Runtime: 10, Memory: 128}},
+				}
+			}
+
+			testDriver := createTestDriver([]int{1})
+			testDriver.Configuration.LoaderConfiguration.DAGMode = true
+			invoker := &fakeInvoker{failing: make(map[string]bool)}
+			for name, node := range nodeOf {
+				invoker.failing[name] = node == test.failing
+			}
+			testDriver.Invoker = invoker
+
+			var successCount, failureCount, functionsInvoked int64
+			records := make(chan *metric.ExecutionRecord, 2*len(functions))
+			announceDone := &sync.WaitGroup{}
+
+			announceDone.Add(1)
+			testDriver.invokeFunction(&InvocationMetadata{
+				RootFunction:        workflows[0],
+				Phase:               common.ExecutionPhase,
+				InvocationID:        composeInvocationID(common.MinuteGranularity, 0, 0),
+				SuccessCount:        &successCount,
+				FailedCount:         &failureCount,
+				FunctionsInvoked:    &functionsInvoked,
+				RecordOutputChannel: records,
+				AnnounceDoneWG:      announceDone,
+				Workflow:            newWorkflowInvocation("workflow", common.ExecutionPhase, "min0.inv0"),
+			})
+			announceDone.Wait()
+			close(records)
+
+			invoked := make(map[string]int)
+			for record := range records {
+				invoked[nodeOf[record.Function]]++
+
+				if nodeOf[record.Function] != "join" && record.JoinWait != 0 {
+					t.Errorf("Node %s without several parents should not wait.", nodeOf[record.Function])
+				}
+			}
+
+			if len(invoked) != len(test.expectInvoked) {
+				t.Errorf("Expected the nodes %v to be invoked, got %v.", test.expectInvoked, invoked)
+			}
+			for _, node := range test.expectInvoked {
+				if invoked[node] != 1 {
+					t.Errorf("Expected node %s to be invoked once, got %d.", node, invoked[node])
+				}
+			}
+
+			if failureCount != test.expectFailed || successCount != int64(len(test.expectInvoked))-test.expectFailed {
+				t.Errorf("Unexpected number of successful (%d) and failed (%d) invocations.", successCount, failureCount)
+			}
+
+			if testDriver.WorkflowRecords.Length() != 1 {
+				t.Fatalf("Expected one workflow record, got %d.", testDriver.WorkflowRecords.Length())
+			}
+
+			record := testDriver.WorkflowRecords.Dequeue()
+			if record.Workflow != "workflow" || record.InvocationID != "min0.inv0" ||
+				record.NodesInvoked != len(test.expectInvoked) || int64(record.NodesFailed) != test.expectFailed {
+
+				t.Errorf("Unexpected workflow record %+v.", record)
+			}
+			if record.Skipped != strings.Join(test.expectSkipped, ";") || record.NodesSkipped != len(test.expectSkipped) {
+				t.Errorf("Expected the nodes %v to be skipped, got %s.", test.expectSkipped, record.Skipped)
+			}
+			if !strings.HasPrefix(record.CriticalPath+";", "root;") || record.EndTime < record.StartTime ||
+				record.ResponseTime != record.EndTime-record.StartTime || record.OrchestrationOverhead != record.ResponseTime {
+
+				t.Errorf("Unexpected critical path of the workflow record %+v.", record)
+			}
+			if test.failing == "" && !strings.HasSuffix(record.CriticalPath, ";join;end") {
+				t.Errorf("Expected the critical path to end with the join, got %s.", record.CriticalPath)
+			}
+		})
+	}
+}
+
+// fakePayloadInvoker responds with the name of the invoked function and keeps the payloads it was sent
+type fakePayloadInvoker struct {
+	fakeInvoker
+
+	mutex    sync.Mutex
+	payloads map[string]string
+}
+
+func (i *fakePayloadInvoker) Invoke(function *common.Function, runtimeSpec *common.RuntimeSpecification) (bool, *metric.ExecutionRecord) {
+	success, record := i.fakeInvoker.Invoke(function, runtimeSpec)
+	record.ResponseBody = "response of " + function.Name
+
+	return success, record
+}
+
+func (i *fakePayloadInvoker) InvokeWithPayload(function *common.Function, runtimeSpec *common.RuntimeSpecification, payload []byte) (bool, *metric.ExecutionRecord) {
+	i.mutex.Lock()
+	i.payloads[function.Name] = string(payload)
+	i.mutex.Unlock()
+
+	return i.Invoke(function, runtimeSpec)
+}
+
+func TestWorkflowEdgePayloads(t *testing.T) {
+	definition := common.WorkflowDefinition{
+		Name:        "workflow",
+		ArrivalRate: 1,
+		Nodes: []common.WorkflowNode{
+			{Name: "root", RuntimeMilli: 10},
+			{Name: "constant", RuntimeMilli: 10},
+			{Name: "forward", RuntimeMilli: 10},
+			{Name: "uniform", RuntimeMilli: 10},
+		},
+		Edges: []common.WorkflowEdge{
+			{From: "root", To: "constant", PayloadBytes: 100},
+			{From: "constant", To: "forward", ForwardResponse: true},
+			{From: "root", To: "uniform", PayloadBytes: 50, PayloadDistribution: common.UniformPayload},
+		},
+	}
+
+	functions, workflows, err := generator.CreateWorkflows([]common.WorkflowDefinition{definition}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeOf := make(map[string]string)
+	functionOf := make(map[string]string)
+	for i, node := range definition.Nodes {
+		nodeOf[functions[i].Name] = node.Name
+		functionOf[node.Name] = functions[i].Name
+		functions[i].Specification = &common.FunctionSpecification{
+			RuntimeSpecification: []common.RuntimeSpecification{{Runtime: 10, Memory: 128}},
+		}
+	}
+
+	testDriver := createTestDriver([]int{1})
+	testDriver.Configuration.LoaderConfiguration.DAGMode = true
+	invoker := &fakePayloadInvoker{payloads: make(map[string]string)}
+	testDriver.Invoker = invoker
+
+	var successCount, failureCount, functionsInvoked int64
+	records := make(chan *metric.ExecutionRecord, 2*len(functions))
+	announceDone := &sync.WaitGroup{}
+	workflow := newWorkflowInvocation("workflow", common.ExecutionPhase, "min0.inv0")
+
+	announceDone.Add(1)
+	testDriver.invokeFunction(&InvocationMetadata{
+		RootFunction:        workflows[0],
+		Phase:               common.ExecutionPhase,
+		InvocationID:        "min0.inv0",
+		SuccessCount:        &successCount,
+		FailedCount:         &failureCount,
+		FunctionsInvoked:    &functionsInvoked,
+		RecordOutputChannel: records,
+		AnnounceDoneWG:      announceDone,
+		Workflow:            workflow,
+	})
+	announceDone.Wait()
+	close(records)
+
+	expectedParent := map[string]string{"root": "", "constant": "root", "forward": "constant", "uniform": "root"}
+	for record := range records {
+		node := nodeOf[record.Function]
+		if record.Parent != expectedParent[node] {
+			t.Errorf("Expected the parent of %s to be '%s', got '%s'.", node, expectedParent[node], record.Parent)
+		}
+		if record.PayloadBytes != len(invoker.payloads[record.Function]) {
+			t.Errorf("Node %s recorded %d payload bytes, but was sent %d.", node, record.PayloadBytes, len(invoker.payloads[record.Function]))
+		}
+		if record.ResponseBody != "" {
+			t.Errorf("The response of node %s should not be kept in its record.", node)
+		}
+	}
+
+	if _, ok := invoker.payloads[functionOf["root"]]; ok {
+		t.Error("The root should be invoked without a payload.")
+	}
+	if len(invoker.payloads[functionOf["constant"]]) != 100 {
+		t.Errorf("Expected a payload of 100 bytes, got %d.", len(invoker.payloads[functionOf["constant"]]))
+	}
+	if invoker.payloads[functionOf["forward"]] != "response of "+functionOf["constant"] {
+		t.Errorf("Expected the response of the parent to be forwarded, got '%s'.", invoker.payloads[functionOf["forward"]])
+	}
+
+	uniform := len(invoker.payloads[functionOf["uniform"]])
+	if uniform > 100 {
+		t.Errorf("Expected a payload of at most 100 bytes, got %d.", uniform)
+	}
+	if resampled := len(workflow.edgePayload(testDriver.Configuration.LoaderConfiguration.Seed, &definition.Edges[2], "")); resampled != uniform {
+		t.Errorf("Expected the same payload size for the same invocation and edge, got %d and %d.", uniform, resampled)
+	}
+}
+
+func TestWorkflowConditionalBranches(t *testing.T) {
+	definition := common.WorkflowDefinition{
+		Name:        "workflow",
+		ArrivalRate: 1,
+		Nodes: []common.WorkflowNode{
+			{Name: "root", RuntimeMilli: 10},
+			{Name: "match", RuntimeMilli: 10},
+			{Name: "mismatch", RuntimeMilli: 10},
+			{Name: "fail", RuntimeMilli: 10},
+			{Name: "handler", RuntimeMilli: 10},
+			{Name: "next", RuntimeMilli: 10},
+			{Name: "a", RuntimeMilli: 10},
+			{Name: "b", RuntimeMilli: 10},
+		},
+		Edges: []common.WorkflowEdge{
+			{From: "root", To: "match", ResponseRegex: "^response of "},
+			{From: "root", To: "mismatch", ResponseRegex: "^OK"},
+			{From: "root", To: "fail"},
+			{From: "fail", To: "handler", Condition: common.FailureCondition},
+			{From: "fail", To: "next"},
+			{From: "root", To: "a", Group: "ab", Probability: 0.5},
+			{From: "root", To: "b", Group: "ab", Probability: 0.5},
+		},
+	}
+
+	functions, workflows, err := generator.CreateWorkflows([]common.WorkflowDefinition{definition}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeOf := make(map[string]string)
+	invoker := &fakePayloadInvoker{fakeInvoker: fakeInvoker{failing: make(map[string]bool)}, payloads: make(map[string]string)}
+	for i, node := range definition.Nodes {
+		nodeOf[functions[i].Name] = node.Name
+		invoker.failing[functions[i].Name] = node.Name == "fail"
+		functions[i].Specification = &common.FunctionSpecification{
+			RuntimeSpecification: []common.RuntimeSpecification{{Runtime: 10, Memory: 128}},
+		}
+	}
+
+	testDriver := createTestDriver([]int{1})
+	testDriver.Configuration.LoaderConfiguration.DAGMode = true
+	testDriver.Invoker = invoker
+
+	var paths []string
+	for run := 0; run < 2; run++ {
+		var successCount, failureCount, functionsInvoked int64
+		records := make(chan *metric.ExecutionRecord, 2*len(functions))
+		announceDone := &sync.WaitGroup{}
+
+		announceDone.Add(1)
+		testDriver.invokeFunction(&InvocationMetadata{
+			RootFunction:        workflows[0],
+			Phase:               common.ExecutionPhase,
+			InvocationID:        "min0.inv0",
+			SuccessCount:        &successCount,
+			FailedCount:         &failureCount,
+			FunctionsInvoked:    &functionsInvoked,
+			RecordOutputChannel: records,
+			AnnounceDoneWG:      announceDone,
+			Workflow:            newWorkflowInvocation("workflow", common.ExecutionPhase, "min0.inv0"),
+		})
+		announceDone.Wait()
+		close(records)
+
+		invoked := make(map[string]bool)
+		for record := range records {
+			invoked[nodeOf[record.Function]] = true
+		}
+
+		for _, node := range []string{"root", "match", "fail", "handler"} {
+			if !invoked[node] {
+				t.Errorf("Expected node %s to be invoked.", node)
+			}
+		}
+		for _, node := range []string{"mismatch", "next"} {
+			if invoked[node] {
+				t.Errorf("Expected node %s not to be invoked.", node)
+			}
+		}
+		if invoked["a"] == invoked["b"] {
+			t.Errorf("Expected exactly one of the nodes in a group to be invoked, got a: %t, b: %t.", invoked["a"], invoked["b"])
+		}
+
+		record := testDriver.WorkflowRecords.Dequeue()
+		if !strings.Contains(record.Path, "fail->handler") || strings.Contains(record.Path, "fail->next") ||
+			len(strings.Split(record.Path, ";")) != 4 {
+
+			t.Errorf("Unexpected path %s.", record.Path)
+		}
+		if record.NodesSkipped != 3 || !strings.Contains(record.Skipped, "mismatch") || !strings.Contains(record.Skipped, "next") {
+			t.Errorf("Expected the nodes of the edges not taken to be skipped, got %s.", record.Skipped)
+		}
+		paths = append(paths, record.Path)
+	}
+
+	if paths[0] != paths[1] {
+		t.Errorf("Expected the same path with the same seed, got %s and %s.", paths[0], paths[1])
+	}
+
+	// the group selects each of its edges in some invocations, but never both
+	taken := make(map[int]int)
+	outcome := &parentOutcome{success: true}
+	for i := 0; i < 100; i++ {
+		workflow := newWorkflowInvocation("workflow", common.ExecutionPhase, composeInvocationID(common.MinuteGranularity, 0, i))
+		edges := workflow.takenEdges(testDriver.Configuration.LoaderConfiguration.Seed, definition.Edges[5:], outcome)
+		if len(edges) != 1 {
+			t.Fatalf("Expected one edge of the group to be taken, got %v.", edges)
+		}
+		taken[edges[0]]++
+	}
+	if taken[0] == 0 || taken[1] == 0 {
+		t.Errorf("Expected both edges of the group to be taken in some invocations, got %v.", taken)
+	}
+}
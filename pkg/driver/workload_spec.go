@@ -0,0 +1,309 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package driver
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// WorkloadSpecFormatVersion is increased whenever the layout of the workload specification file changes
+const WorkloadSpecFormatVersion = 3
+
+const defaultWorkloadSpecPath = "workload_spec.jsonl.gz"
+
+// WorkloadSpecHeader is the first line of the workload specification file. It identifies the configuration the
+// specification has been generated with.
+type WorkloadSpecHeader struct {
+	FormatVersion int    `json:"FormatVersion"`
+	LoaderVersion string `json:"LoaderVersion"`
+	ConfigHash    string `json:"ConfigHash"`
+	Seed          int64  `json:"Seed"`
+	TracePath     string `json:"TracePath"`
+	Functions     int    `json:"Functions"`
+}
+
+// WorkloadSpecEntry holds the specification of a single function, one per line after the header. Functions are
+// identified by their hashes in the trace, as their names are random and change every time the trace is parsed.
+type WorkloadSpecEntry struct {
+	// Name Name of the function when the specification was generated, for reference only
+	Name         string `json:"Name"`
+	HashOwner    string `json:"HashOwner"`
+	HashApp      string `json:"HashApp"`
+	HashFunction string `json:"HashFunction"`
+	// Occurrence Number of functions with the same hashes before the function in the trace
+	Occurrence    int                           `json:"Occurrence,omitempty"`
+	Specification *common.FunctionSpecification `json:"Specification"`
+}
+
+// traceFunctionKey identifies a function of the trace across the runs of the loader, irrespective of the order of the
+// rows of the trace. Functions with the same hashes, e.g., replicated by a trace transformation, are told apart by
+// their order in the trace.
+type traceFunctionKey struct {
+	hashOwner, hashApp, hashFunction string
+	occurrence                       int
+}
+
+// traceFunctionKeys assigns the functions their keys in order
+type traceFunctionKeys map[traceFunctionKey]int
+
+func (k traceFunctionKeys) next(hashOwner string, hashApp string, hashFunction string) traceFunctionKey {
+	hashes := traceFunctionKey{hashOwner: hashOwner, hashApp: hashApp, hashFunction: hashFunction}
+
+	key := hashes
+	key.occurrence = k[hashes]
+	k[hashes]++
+
+	return key
+}
+
+// functionKeys returns the keys of the functions in order
+func functionKeys(functions []*common.Function) []traceFunctionKey {
+	keys := make(traceFunctionKeys)
+
+	result := make([]traceFunctionKey, len(functions))
+	for i, function := range functions {
+		stats := function.InvocationStats
+		if stats == nil {
+			stats = &common.FunctionInvocationStats{}
+		}
+
+		result[i] = keys.next(stats.HashOwner, stats.HashApp, stats.HashFunction)
+	}
+
+	return result
+}
+
+func (d *Driver) workloadSpecPath() string {
+	if path := d.Configuration.LoaderConfiguration.WorkloadSpecPath; path != "" {
+		return path
+	}
+
+	return defaultWorkloadSpecPath
+}
+
+// configurationHash fingerprints the parts of the configuration that determine the generated specification, so that
+// parameters such as the run ID or the output path do not invalidate the specification
+func (d *Driver) configurationHash() string {
+	loaderConfiguration := d.Configuration.LoaderConfiguration
+
+	// the transformations and workflows are fingerprinted by their content, as the files may be edited in place
+	readContent := func(path string, description string) []byte {
+		if path == "" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read the %s - %v", description, err)
+		}
+
+		return content
+	}
+
+	data, err := json.Marshal(struct {
+		TracePath                string
+		TraceFormat              string
+		TraceStartMinute         int
+		TraceDuration            int
+		Seed                     int64
+		SeedMode                 common.SeedMode
+		IATDistribution          common.IatDistribution
+		ShiftIAT                 bool
+		ExecutionSampling        common.ExecutionSampling
+		RuntimeMemoryCorrelation float64
+		TraceGranularity         common.TraceGranularity
+		FunctionOverrides        []common.FunctionOverride
+		TraceTransform           []byte
+		DAGMode                  bool
+		WorkflowDefinitions      []byte
+	}{
+		TracePath:                loaderConfiguration.TracePath,
+		TraceFormat:              loaderConfiguration.TraceFormat,
+		TraceStartMinute:         loaderConfiguration.TraceStartMinute,
+		TraceDuration:            d.Configuration.TraceDuration,
+		Seed:                     loaderConfiguration.Seed,
+		SeedMode:                 d.Configuration.SeedMode,
+		IATDistribution:          d.Configuration.IATDistribution,
+		ShiftIAT:                 d.Configuration.ShiftIAT,
+		ExecutionSampling:        d.Configuration.ExecutionSampling,
+		RuntimeMemoryCorrelation: loaderConfiguration.RuntimeMemoryCorrelation,
+		TraceGranularity:         d.Configuration.TraceGranularity,
+		FunctionOverrides:        d.Configuration.FunctionOverrides,
+		TraceTransform:           readContent(loaderConfiguration.TraceTransformPath, "trace transformations"),
+		DAGMode:                  loaderConfiguration.DAGMode,
+		WorkflowDefinitions:      readContent(loaderConfiguration.WorkflowDefinitionPath, "workflow definitions"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to serialize the configuration - %v", err)
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func loaderVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+
+	return info.Main.Version
+}
+
+func (d *Driver) workloadSpecHeader() WorkloadSpecHeader {
+	return WorkloadSpecHeader{
+		FormatVersion: WorkloadSpecFormatVersion,
+		LoaderVersion: loaderVersion(),
+		ConfigHash:    d.configurationHash(),
+		Seed:          d.Configuration.LoaderConfiguration.Seed,
+		TracePath:     d.Configuration.LoaderConfiguration.TracePath,
+		Functions:     len(d.Configuration.Functions),
+	}
+}
+
+// writeWorkloadSpecification writes the specifications of all the functions into a single gzip-compressed JSON
+// Lines file
+func (d *Driver) writeWorkloadSpecification(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zipWriter := gzip.NewWriter(file)
+	encoder := json.NewEncoder(zipWriter)
+
+	if err = encoder.Encode(d.workloadSpecHeader()); err != nil {
+		return err
+	}
+
+	keys := functionKeys(d.Configuration.Functions)
+	for i, function := range d.Configuration.Functions {
+		err = encoder.Encode(WorkloadSpecEntry{
+			Name:          function.Name,
+			HashOwner:     keys[i].hashOwner,
+			HashApp:       keys[i].hashApp,
+			HashFunction:  keys[i].hashFunction,
+			Occurrence:    keys[i].occurrence,
+			Specification: function.Specification,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = zipWriter.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// readWorkloadSpecification loads the function specifications from the file. Loading fails if the file has been
+// generated with a different configuration or for a different set of functions.
+func (d *Driver) readWorkloadSpecification(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("%s is not a gzip-compressed workload specification - %v", path, err)
+	}
+	defer zipReader.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(zipReader))
+
+	var header WorkloadSpecHeader
+	if err = decoder.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read the header of %s - %v", path, err)
+	}
+
+	expected := d.workloadSpecHeader()
+	switch {
+	case header.FormatVersion != expected.FormatVersion:
+		return fmt.Errorf("format version %d of %s is not supported (expected %d)", header.FormatVersion, path, expected.FormatVersion)
+	case header.Seed != expected.Seed:
+		return fmt.Errorf("%s has been generated with seed %d, but the configured seed is %d", path, header.Seed, expected.Seed)
+	case header.TracePath != expected.TracePath:
+		return fmt.Errorf("%s has been generated from trace %s, but the configured trace is %s", path, header.TracePath, expected.TracePath)
+	case header.ConfigHash != expected.ConfigHash:
+		return fmt.Errorf("%s has been generated with a different configuration", path)
+	case header.Functions != expected.Functions:
+		return fmt.Errorf("%s contains %d functions, but the trace contains %d", path, header.Functions, expected.Functions)
+	}
+
+	if header.LoaderVersion != expected.LoaderVersion {
+		log.Warnf("%s has been generated by loader version %s, the current version is %s.", path, header.LoaderVersion, expected.LoaderVersion)
+	}
+
+	specifications := make(map[traceFunctionKey]*common.FunctionSpecification)
+	for decoder.More() {
+		var entry WorkloadSpecEntry
+		if err = decoder.Decode(&entry); err != nil {
+			return fmt.Errorf("failed to read the specification of function %d from %s - %v", len(specifications), path, err)
+		}
+
+		key := traceFunctionKey{entry.HashOwner, entry.HashApp, entry.HashFunction, entry.Occurrence}
+		if _, ok := specifications[key]; ok {
+			return fmt.Errorf("%s contains function %s/%s/%s more than once", path, entry.HashOwner, entry.HashApp, entry.HashFunction)
+		}
+		specifications[key] = entry.Specification
+	}
+
+	keys := functionKeys(d.Configuration.Functions)
+	for i, function := range d.Configuration.Functions {
+		spec, ok := specifications[keys[i]]
+		if !ok || spec == nil {
+			return fmt.Errorf("%s does not contain the specification of function %s (%s/%s/%s)", path, function.Name,
+				keys[i].hashOwner, keys[i].hashApp, keys[i].hashFunction)
+		}
+	}
+
+	// functions are modified only after the whole file has been validated
+	for i, function := range d.Configuration.Functions {
+		function.Specification = specifications[keys[i]]
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// inverseCDF is a continuous quantile function interpolating the percentiles reported in the trace. Between two
+// knots, the value is interpolated either linearly or with a monotone cubic Hermite spline (Fritsch-Carlson), so
+// that the generated samples never fall outside the range of the neighbouring percentiles.
+type inverseCDF struct {
+	quantiles []float64
+	values    []float64
+	// tangents are only set for spline interpolation
+	tangents []float64
+}
+
+func newInverseCDF(quantiles []float64, values []float64, spline bool) *inverseCDF {
+	result := &inverseCDF{
+		quantiles: quantiles,
+		values:    make([]float64, len(values)),
+	}
+
+	// trace percentiles are sometimes not monotonic due to rounding, which would break the quantile function
+	copy(result.values, values)
+	for i := 1; i < len(result.values); i++ {
+		result.values[i] = math.Max(result.values[i], result.values[i-1])
+	}
+
+	if spline {
+		result.tangents = monotoneTangents(result.quantiles, result.values)
+	}
+
+	return result
+}
+
+// newRuntimeInverseCDF builds the quantile function of the runtime in milliseconds
+func newRuntimeInverseCDF(runStats *common.FunctionRuntimeStats, spline bool) *inverseCDF {
+	return newInverseCDF(
+		[]float64{0, 0.01, 0.25, 0.50, 0.75, 0.99, 1},
+		[]float64{
+			runStats.Percentile0,
+			runStats.Percentile1,
+			runStats.Percentile25,
+			runStats.Percentile50,
+			runStats.Percentile75,
+			runStats.Percentile99,
+			runStats.Percentile100,
+		},
+		spline,
+	)
+}
+
+// newMemoryInverseCDF builds the quantile function of the memory in MiB. The trace does not report the minimum, so
+// the first percentile is used for the lowest quantiles, as in GenerateMemorySpec.
+func newMemoryInverseCDF(memStats *common.FunctionMemoryStats, spline bool) *inverseCDF {
+	return newInverseCDF(
+		[]float64{0, 0.01, 0.05, 0.25, 0.50, 0.75, 0.95, 0.99, 1},
+		[]float64{
+			memStats.Percentile1,
+			memStats.Percentile1,
+			memStats.Percentile5,
+			memStats.Percentile25,
+			memStats.Percentile50,
+			memStats.Percentile75,
+			memStats.Percentile95,
+			memStats.Percentile99,
+			memStats.Percentile100,
+		},
+		spline,
+	)
+}
+
+// monotoneTangents computes the Fritsch-Carlson tangents that keep the cubic Hermite interpolation monotone
+func monotoneTangents(x []float64, y []float64) []float64 {
+	n := len(x)
+	secants := make([]float64, n-1)
+	for i := 0; i < n-1; i++ {
+		secants[i] = (y[i+1] - y[i]) / (x[i+1] - x[i])
+	}
+
+	tangents := make([]float64, n)
+	tangents[0], tangents[n-1] = secants[0], secants[n-2]
+	for i := 1; i < n-1; i++ {
+		if secants[i-1]*secants[i] <= 0 {
+			tangents[i] = 0
+		} else {
+			tangents[i] = (secants[i-1] + secants[i]) / 2
+		}
+	}
+
+	for i := 0; i < n-1; i++ {
+		if secants[i] == 0 {
+			tangents[i], tangents[i+1] = 0, 0
+			continue
+		}
+
+		alpha, beta := tangents[i]/secants[i], tangents[i+1]/secants[i]
+		if norm := alpha*alpha + beta*beta; norm > 9 {
+			tau := 3 / math.Sqrt(norm)
+			tangents[i] = tau * alpha * secants[i]
+			tangents[i+1] = tau * beta * secants[i]
+		}
+	}
+
+	return tangents
+}
+
+// At returns the value of the quantile function for the quantile q in [0, 1]
+func (c *inverseCDF) At(q float64) float64 {
+	q = math.Min(math.Max(q, 0), 1)
+
+	// index of the first knot with quantile >= q
+	i := sort.SearchFloat64s(c.quantiles, q)
+	if i == 0 {
+		return c.values[0]
+	} else if i >= len(c.quantiles) {
+		return c.values[len(c.values)-1]
+	}
+
+	x0, x1 := c.quantiles[i-1], c.quantiles[i]
+	y0, y1 := c.values[i-1], c.values[i]
+	h := x1 - x0
+	t := (q - x0) / h
+
+	if c.tangents == nil {
+		return y0 + t*(y1-y0)
+	}
+
+	t2, t3 := t*t, t*t*t
+	h00 := 2*t3 - 3*t2 + 1
+	h10 := t3 - 2*t2 + t
+	h01 := -2*t3 + 3*t2
+	h11 := t3 - t2
+
+	result := h00*y0 + h10*h*c.tangents[i-1] + h01*y1 + h11*h*c.tangents[i]
+
+	// guard against floating-point overshoot
+	return math.Min(math.Max(result, y0), y1)
+}
+
+// standardNormalCDF is the cumulative distribution function of N(0, 1)
+func standardNormalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
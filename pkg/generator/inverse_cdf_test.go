@@ -0,0 +1,139 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestInverseCDFKnotsAndMonotonicity(t *testing.T) {
+	for _, spline := range []bool{false, true} {
+		runtimeCDF := newRuntimeInverseCDF(testFunction.RuntimeStats, spline)
+
+		expected := map[float64]float64{0: 0, 0.01: 1, 0.25: 25, 0.5: 50, 0.75: 75, 0.99: 99, 1: 100}
+		for q, value := range expected {
+			if !floatEqual(runtimeCDF.At(q), value) {
+				t.Errorf("Quantile %f should map to %f, got %f (spline: %v).", q, value, runtimeCDF.At(q), spline)
+			}
+		}
+
+		previous := runtimeCDF.At(0)
+		for q := 0.0; q <= 1; q += 0.001 {
+			current := runtimeCDF.At(q)
+			if current < previous {
+				t.Fatalf("Inverse CDF is not monotone at quantile %f (spline: %v).", q, spline)
+			}
+			previous = current
+		}
+	}
+}
+
+func TestContinuousExecutionSampling(t *testing.T) {
+	function := common.Function{
+		Name: "continuous-function",
+		InvocationStats: &common.FunctionInvocationStats{
+			Invocations: []int{5000},
+		},
+		RuntimeStats: &common.FunctionRuntimeStats{
+			Count:         100,
+			Percentile0:   0.1,
+			Percentile1:   0.2,
+			Percentile25:  0.3,
+			Percentile50:  0.4,
+			Percentile75:  0.5,
+			Percentile99:  0.8,
+			Percentile100: 0.9,
+		},
+		MemoryStats: testFunction.MemoryStats,
+	}
+
+	generate := func(sampling common.ExecutionSampling, correlation float64) common.RuntimeSpecificationArray {
+		sg := NewSpecificationGenerator(42).WithExecutionSampling(sampling, correlation)
+		return sg.GenerateInvocationData(&function, common.Equidistant, false, common.MinuteGranularity).RuntimeSpecification
+	}
+
+	spec := generate(common.SplineSampling, 0)
+	for _, runtimeSpec := range spec {
+		if runtimeSpec.RuntimeMicroseconds < 100 || runtimeSpec.RuntimeMicroseconds > 900 {
+			t.Fatalf("Sub-millisecond runtime %dµs is outside of the trace range.", runtimeSpec.RuntimeMicroseconds)
+		}
+		if runtimeSpec.Runtime != common.MinExecTimeMilli {
+			t.Fatalf("Sub-millisecond runtime is invoked with %dms instead of the minimum runtime.", runtimeSpec.Runtime)
+		}
+	}
+
+	if !reflect.DeepEqual(spec, generate(common.SplineSampling, 0)) {
+		t.Error("Continuous sampling is not deterministic for the same seed.")
+	}
+
+	independent := rankCorrelation(generate(common.LinearSampling, 0))
+	correlated := rankCorrelation(generate(common.LinearSampling, 0.9))
+	if math.Abs(independent) > 0.1 || correlated < 0.8 {
+		t.Errorf("Unexpected runtime-memory rank correlation: %f without and %f with coupling.", independent, correlated)
+	}
+}
+
+func floatEqual(n, expected float64) bool {
+	return math.Abs(n-expected) < 1e-6
+}
+
+// rankCorrelation computes the Spearman correlation between runtime and memory
+func rankCorrelation(spec common.RuntimeSpecificationArray) float64 {
+	ranks := func(values []float64) []float64 {
+		indices := make([]int, len(values))
+		for i := range indices {
+			indices[i] = i
+		}
+		sort.SliceStable(indices, func(i, j int) bool { return values[indices[i]] < values[indices[j]] })
+
+		result := make([]float64, len(values))
+		for rank, index := range indices {
+			result[index] = float64(rank)
+		}
+		return result
+	}
+
+	runtimes, memories := make([]float64, len(spec)), make([]float64, len(spec))
+	for i, runtimeSpec := range spec {
+		runtimes[i], memories[i] = float64(runtimeSpec.RuntimeMicroseconds), float64(runtimeSpec.Memory)
+	}
+	runRanks, memRanks := ranks(runtimes), ranks(memories)
+
+	n := float64(len(spec))
+	mean := (n - 1) / 2
+	var covariance, varRun, varMem float64
+	for i := range runRanks {
+		covariance += (runRanks[i] - mean) * (memRanks[i] - mean)
+		varRun += (runRanks[i] - mean) * (runRanks[i] - mean)
+		varMem += (memRanks[i] - mean) * (memRanks[i] - mean)
+	}
+
+	return covariance / math.Sqrt(varRun*varMem)
+}
@@ -25,24 +25,81 @@
 package generator
 
 import (
+	"math"
 	"math/rand"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vhive-serverless/loader/pkg/common"
 )
 
 type SpecificationGenerator struct {
+	seed     int64
+	seedMode common.SeedMode
+
 	iatRand  *rand.Rand
 	specRand *rand.Rand
+
+	executionSampling common.ExecutionSampling
+	// runtimeMemoryCorrelation of the Gaussian copula coupling runtime and memory quantiles
+	runtimeMemoryCorrelation float64
 }
 
 func NewSpecificationGenerator(seed int64) *SpecificationGenerator {
 	return &SpecificationGenerator{
+		seed:     seed,
 		iatRand:  rand.New(rand.NewSource(seed)),
 		specRand: rand.New(rand.NewSource(seed)),
 	}
 }
 
+// WithExecutionSampling selects how runtime and memory are sampled from the trace percentiles and how strongly the
+// runtime and memory quantiles are correlated. Correlation of 0 keeps the quantiles independent.
+func (s *SpecificationGenerator) WithExecutionSampling(sampling common.ExecutionSampling, correlation float64) *SpecificationGenerator {
+	if correlation < -1 || correlation > 1 {
+		log.Fatalf("Runtime-memory correlation must be within [-1, 1], got %f.", correlation)
+	}
+
+	s.executionSampling = sampling
+	s.runtimeMemoryCorrelation = correlation
+
+	return s
+}
+
+// WithSeedMode selects whether all the functions share the random streams of the generator or each function gets its
+// own streams derived from the seed and the function hash
+func (s *SpecificationGenerator) WithSeedMode(mode common.SeedMode) *SpecificationGenerator {
+	s.seedMode = mode
+
+	return s
+}
+
+// FunctionSeed derives the seed of the random streams of a function. Functions are identified by HashFunction, or by
+// name if the function has no invocation statistics.
+func FunctionSeed(seed int64, function *common.Function) int64 {
+	key := function.Name
+	if function.InvocationStats != nil && function.InvocationStats.HashFunction != "" {
+		key = function.InvocationStats.HashFunction
+	}
+
+	return int64(common.Hash(strconv.FormatInt(seed, 10) + "/" + key))
+}
+
+// forFunction returns the generator to be used for the given function
+func (s *SpecificationGenerator) forFunction(function *common.Function) *SpecificationGenerator {
+	if s.seedMode != common.PerFunctionSeed {
+		return s
+	}
+
+	seed := FunctionSeed(s.seed, function)
+
+	perFunction := *s
+	perFunction.iatRand = rand.New(rand.NewSource(seed))
+	perFunction.specRand = rand.New(rand.NewSource(seed))
+
+	return &perFunction
+}
+
 //////////////////////////////////////////////////
 // IAT GENERATION
 //////////////////////////////////////////////////
@@ -163,16 +220,33 @@ func (s *SpecificationGenerator) generateIAT(invocationsPerMinute []int, iatDist
 }
 
 func (s *SpecificationGenerator) GenerateInvocationData(function *common.Function, iatDistribution common.IatDistribution, shiftIAT bool, granularity common.TraceGranularity) *common.FunctionSpecification {
+	s = s.forFunction(function)
 	invocationsPerMinute := function.InvocationStats.Invocations
 
+	if function.Override != nil && function.Override.IATDistribution != "" {
+		var err error
+
+		iatDistribution, shiftIAT, err = common.ParseIATDistribution(function.Override.IATDistribution)
+		if err != nil {
+			log.Fatalf("Invalid IAT distribution override for function %s - %v", function.Name, err)
+		}
+	}
+
 	// Generating IAT
 	iat, perMinuteCount, rawDuration := s.generateIAT(invocationsPerMinute, iatDistribution, shiftIAT, granularity)
 
 	// Generating runtime specifications
 	var runtimeArray common.RuntimeSpecificationArray
+	sampler := s.newExecutionSampler(function)
+	periodSamplers, nextPeriod := s.newPeriodSamplers(function), 0
 	for i := 0; i < len(perMinuteCount); i++ {
+		// statistics of the trace window may change over time, e.g., per day
+		for ; nextPeriod < len(periodSamplers) && function.ExecutionStatsPeriods[nextPeriod].FromMinute <= i; nextPeriod++ {
+			sampler = periodSamplers[nextPeriod]
+		}
+
 		for j := 0; j < perMinuteCount[i]; j++ {
-			runtimeArray = append(runtimeArray, s.generateExecutionSpecs(function))
+			runtimeArray = append(runtimeArray, sampler.generateExecutionSpecs())
 		}
 	}
 
@@ -205,6 +279,10 @@ func randIntBetween(gen *rand.Rand, min, max float64) int {
 
 // Should be called only when specRand is locked with its mutex
 func (s *SpecificationGenerator) determineExecutionSpecSeedQuantiles() (float64, float64) {
+	if s.runtimeMemoryCorrelation != 0 {
+		return s.determineCorrelatedQuantiles()
+	}
+
 	//* Generate uniform quantiles in [0, 1).
 	runQtl := s.specRand.Float64()
 	memQtl := s.specRand.Float64()
@@ -212,6 +290,20 @@ func (s *SpecificationGenerator) determineExecutionSpecSeedQuantiles() (float64,
 	return runQtl, memQtl
 }
 
+// determineCorrelatedQuantiles draws runtime and memory quantiles from a Gaussian copula
+func (s *SpecificationGenerator) determineCorrelatedQuantiles() (float64, float64) {
+	rho := s.runtimeMemoryCorrelation
+
+	zRun := s.specRand.NormFloat64()
+	zMem := rho*zRun + math.Sqrt(1-rho*rho)*s.specRand.NormFloat64()
+
+	// quantiles must stay in [0, 1) as the bucket sampler treats 1 as out of range
+	runQtl := math.Min(standardNormalCDF(zRun), math.Nextafter(1, 0))
+	memQtl := math.Min(standardNormalCDF(zMem), math.Nextafter(1, 0))
+
+	return runQtl, memQtl
+}
+
 // GenerateExecuteSpec is not thread safe as it could cause non-repeatable spec generation
 func GenerateExecuteSpec(gen *rand.Rand, runQtl float64, runStats *common.FunctionRuntimeStats) (runtime int) {
 	switch {
@@ -258,18 +350,104 @@ func GenerateMemorySpec(gen *rand.Rand, memQtl float64, memStats *common.Functio
 	return memory
 }
 
-func (s *SpecificationGenerator) generateExecutionSpecs(function *common.Function) common.RuntimeSpecification {
+// executionSampler generates runtime specifications of a single function
+type executionSampler struct {
+	generator *SpecificationGenerator
+	function  *common.Function
+
+	runtimeCDF *inverseCDF
+	memoryCDF  *inverseCDF
+}
+
+func (s *SpecificationGenerator) newExecutionSampler(function *common.Function) *executionSampler {
+	sampler := &executionSampler{
+		generator: s,
+		function:  function,
+	}
+
+	if s.executionSampling != common.BucketSampling && function.RuntimeStats != nil && function.MemoryStats != nil {
+		spline := s.executionSampling == common.SplineSampling
+
+		sampler.runtimeCDF = newRuntimeInverseCDF(function.RuntimeStats, spline)
+		sampler.memoryCDF = newMemoryInverseCDF(function.MemoryStats, spline)
+	}
+
+	return sampler
+}
+
+// newPeriodSamplers creates a sampler for each period of the execution statistics of the function
+func (s *SpecificationGenerator) newPeriodSamplers(function *common.Function) []*executionSampler {
+	var samplers []*executionSampler
+
+	for _, period := range function.ExecutionStatsPeriods {
+		periodFunction := *function
+		periodFunction.RuntimeStats, periodFunction.MemoryStats = period.RuntimeStats, period.MemoryStats
+
+		samplers = append(samplers, s.newExecutionSampler(&periodFunction))
+	}
+
+	return samplers
+}
+
+func (e *executionSampler) generateExecutionSpecs() common.RuntimeSpecification {
+	s, function := e.generator, e.function
+
 	runStats, memStats := function.RuntimeStats, function.MemoryStats
 	if runStats.Count <= 0 || memStats.Count <= 0 {
 		log.Fatal("Invalid duration or memory specification of the function '" + function.Name + "'.")
 	}
 
+	minRuntime, maxRuntime, minMemory, maxMemory := executionLimits(function)
+
 	runQtl, memQtl := s.determineExecutionSpecSeedQuantiles()
-	runtime := common.MinOf(common.MaxExecTimeMilli, common.MaxOf(common.MinExecTimeMilli, GenerateExecuteSpec(s.specRand, runQtl, runStats)))
-	memory := common.MinOf(common.MaxMemQuotaMib, common.MaxOf(common.MinMemQuotaMib, GenerateMemorySpec(s.specRand, memQtl, memStats)))
+
+	if e.runtimeCDF != nil {
+		// continuous sampling keeps sub-millisecond precision of the runtime
+		minRuntimeMicro := common.MinExecTimeMicro
+		if function.Override != nil && function.Override.MinRuntimeMilli > 0 {
+			minRuntimeMicro = minRuntime * 1e3
+		}
+
+		runtimeMicro := int(math.Round(e.runtimeCDF.At(runQtl) * 1e3))
+		runtimeMicro = common.MinOf(maxRuntime*1e3, common.MaxOf(minRuntimeMicro, runtimeMicro))
+		memory := int(math.Round(e.memoryCDF.At(memQtl)))
+
+		return common.RuntimeSpecification{
+			Runtime:             common.MaxOf(common.MinExecTimeMilli, int(math.Round(float64(runtimeMicro)/1e3))),
+			Memory:              common.MinOf(maxMemory, common.MaxOf(minMemory, memory)),
+			RuntimeMicroseconds: runtimeMicro,
+		}
+	}
+
+	runtime := common.MinOf(maxRuntime, common.MaxOf(minRuntime, GenerateExecuteSpec(s.specRand, runQtl, runStats)))
+	memory := common.MinOf(maxMemory, common.MaxOf(minMemory, GenerateMemorySpec(s.specRand, memQtl, memStats)))
 
 	return common.RuntimeSpecification{
 		Runtime: runtime,
 		Memory:  memory,
 	}
 }
+
+// executionLimits returns the runtime and memory clamps of the function, i.e., the global limits unless the function
+// override narrows them down
+func executionLimits(function *common.Function) (minRuntime, maxRuntime, minMemory, maxMemory int) {
+	minRuntime, maxRuntime = common.MinExecTimeMilli, common.MaxExecTimeMilli
+	minMemory, maxMemory = common.MinMemQuotaMib, common.MaxMemQuotaMib
+
+	if override := function.Override; override != nil {
+		if override.MinRuntimeMilli > 0 {
+			minRuntime = override.MinRuntimeMilli
+		}
+		if override.MaxRuntimeMilli > 0 {
+			maxRuntime = override.MaxRuntimeMilli
+		}
+		if override.MinMemoryMiB > 0 {
+			minMemory = override.MinMemoryMiB
+		}
+		if override.MaxMemoryMiB > 0 {
+			maxMemory = override.MaxMemoryMiB
+		}
+	}
+
+	return minRuntime, maxRuntime, minMemory, maxMemory
+}
@@ -29,6 +29,7 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"reflect"
 	"strconv"
 	"sync"
 	"testing"
@@ -836,3 +837,119 @@ func TestGenerateExecutionSpecifications(t *testing.T) {
 		})
 	}
 }
+
+func TestFunctionOverride(t *testing.T) {
+	overrides := []common.FunctionOverride{
+		{NamePattern: "^override-", MinRuntimeMilli: 40, MaxRuntimeMilli: 60, MaxMemoryMiB: 1000},
+		{HashFunction: "hash-1", IATDistribution: "equidistant"},
+	}
+	for i := range overrides {
+		if err := overrides[i].Compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	function := common.Function{
+		Name:            "override-function",
+		InvocationStats: &common.FunctionInvocationStats{HashFunction: "hash-1", Invocations: []int{4}},
+		RuntimeStats:    testFunction.RuntimeStats,
+		MemoryStats:     testFunction.MemoryStats,
+	}
+
+	function.Override = common.FindFunctionOverride(&function, overrides)
+	if function.Override != &overrides[1] {
+		t.Fatal("Override keyed by HashFunction should take precedence over the name pattern.")
+	}
+
+	spec := NewSpecificationGenerator(42).GenerateInvocationData(&function, common.Exponential, false, common.MinuteGranularity)
+	for i := 1; i < len(spec.IAT); i++ {
+		if math.Abs(spec.IAT[i]-15_000_000) > 10e-3 {
+			t.Errorf("IATs should be equidistant after applying the override, got %f.", spec.IAT[i])
+		}
+	}
+
+	function.InvocationStats = &common.FunctionInvocationStats{HashFunction: "hash-2", Invocations: []int{1000}}
+	function.Override = common.FindFunctionOverride(&function, overrides)
+	if function.Override != &overrides[0] {
+		t.Fatal("Override keyed by name pattern has not been found.")
+	}
+
+	spec = NewSpecificationGenerator(42).GenerateInvocationData(&function, common.Exponential, false, common.MinuteGranularity)
+	for _, runtimeSpec := range spec.RuntimeSpecification {
+		if runtimeSpec.Runtime < 40 || runtimeSpec.Runtime > 60 || runtimeSpec.Memory > 1000 {
+			t.Fatalf("Runtime specification %v is not clamped according to the override.", runtimeSpec)
+		}
+	}
+}
+
+func TestPerFunctionSeed(t *testing.T) {
+	createFunctions := func(hashes ...string) []*common.Function {
+		var functions []*common.Function
+		for _, hash := range hashes {
+			functions = append(functions, &common.Function{
+				Name:            "function-" + hash,
+				InvocationStats: &common.FunctionInvocationStats{HashFunction: hash, Invocations: []int{20, 30}},
+				RuntimeStats:    testFunction.RuntimeStats,
+				MemoryStats:     testFunction.MemoryStats,
+			})
+		}
+
+		return functions
+	}
+
+	generate := func(mode common.SeedMode, functions []*common.Function) map[string]*common.FunctionSpecification {
+		sg := NewSpecificationGenerator(42).WithSeedMode(mode)
+
+		result := make(map[string]*common.FunctionSpecification)
+		for _, function := range functions {
+			result[function.Name] = sg.GenerateInvocationData(function, common.Exponential, true, common.MinuteGranularity)
+		}
+
+		return result
+	}
+
+	full := generate(common.PerFunctionSeed, createFunctions("a", "b", "c"))
+	reduced := generate(common.PerFunctionSeed, createFunctions("a", "c"))
+	if !reflect.DeepEqual(full["function-c"], reduced["function-c"]) {
+		t.Error("Removing a function changed the specification of another function in the per-function seed mode.")
+	}
+
+	full = generate(common.LegacySeed, createFunctions("a", "b", "c"))
+	reduced = generate(common.LegacySeed, createFunctions("a", "c"))
+	if !reflect.DeepEqual(full["function-a"], reduced["function-a"]) {
+		t.Error("The specification of the first function should not depend on the rest of the trace.")
+	}
+	if reflect.DeepEqual(full["function-c"], reduced["function-c"]) {
+		t.Error("The legacy seed mode should consume a single stream in function order.")
+	}
+
+	legacy := NewSpecificationGenerator(42).GenerateInvocationData(createFunctions("a")[0], common.Exponential, true, common.MinuteGranularity)
+	if !reflect.DeepEqual(legacy, full["function-a"]) {
+		t.Error("The legacy seed mode should be the default.")
+	}
+}
+
+func TestExecutionStatsPeriods(t *testing.T) {
+	secondDay := &common.FunctionRuntimeStats{
+		Average: 500, Count: 100, Minimum: 500, Maximum: 500,
+		Percentile0: 500, Percentile1: 500, Percentile25: 500, Percentile50: 500, Percentile75: 500, Percentile99: 500, Percentile100: 500,
+	}
+
+	function := common.Function{
+		Name:            "multi-day-function",
+		InvocationStats: &common.FunctionInvocationStats{Invocations: []int{50, 50}},
+		RuntimeStats:    testFunction.RuntimeStats,
+		MemoryStats:     testFunction.MemoryStats,
+		ExecutionStatsPeriods: []common.ExecutionStatsPeriod{
+			{FromMinute: 0, RuntimeStats: testFunction.RuntimeStats, MemoryStats: testFunction.MemoryStats},
+			{FromMinute: 1, RuntimeStats: secondDay, MemoryStats: testFunction.MemoryStats},
+		},
+	}
+
+	spec := NewSpecificationGenerator(42).GenerateInvocationData(&function, common.Equidistant, false, common.MinuteGranularity)
+	for i, runtimeSpec := range spec.RuntimeSpecification {
+		if secondPeriod := i >= 50; secondPeriod != (runtimeSpec.Runtime == 500) {
+			t.Fatalf("Invocation %d has runtime %d that does not follow the statistics of its period.", i, runtimeSpec.Runtime)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"gonum.org/v1/gonum/stat"
+)
+
+// ValidationConfiguration holds the thresholds the generated specification is validated with
+type ValidationConfiguration struct {
+	// Alpha Family-wise significance level of the IAT distribution tests, Bonferroni-corrected per function
+	Alpha float64
+	// PercentileTolerance Maximum difference between the nominal probability of a trace percentile and the share of
+	// generated samples not above it, on top of the sampling noise allowed by the Dvoretzky-Kiefer-Wolfowitz bound
+	PercentileTolerance float64
+	// MinSamples Minimum number of samples for a statistical check to be performed
+	MinSamples int
+	// Seed Seed of the reference samples of the IAT distribution tests
+	Seed int64
+}
+
+type ValidationReport struct {
+	Passed              bool                  `json:"Passed"`
+	Alpha               float64               `json:"Alpha"`
+	PercentileTolerance float64               `json:"PercentileTolerance"`
+	FailedFunctions     int                   `json:"FailedFunctions"`
+	Functions           []*FunctionValidation `json:"Functions"`
+}
+
+type FunctionValidation struct {
+	Name         string   `json:"Name"`
+	HashFunction string   `json:"HashFunction"`
+	Passed       bool     `json:"Passed"`
+	Failures     []string `json:"Failures"`
+
+	// CountMismatchMinutes Number of minutes in which the generated number of invocations differs from the trace
+	CountMismatchMinutes int `json:"CountMismatchMinutes"`
+
+	IAT *DistributionTest `json:"IAT"`
+
+	// ExecutionValidation Validation of the runtime and memory samples against the statistics in effect from the
+	// beginning of the trace window
+	ExecutionValidation
+	// Periods Validation of the samples of each later period if the statistics change over the trace window, e.g., per
+	// day of a multi-day trace
+	Periods []*ExecutionValidation `json:"Periods,omitempty"`
+}
+
+// ExecutionValidation compares the runtime and memory samples of a period with the statistics in effect in the period
+type ExecutionValidation struct {
+	FromMinute int `json:"FromMinute,omitempty"`
+
+	RuntimePercentileErrors map[string]float64 `json:"RuntimePercentileErrors"`
+	MemoryPercentileErrors  map[string]float64 `json:"MemoryPercentileErrors"`
+
+	RuntimeClamping ClampingReport `json:"RuntimeClamping"`
+	MemoryClamping  ClampingReport `json:"MemoryClamping"`
+}
+
+type DistributionTest struct {
+	// Test Either 'KS' (two-sample Kolmogorov-Smirnov against a reference sample) or 'equidistance'
+	Test      string  `json:"Test"`
+	Samples   int     `json:"Samples"`
+	Statistic float64 `json:"Statistic"`
+	PValue    float64 `json:"PValue"`
+}
+
+// ClampingReport compares the share of the trace distribution outside of the runtime/memory limits with the share of
+// generated samples that hit the limits
+type ClampingReport struct {
+	Minimum int `json:"Minimum"`
+	Maximum int `json:"Maximum"`
+
+	ExpectedBelowMinimum float64 `json:"ExpectedBelowMinimum"`
+	ExpectedAboveMaximum float64 `json:"ExpectedAboveMaximum"`
+	AtMinimum            float64 `json:"AtMinimum"`
+	AtMaximum            float64 `json:"AtMaximum"`
+}
+
+var runtimeProbabilities = []float64{0, 0.01, 0.25, 0.50, 0.75, 0.99, 1}
+var memoryProbabilities = []float64{0.01, 0.05, 0.25, 0.50, 0.75, 0.95, 0.99, 1}
+
+func runtimePercentiles(stats *common.FunctionRuntimeStats) []float64 {
+	return []float64{stats.Percentile0, stats.Percentile1, stats.Percentile25, stats.Percentile50,
+		stats.Percentile75, stats.Percentile99, stats.Percentile100}
+}
+
+func memoryPercentiles(stats *common.FunctionMemoryStats) []float64 {
+	return []float64{stats.Percentile1, stats.Percentile5, stats.Percentile25, stats.Percentile50,
+		stats.Percentile75, stats.Percentile95, stats.Percentile99, stats.Percentile100}
+}
+
+// ValidateSpecification checks that the generated specifications of the functions follow the trace they have been
+// generated from
+func ValidateSpecification(functions []*common.Function, iatDistribution common.IatDistribution, shiftIAT bool,
+	granularity common.TraceGranularity, cfg ValidationConfiguration) *ValidationReport {
+
+	report := &ValidationReport{
+		Passed:              true,
+		Alpha:               cfg.Alpha,
+		PercentileTolerance: cfg.PercentileTolerance,
+	}
+
+	gen := rand.New(rand.NewSource(cfg.Seed))
+	// Bonferroni correction, as thousands of functions are tested at once
+	alpha := cfg.Alpha / float64(common.MaxOf(len(functions), 1))
+
+	for _, function := range functions {
+		distribution, shift := iatDistribution, shiftIAT
+		if function.Override != nil && function.Override.IATDistribution != "" {
+			distribution, shift, _ = common.ParseIATDistribution(function.Override.IATDistribution)
+		}
+
+		validation := validateFunction(function, distribution, shift, granularity, cfg, alpha, gen)
+		if !validation.Passed {
+			report.Passed = false
+			report.FailedFunctions++
+		}
+
+		report.Functions = append(report.Functions, validation)
+	}
+
+	return report
+}
+
+func validateFunction(function *common.Function, iatDistribution common.IatDistribution, shiftIAT bool,
+	granularity common.TraceGranularity, cfg ValidationConfiguration, alpha float64, gen *rand.Rand) *FunctionValidation {
+
+	validation := &FunctionValidation{
+		Name:     function.Name,
+		Failures: []string{},
+	}
+	fail := func(format string, args ...any) {
+		validation.Failures = append(validation.Failures, fmt.Sprintf(format, args...))
+	}
+
+	spec := function.Specification
+	if spec == nil {
+		fail("no specification has been generated")
+		return validation
+	}
+
+	if function.InvocationStats != nil {
+		validation.HashFunction = function.InvocationStats.HashFunction
+
+		for minute, count := range spec.PerMinuteCount {
+			if minute >= len(function.InvocationStats.Invocations) || count != function.InvocationStats.Invocations[minute] {
+				validation.CountMismatchMinutes++
+			}
+		}
+		if validation.CountMismatchMinutes > 0 {
+			fail("number of invocations differs from the trace in %d minutes", validation.CountMismatchMinutes)
+		}
+	}
+
+	validation.IAT = testIATDistribution(spec, iatDistribution, shiftIAT, granularity, gen)
+	if validation.IAT.Samples >= cfg.MinSamples && validation.IAT.PValue < alpha {
+		fail("IATs do not follow the distribution (%s statistic %.4f, p-value %.2e)",
+			validation.IAT.Test, validation.IAT.Statistic, validation.IAT.PValue)
+	}
+
+	if function.RuntimeStats == nil || function.MemoryStats == nil {
+		validation.Passed = len(validation.Failures) == 0
+		return validation
+	}
+
+	// the samples of each period are generated from the statistics in effect in it
+	periods := []common.ExecutionStatsPeriod{{RuntimeStats: function.RuntimeStats, MemoryStats: function.MemoryStats}}
+	periods = append(periods, function.ExecutionStatsPeriods...)
+	samples := make([][]common.RuntimeSpecification, len(periods))
+
+	current, index := 0, 0
+	for minute, count := range spec.PerMinuteCount {
+		for ; current+1 < len(periods) && periods[current+1].FromMinute <= minute; current++ {
+		}
+
+		for j := 0; j < count && index < len(spec.RuntimeSpecification); j++ {
+			samples[current] = append(samples[current], spec.RuntimeSpecification[index])
+			index++
+		}
+	}
+
+	for i, period := range periods {
+		if period.RuntimeStats == nil || period.MemoryStats == nil || (i > 0 && len(samples[i]) == 0) {
+			continue
+		}
+
+		execution, failures := validateExecution(function, &periods[i], samples[i], cfg, alpha)
+		if i == 0 {
+			validation.ExecutionValidation = *execution
+		} else {
+			validation.Periods = append(validation.Periods, execution)
+		}
+
+		for _, failure := range failures {
+			if i > 0 {
+				failure = fmt.Sprintf("from minute %d, %s", period.FromMinute, failure)
+			}
+			fail("%s", failure)
+		}
+	}
+
+	validation.Passed = len(validation.Failures) == 0
+	return validation
+}
+
+// validateExecution compares the runtime and memory samples of the period with its statistics
+func validateExecution(function *common.Function, period *common.ExecutionStatsPeriod, samples []common.RuntimeSpecification,
+	cfg ValidationConfiguration, alpha float64) (*ExecutionValidation, []string) {
+
+	validation := &ExecutionValidation{FromMinute: period.FromMinute}
+	var failures []string
+
+	var runtimes, memory []float64
+	for _, runtimeSpec := range samples {
+		if runtimeSpec.RuntimeMicroseconds > 0 {
+			runtimes = append(runtimes, float64(runtimeSpec.RuntimeMicroseconds)/1e3)
+		} else {
+			runtimes = append(runtimes, float64(runtimeSpec.Runtime))
+		}
+
+		memory = append(memory, float64(runtimeSpec.Memory))
+	}
+	sort.Float64s(runtimes)
+	sort.Float64s(memory)
+
+	minRuntime, maxRuntime, minMemory, maxMemory := executionLimits(function)
+
+	validation.RuntimeClamping = clampingReport(runtimes, runtimePercentiles(period.RuntimeStats), runtimeProbabilities, minRuntime, maxRuntime)
+	validation.MemoryClamping = clampingReport(memory, memoryPercentiles(period.MemoryStats), memoryProbabilities, minMemory, maxMemory)
+
+	if len(runtimes) >= cfg.MinSamples {
+		validation.RuntimePercentileErrors = percentileErrors(runtimes, runtimePercentiles(period.RuntimeStats),
+			runtimeProbabilities, minRuntime, maxRuntime)
+		validation.MemoryPercentileErrors = percentileErrors(memory, memoryPercentiles(period.MemoryStats),
+			memoryProbabilities, minMemory, maxMemory)
+
+		// sampling noise of the empirical CDF at the significance level
+		tolerance := cfg.PercentileTolerance + math.Sqrt(math.Log(2/alpha)/(2*float64(len(runtimes))))
+
+		for _, kind := range []struct {
+			name   string
+			errors map[string]float64
+		}{{"runtime", validation.RuntimePercentileErrors}, {"memory", validation.MemoryPercentileErrors}} {
+			for _, percentile := range sortedKeys(kind.errors) {
+				if kind.errors[percentile] > tolerance {
+					failures = append(failures, fmt.Sprintf("share of %s samples below the trace %s is off by %.3f",
+						kind.name, percentile, kind.errors[percentile]))
+				}
+			}
+		}
+	}
+
+	return validation, failures
+}
+
+// withinMinuteGaps returns the gaps between consecutive invocations of the same minute, along with the number of
+// invocations in the minute of each gap
+func withinMinuteGaps(spec *common.FunctionSpecification) ([]float64, []int) {
+	var gaps []float64
+	var counts []int
+
+	timestamp, index := 0.0, 0
+	for _, count := range spec.PerMinuteCount {
+		previous := 0.0
+		for j := 0; j < count && index < len(spec.IAT); j++ {
+			timestamp += spec.IAT[index]
+			index++
+
+			if j > 0 {
+				gaps = append(gaps, timestamp-previous)
+				counts = append(counts, count)
+			}
+			previous = timestamp
+		}
+	}
+
+	return gaps, counts
+}
+
+// referenceGaps simulates the generator for a minute with n invocations and returns the gaps between consecutive
+// invocations, i.e., all the IATs but the one cut by the end of the minute
+func referenceGaps(n int, iatDistribution common.IatDistribution, shiftIAT bool, gen *rand.Rand) []float64 {
+	iats := make([]float64, n)
+	total := 0.0
+	for i := range iats {
+		if iatDistribution == common.Uniform {
+			iats[i] = gen.Float64()
+		} else {
+			iats[i] = gen.ExpFloat64()
+		}
+		total += iats[i]
+	}
+
+	cut := n - 1
+	if shiftIAT {
+		split, sum := gen.Float64()*total, 0.0
+		for cut = 0; cut < n-1; cut++ {
+			sum += iats[cut]
+			if sum > split {
+				break
+			}
+		}
+	}
+
+	var gaps []float64
+	for i := range iats {
+		if i != cut {
+			gaps = append(gaps, iats[i]/total)
+		}
+	}
+
+	return gaps
+}
+
+func testIATDistribution(spec *common.FunctionSpecification, iatDistribution common.IatDistribution, shiftIAT bool,
+	granularity common.TraceGranularity, gen *rand.Rand) *DistributionTest {
+
+	unit := getBlankTimeUnit(granularity)
+	gaps, counts := withinMinuteGaps(spec)
+
+	if iatDistribution == common.Equidistant {
+		deviation := 0.0
+		for i, gap := range gaps {
+			expected := unit / float64(counts[i])
+			deviation = math.Max(deviation, math.Abs(gap-expected)/expected)
+		}
+
+		pValue := 1.0
+		// tolerates the microsecond precision of the IATs
+		if deviation > 1e-3 {
+			pValue = 0
+		}
+
+		return &DistributionTest{Test: "equidistance", Samples: len(gaps), Statistic: deviation, PValue: pValue}
+	}
+
+	observed := make([]float64, len(gaps))
+	for i, gap := range gaps {
+		observed[i] = gap / unit
+	}
+
+	// one simulated minute per generated minute
+	var reference []float64
+	for _, count := range spec.PerMinuteCount {
+		if count > 1 {
+			reference = append(reference, referenceGaps(count, iatDistribution, shiftIAT, gen)...)
+		}
+	}
+
+	if len(observed) == 0 || len(reference) == 0 {
+		return &DistributionTest{Test: "KS", PValue: 1}
+	}
+
+	sort.Float64s(observed)
+	sort.Float64s(reference)
+
+	statistic := stat.KolmogorovSmirnov(observed, nil, reference, nil)
+	effective := float64(len(observed)) * float64(len(reference)) / float64(len(observed)+len(reference))
+
+	return &DistributionTest{
+		Test:      "KS",
+		Samples:   len(observed),
+		Statistic: statistic,
+		PValue:    kolmogorovPValue(statistic, effective),
+	}
+}
+
+// kolmogorovPValue approximates the p-value of the Kolmogorov-Smirnov statistic for the effective number of samples
+func kolmogorovPValue(statistic float64, effective float64) float64 {
+	sqrtN := math.Sqrt(effective)
+	lambda := (sqrtN + 0.12 + 0.11/sqrtN) * statistic
+
+	if lambda < 1e-3 {
+		return 1
+	}
+
+	sum, sign := 0.0, 1.0
+	for k := 1; k <= 100; k++ {
+		term := sign * math.Exp(-2*float64(k*k)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+
+	return math.Min(math.Max(2*sum, 0), 1)
+}
+
+// percentileErrors compares the nominal probability of each trace percentile with the share of the sorted samples
+// below and not above it. Percentiles outside the limits are skipped, as their deviation is due to clamping.
+func percentileErrors(samples []float64, percentiles []float64, probabilities []float64, minimum, maximum int) map[string]float64 {
+	result := make(map[string]float64)
+
+	for i, p := range probabilities {
+		// the extremes are determined by a few samples only
+		if p == 0 || p == 1 || percentiles[i] < float64(minimum) || percentiles[i] > float64(maximum) {
+			continue
+		}
+
+		// equal percentiles in the trace form a point mass, hence p only has to be within the jump of the CDF
+		below := float64(sort.SearchFloat64s(samples, percentiles[i])) / float64(len(samples))
+		notAbove := float64(sort.Search(len(samples), func(j int) bool { return samples[j] > percentiles[i] })) / float64(len(samples))
+
+		result[fmt.Sprintf("p%g", p*100)] = math.Max(math.Max(below-p, p-notAbove), 0)
+	}
+
+	return result
+}
+
+func clampingReport(samples []float64, percentiles []float64, probabilities []float64, minimum, maximum int) ClampingReport {
+	report := ClampingReport{
+		Minimum:              minimum,
+		Maximum:              maximum,
+		ExpectedBelowMinimum: traceCDF(float64(minimum), percentiles, probabilities),
+		ExpectedAboveMaximum: 1 - traceCDF(float64(maximum), percentiles, probabilities),
+	}
+
+	if len(samples) == 0 {
+		return report
+	}
+
+	for _, sample := range samples {
+		if sample <= float64(minimum) {
+			report.AtMinimum++
+		}
+		if sample >= float64(maximum) {
+			report.AtMaximum++
+		}
+	}
+	report.AtMinimum /= float64(len(samples))
+	report.AtMaximum /= float64(len(samples))
+
+	return report
+}
+
+// traceCDF interpolates the share of the trace distribution below the value from the percentiles
+func traceCDF(value float64, percentiles []float64, probabilities []float64) float64 {
+	if value < percentiles[0] {
+		return 0
+	}
+
+	for i := 1; i < len(percentiles); i++ {
+		if value < percentiles[i] {
+			return probabilities[i-1] + (probabilities[i]-probabilities[i-1])*
+				(value-percentiles[i-1])/(percentiles[i]-percentiles[i-1])
+		}
+	}
+
+	return 1
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
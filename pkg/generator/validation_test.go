@@ -0,0 +1,141 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestValidateSpecification(t *testing.T) {
+	cfg := ValidationConfiguration{Alpha: 0.01, PercentileTolerance: 0.02, MinSamples: 100, Seed: 7}
+
+	createFunction := func() *common.Function {
+		return &common.Function{
+			Name:            "validated-function",
+			InvocationStats: &common.FunctionInvocationStats{HashFunction: "hash", Invocations: []int{500, 300, 700, 0, 2}},
+			RuntimeStats:    testFunction.RuntimeStats,
+			MemoryStats:     testFunction.MemoryStats,
+		}
+	}
+
+	for _, test := range []struct {
+		distribution common.IatDistribution
+		shift        bool
+	}{
+		{common.Exponential, false},
+		{common.Exponential, true},
+		{common.Uniform, false},
+		{common.Uniform, true},
+		{common.Equidistant, false},
+	} {
+		function := createFunction()
+		function.Specification = NewSpecificationGenerator(42).
+			GenerateInvocationData(function, test.distribution, test.shift, common.MinuteGranularity)
+
+		report := ValidateSpecification([]*common.Function{function}, test.distribution, test.shift, common.MinuteGranularity, cfg)
+		if !report.Passed {
+			t.Errorf("Generated specification (%v, shift %v) failed validation: %v", test.distribution, test.shift, report.Functions[0].Failures)
+		}
+	}
+
+	// equidistant IATs do not pass as exponential ones
+	function := createFunction()
+	function.Specification = NewSpecificationGenerator(42).
+		GenerateInvocationData(function, common.Equidistant, false, common.MinuteGranularity)
+
+	report := ValidateSpecification([]*common.Function{function}, common.Exponential, false, common.MinuteGranularity, cfg)
+	if report.Passed || report.FailedFunctions != 1 || report.Functions[0].IAT.PValue >= cfg.Alpha {
+		t.Error("Equidistant IATs should fail the test of the exponential distribution.")
+	}
+
+	// mismatched counts and runtimes are reported
+	function.Specification.PerMinuteCount[1]++
+	for i := range function.Specification.RuntimeSpecification {
+		function.Specification.RuntimeSpecification[i].Runtime *= 2
+	}
+
+	validation := ValidateSpecification([]*common.Function{function}, common.Equidistant, false, common.MinuteGranularity, cfg).Functions[0]
+	if validation.CountMismatchMinutes != 1 {
+		t.Errorf("Expected one minute with mismatched counts, got %d.", validation.CountMismatchMinutes)
+	}
+	if validation.RuntimePercentileErrors["p50"] < 0.2 {
+		t.Errorf("Doubled runtimes should be reported, got median error %f.", validation.RuntimePercentileErrors["p50"])
+	}
+
+	// clamping is reported
+	function = createFunction()
+	function.Override = &common.FunctionOverride{MinRuntimeMilli: 50}
+	function.Specification = NewSpecificationGenerator(42).
+		GenerateInvocationData(function, common.Exponential, false, common.MinuteGranularity)
+
+	validation = ValidateSpecification([]*common.Function{function}, common.Exponential, false, common.MinuteGranularity, cfg).Functions[0]
+	if !validation.Passed {
+		t.Errorf("Clamping should not fail the validation: %v", validation.Failures)
+	}
+	if clamping := validation.RuntimeClamping; clamping.Minimum != 50 || clamping.AtMinimum < 0.4 || clamping.ExpectedBelowMinimum < 0.4 {
+		t.Errorf("Unexpected runtime clamping report %+v.", clamping)
+	}
+}
+
+func TestValidateExecutionStatsPeriods(t *testing.T) {
+	cfg := ValidationConfiguration{Alpha: 0.01, PercentileTolerance: 0.02, MinSamples: 100, Seed: 7}
+
+	secondDay := *testFunction.RuntimeStats
+	for _, percentile := range []*float64{&secondDay.Average, &secondDay.Minimum, &secondDay.Maximum, &secondDay.Percentile0,
+		&secondDay.Percentile1, &secondDay.Percentile25, &secondDay.Percentile50, &secondDay.Percentile75,
+		&secondDay.Percentile99, &secondDay.Percentile100} {
+		*percentile *= 10
+	}
+
+	function := &common.Function{
+		Name:            "multi-day-function",
+		InvocationStats: &common.FunctionInvocationStats{HashFunction: "hash", Invocations: []int{600, 600}},
+		RuntimeStats:    testFunction.RuntimeStats,
+		MemoryStats:     testFunction.MemoryStats,
+		ExecutionStatsPeriods: []common.ExecutionStatsPeriod{
+			{FromMinute: 1, RuntimeStats: &secondDay, MemoryStats: testFunction.MemoryStats},
+		},
+	}
+	function.Specification = NewSpecificationGenerator(42).
+		GenerateInvocationData(function, common.Exponential, false, common.MinuteGranularity)
+
+	validation := ValidateSpecification([]*common.Function{function}, common.Exponential, false, common.MinuteGranularity, cfg).Functions[0]
+	if !validation.Passed {
+		t.Errorf("Generated specification failed validation: %v", validation.Failures)
+	}
+	if len(validation.Periods) != 1 || validation.Periods[0].FromMinute != 1 || len(validation.Periods[0].RuntimePercentileErrors) == 0 {
+		t.Errorf("Expected the validation of the period from minute 1, got %+v.", validation.Periods)
+	}
+
+	// the samples of the second period do not follow the statistics of the first one
+	function.ExecutionStatsPeriods = nil
+
+	validation = ValidateSpecification([]*common.Function{function}, common.Exponential, false, common.MinuteGranularity, cfg).Functions[0]
+	if validation.Passed || validation.RuntimePercentileErrors["p50"] < 0.2 {
+		t.Errorf("Runtimes of another period should fail the validation, got median error %f.", validation.RuntimePercentileErrors["p50"])
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// CreateWorkflows creates the functions of the nodes of the workflows and the lists of nodes the driver invokes them
+// through. Every node is a function of its own, a copy of the trace function
+// it is bound to or a function with the explicit runtime and memory of the node, invoked as often as the workflow.
+func CreateWorkflows(definitions []common.WorkflowDefinition, functions []*common.Function, duration int) ([]*common.Function, []*list.List, error) {
+	traceFunctions := make(map[string]*common.Function)
+	for _, function := range functions {
+		traceFunctions[function.Name] = function
+		if function.InvocationStats != nil {
+			traceFunctions[function.InvocationStats.HashFunction] = function
+		}
+	}
+
+	var workflowFunctions []*common.Function
+	var workflows []*list.List
+
+	for _, definition := range definitions {
+		nodeFunctions := make(map[string]*common.Function)
+		for _, node := range definition.Nodes {
+			var function *common.Function
+
+			if node.Function != "" {
+				traceFunction, ok := traceFunctions[node.Function]
+				if !ok {
+					return nil, nil, fmt.Errorf("node %s of workflow %s is bound to function %s, which is not in the trace",
+						node.Name, definition.Name, node.Function)
+				}
+
+				function = workflowTraceFunction(traceFunction)
+			} else {
+				function = workflowExplicitFunction(&definition, &node)
+			}
+
+			function.Name = fmt.Sprintf("%s-%d-wf", common.FunctionNamePrefix, len(workflowFunctions))
+			nodeFunctions[node.Name] = function
+			workflowFunctions = append(workflowFunctions, function)
+		}
+
+		var invocations []int
+		if definition.ArrivalRate > 0 {
+			invocations = constantArrivals(definition.ArrivalRate, duration)
+		} else {
+			// the root is bound to a trace function, which the workflow follows
+			invocations = nodeFunctions[workflowRoot(&definition)].InvocationStats.Invocations
+		}
+		for _, function := range nodeFunctions {
+			function.InvocationStats.Invocations = invocations
+		}
+
+		workflows = append(workflows, createWorkflowList(&definition, nodeFunctions))
+	}
+
+	return workflowFunctions, workflows, nil
+}
+
+func workflowTraceFunction(traceFunction *common.Function) *common.Function {
+	function := &common.Function{
+		RuntimeStats:          traceFunction.RuntimeStats,
+		MemoryStats:           traceFunction.MemoryStats,
+		ExecutionStatsPeriods: traceFunction.ExecutionStatsPeriods,
+		DirigentMetadata:      traceFunction.DirigentMetadata,
+		ColdStartBusyLoopMs:   traceFunction.ColdStartBusyLoopMs,
+	}
+
+	invocationStats := *traceFunction.InvocationStats
+	function.InvocationStats = &invocationStats
+
+	return function
+}
+
+func workflowExplicitFunction(definition *common.WorkflowDefinition, node *common.WorkflowNode) *common.Function {
+	runtime := float64(node.RuntimeMilli)
+	memory := float64(node.MemoryMiB)
+	if node.MemoryMiB == 0 {
+		memory = common.DefaultTraceMemoryMiB
+	}
+
+	hashFunction := fmt.Sprintf("%s/%s", definition.Name, node.Name)
+
+	return &common.Function{
+		InvocationStats: &common.FunctionInvocationStats{
+			HashOwner:    definition.Name,
+			HashApp:      definition.Name,
+			HashFunction: hashFunction,
+			Trigger:      "orchestration",
+		},
+		RuntimeStats: &common.FunctionRuntimeStats{
+			HashOwner:     definition.Name,
+			HashApp:       definition.Name,
+			HashFunction:  hashFunction,
+			Average:       runtime,
+			Count:         1,
+			Minimum:       runtime,
+			Maximum:       runtime,
+			Percentile0:   runtime,
+			Percentile1:   runtime,
+			Percentile25:  runtime,
+			Percentile50:  runtime,
+			Percentile75:  runtime,
+			Percentile99:  runtime,
+			Percentile100: runtime,
+		},
+		MemoryStats: &common.FunctionMemoryStats{
+			HashOwner:     definition.Name,
+			HashApp:       definition.Name,
+			HashFunction:  hashFunction,
+			Count:         1,
+			Average:       memory,
+			Percentile1:   memory,
+			Percentile5:   memory,
+			Percentile25:  memory,
+			Percentile50:  memory,
+			Percentile75:  memory,
+			Percentile95:  memory,
+			Percentile99:  memory,
+			Percentile100: memory,
+		},
+		ColdStartBusyLoopMs: ComputeBusyLoopPeriod(int(memory)),
+	}
+}
+
+// constantArrivals spreads rate invocations per minute over the minutes, carrying the fractions over
+func constantArrivals(rate float64, duration int) []int {
+	invocations := make([]int, duration)
+	for minute := 0; minute < duration; minute++ {
+		invocations[minute] = int(math.Floor(float64(minute+1)*rate) - math.Floor(float64(minute)*rate))
+	}
+
+	return invocations
+}
+
+func workflowRoot(definition *common.WorkflowDefinition) string {
+	hasParent := make(map[string]bool)
+	for _, edge := range definition.Edges {
+		hasParent[edge.To] = true
+	}
+
+	for _, node := range definition.Nodes {
+		if !hasParent[node.Name] {
+			return node.Name
+		}
+	}
+
+	return ""
+}
+
+// createWorkflowList lays the workflow out as a list holding its root, with every child of a node starting one of its
+// Branches. A join, i.e., a node with several parents, starts the same list in the Branches of all of its parents.
+func createWorkflowList(definition *common.WorkflowDefinition, functions map[string]*common.Function) *list.List {
+	children := make(map[string][]common.WorkflowEdge)
+	parents := make(map[string]int)
+	for _, edge := range definition.Edges {
+		children[edge.From] = append(children[edge.From], edge)
+		parents[edge.To]++
+	}
+
+	dagIdentifier := fmt.Sprintf("%s,", definition.Name)
+
+	lists := make(map[string]*list.List)
+	for _, node := range definition.Nodes {
+		quorum := node.Quorum
+		if quorum == 0 {
+			quorum = parents[node.Name]
+		}
+
+		lists[node.Name] = list.New()
+		lists[node.Name].PushBack(&common.Node{
+			Name:     node.Name,
+			Function: functions[node.Name],
+			DAG:      dagIdentifier,
+			Parents:  parents[node.Name],
+			Quorum:   quorum,
+		})
+	}
+
+	// nodes in topological order, with the depth of a node being its longest distance from the root
+	remaining := make(map[string]int)
+	for name, count := range parents {
+		remaining[name] = count
+	}
+	queue := []string{workflowRoot(definition)}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		node := lists[name].Front().Value.(*common.Node)
+		for _, edge := range children[name] {
+			childNode := lists[edge.To].Front().Value.(*common.Node)
+			childNode.Depth = max(childNode.Depth, node.Depth+1)
+			node.Branches = append(node.Branches, lists[edge.To])
+			node.Edges = append(node.Edges, edge)
+
+			if remaining[edge.To]--; remaining[edge.To] == 0 {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	return lists[workflowRoot(definition)]
+}
@@ -0,0 +1,124 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestCreateWorkflows(t *testing.T) {
+	traceFunction := &common.Function{
+		Name: "trace-func-0-42",
+		InvocationStats: &common.FunctionInvocationStats{
+			HashFunction: "hash",
+			Invocations:  []int{1, 2, 3},
+		},
+		RuntimeStats: &common.FunctionRuntimeStats{Count: 1, Average: 10},
+		MemoryStats:  &common.FunctionMemoryStats{Count: 1, Average: 128},
+	}
+
+	definitions := []common.WorkflowDefinition{
+		{
+			Name:        "rate",
+			ArrivalRate: 1.5,
+			Nodes: []common.WorkflowNode{
+				{Name: "root", RuntimeMilli: 100},
+				{Name: "a", Function: "trace-func-0-42"},
+				{Name: "b", RuntimeMilli: 20, MemoryMiB: 512},
+				{Name: "c", RuntimeMilli: 30, Quorum: 1},
+			},
+			Edges: []common.WorkflowEdge{
+				{From: "root", To: "a"},
+				{From: "root", To: "b", PayloadBytes: 1024},
+				{From: "a", To: "c"},
+				{From: "b", To: "c"},
+			},
+		},
+		{
+			Name:  "trace",
+			Nodes: []common.WorkflowNode{{Name: "root", Function: "hash"}},
+		},
+	}
+
+	functions, workflows, err := CreateWorkflows(definitions, []*common.Function{traceFunction}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(functions) != 5 || len(workflows) != 2 {
+		t.Fatalf("Expected 5 functions in 2 workflows, got %d and %d.", len(functions), len(workflows))
+	}
+
+	// root -> (a, b) -> c, with c joining both branches
+	first := workflows[0]
+	root := first.Front().Value.(*common.Node)
+	if first.Len() != 1 || root.Function != functions[0] || root.DAG != "rate," || len(root.Branches) != 2 {
+		t.Fatal("Unexpected root of the first workflow.")
+	}
+
+	a, b := root.Branches[0].Front().Value.(*common.Node), root.Branches[1].Front().Value.(*common.Node)
+	if a.Function != functions[1] || b.Function != functions[2] || len(a.Branches) != 1 || len(b.Branches) != 1 {
+		t.Fatal("Unexpected branches of the root of the first workflow.")
+	}
+	if len(root.Edges) != 2 || root.Edges[1].To != "b" || root.Edges[1].PayloadBytes != 1024 {
+		t.Errorf("Expected the root to keep the edges to its branches, got %+v.", root.Edges)
+	}
+	if a.Branches[0] != b.Branches[0] {
+		t.Error("Both parents of the join should start the same list.")
+	}
+
+	c := a.Branches[0].Front().Value.(*common.Node)
+	if c.Function != functions[3] || c.Parents != 2 || c.Quorum != 1 || c.Depth != 2 || a.Quorum != 1 {
+		t.Errorf("Unexpected join node %+v.", c)
+	}
+
+	for _, function := range functions[:4] {
+		if !reflect.DeepEqual(function.InvocationStats.Invocations, []int{1, 2, 1}) {
+			t.Errorf("Unexpected invocations %v of %s.", function.InvocationStats.Invocations, function.Name)
+		}
+	}
+	if !reflect.DeepEqual(functions[4].InvocationStats.Invocations, []int{1, 2, 3}) {
+		t.Errorf("Expected the invocations of the trace function, got %v.", functions[4].InvocationStats.Invocations)
+	}
+
+	if functions[1].RuntimeStats != traceFunction.RuntimeStats || functions[1].InvocationStats == traceFunction.InvocationStats {
+		t.Error("Node bound to a trace function should copy its statistics without sharing its invocations.")
+	}
+	if functions[2].RuntimeStats.Percentile99 != 20 || functions[2].MemoryStats.Percentile100 != 512 ||
+		functions[3].MemoryStats.Percentile50 != common.DefaultTraceMemoryMiB {
+
+		t.Error("Unexpected statistics of the nodes with explicit runtime and memory.")
+	}
+	if functions[0].Name != "trace-func-0-wf" || functions[4].Name != "trace-func-4-wf" {
+		t.Errorf("Unexpected function names %s and %s.", functions[0].Name, functions[4].Name)
+	}
+
+	if _, _, err = CreateWorkflows([]common.WorkflowDefinition{{Name: "missing", Nodes: []common.WorkflowNode{{Name: "x", Function: "unknown"}}}}, nil, 3); err == nil {
+		t.Error("Expected an error for a node bound to a function not in the trace.")
+	}
+}
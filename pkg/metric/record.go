@@ -57,6 +57,45 @@ type ExecutionRecordBase struct {
 	FunctionTimeout   bool `csv:"functionTimeout"`
 }
 
+// WorkflowRecord summarizes one invocation of a DAG, whose nodes have execution records with the same InvocationID
+type WorkflowRecord struct {
+	Phase        int    `csv:"phase"`
+	Workflow     string `csv:"workflow"`
+	InvocationID string `csv:"invocationID"`
+	StartTime    int64  `csv:"startTime"`
+	EndTime      int64  `csv:"endTime"`
+
+	NodesInvoked int `csv:"nodesInvoked"`
+	NodesFailed  int `csv:"nodesFailed"`
+
+	// Path Edges taken in a workflow definition as From->To, sorted and separated by semicolons
+	Path string `csv:"path"`
+
+	// CriticalPath Nodes from the root to the node completing last, separated by semicolons
+	CriticalPath string `csv:"criticalPath"`
+
+	// Measurements in microseconds
+	ResponseTime          int64  `csv:"responseTime"`
+	CriticalPathDurations string `csv:"criticalPathDurations"`
+	OrchestrationOverhead int64  `csv:"orchestrationOverhead"`
+
+	// NodesSkipped Nodes not invoked, as no edge to them was taken or a join did not reach its quorum
+	NodesSkipped int `csv:"nodesSkipped"`
+	// Skipped Names of the skipped nodes, sorted and separated by semicolons
+	Skipped string `csv:"skipped"`
+}
+
+// DeploymentRecord is the outcome of the readiness probes of a deployed function
+type DeploymentRecord struct {
+	Function string `csv:"function"`
+	Endpoint string `csv:"endpoint"`
+	Ready    bool   `csv:"ready"`
+	// DeployLatency Time from the start of the deployment until the function became ready or the probes gave up, in ms
+	DeployLatency int64  `csv:"deployLatency"`
+	Probes        int    `csv:"probes"`
+	Error         string `csv:"error"`
+}
+
 type ExecutionRecordOpenWhisk struct {
 	ExecutionRecordBase
 
@@ -77,10 +116,25 @@ type ExecutionRecord struct {
 	MemoryAllocationTimeout bool   `csv:"memoryAllocationTimeout"`
 
 	AsyncResponseID     string `csv:"-"`
+	ResponseBody        string `csv:"-"`
 	TimeToSubmitMs      int64  `csv:"timeToSubmitMs"`
 	UserCodeExecutionMs int64  `csv:"userCodeExecutionMs"`
 
 	TimeToGetResponseMs int64 `csv:"timeToGetResponseMs"`
+
+	// Columns added since are appended, so that readers of the earlier columns by position keep working
+
+	// TraceMinute Minute of the trace the invocation replays
+	TraceMinute int `csv:"traceMinute"`
+	// Function Trace function invoked, which differs from the instance if several functions share a service
+	Function string `csv:"function"`
+	// JoinWait Time a DAG node with several parents waited for its quorum since the first parent completed, in µs
+	JoinWait int64 `csv:"joinWait"`
+	// SerializationTime Time to serialize the payload sent along the incoming DAG edge, in µs
+	SerializationTime int64 `csv:"serializationTime"`
+	// Parent DAG node whose completion triggered the invocation, sending a payload of PayloadBytes along its edge
+	Parent       string `csv:"parent"`
+	PayloadBytes int    `csv:"payloadBytes"`
 }
 
 type DeploymentScale struct {
@@ -0,0 +1,418 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package synthesizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/trace"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Specification is a declarative description of a synthetic workload
+type Specification struct {
+	Seed      int64 `json:"Seed"`
+	Functions int   `json:"Functions"`
+	// Duration In minutes.
+	Duration int `json:"Duration"`
+
+	Popularity Popularity `json:"Popularity"`
+	// Patterns Mix of per-minute invocation patterns. Shares are normalized, and functions are assigned to patterns
+	// in proportion to them. Without any pattern, all functions are flat.
+	Patterns []Pattern `json:"Patterns"`
+
+	// Runtime Distribution of the median runtime of functions in milliseconds
+	Runtime Distribution `json:"Runtime"`
+	// Memory Distribution of the median memory of functions in MiB
+	Memory Distribution `json:"Memory"`
+
+	// Triggers Share of functions per trigger type, e.g., {"http": 0.5, "timer": 0.3, "queue": 0.2}
+	Triggers map[string]float64 `json:"Triggers"`
+}
+
+type Popularity struct {
+	// Law Either 'zipf', where the i-th most popular function gets a share proportional to 1/i^Exponent, or 'equal'
+	Law      string  `json:"Law"`
+	Exponent float64 `json:"Exponent"`
+	// TotalRPS Average number of invocations per second of all the functions together
+	TotalRPS float64 `json:"TotalRPS"`
+}
+
+type Pattern struct {
+	// Type One of 'flat', 'diurnal' or 'bursty'
+	Type  string  `json:"Type"`
+	Share float64 `json:"Share"`
+
+	// Amplitude Relative amplitude of the diurnal sine wave, in [0, 1]
+	Amplitude float64 `json:"Amplitude"`
+	// PeriodMinutes Period of the diurnal pattern, 1440 by default
+	PeriodMinutes int `json:"PeriodMinutes"`
+	// PeakMinute Minute at which the diurnal pattern peaks
+	PeakMinute int `json:"PeakMinute"`
+
+	// BurstProbability Probability that a minute of a bursty function is a burst
+	BurstProbability float64 `json:"BurstProbability"`
+	// BurstMultiplier Load multiplier of a burst minute. The load of other minutes is lowered to keep the average.
+	BurstMultiplier float64 `json:"BurstMultiplier"`
+}
+
+type Distribution struct {
+	// Type One of 'constant', 'uniform', 'exponential' or 'lognormal'
+	Type string `json:"Type"`
+
+	Value float64 `json:"Value"` // constant
+	Min   float64 `json:"Min"`   // uniform
+	Max   float64 `json:"Max"`   // uniform
+	Mean  float64 `json:"Mean"`  // exponential
+	Mu    float64 `json:"Mu"`    // lognormal
+	Sigma float64 `json:"Sigma"` // lognormal
+
+	// Spread Standard deviation of the log-normal distribution of individual invocations around the function median
+	Spread float64 `json:"Spread"`
+}
+
+func ReadSpecification(path string) (*Specification, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec Specification
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, spec.validate()
+}
+
+func (s *Specification) validate() error {
+	if s.Functions <= 0 || s.Duration <= 0 {
+		return fmt.Errorf("number of functions and duration must be positive")
+	}
+	if s.Popularity.TotalRPS < 0 {
+		return fmt.Errorf("total RPS must not be negative")
+	}
+
+	switch s.Popularity.Law {
+	case "", "equal", "zipf":
+	default:
+		return fmt.Errorf("unsupported popularity law '%s'", s.Popularity.Law)
+	}
+
+	for _, pattern := range s.Patterns {
+		switch pattern.Type {
+		case "flat", "diurnal", "bursty":
+		default:
+			return fmt.Errorf("unsupported invocation pattern '%s'", pattern.Type)
+		}
+
+		if pattern.Share < 0 || pattern.Amplitude < 0 || pattern.Amplitude > 1 ||
+			pattern.BurstProbability < 0 || pattern.BurstProbability > 1 {
+			return fmt.Errorf("invalid parameters of the '%s' pattern", pattern.Type)
+		}
+	}
+
+	for _, distribution := range []Distribution{s.Runtime, s.Memory} {
+		switch distribution.Type {
+		case "constant", "uniform", "exponential", "lognormal":
+		default:
+			return fmt.Errorf("unsupported distribution '%s'", distribution.Type)
+		}
+	}
+
+	for trigger, share := range s.Triggers {
+		if share < 0 {
+			return fmt.Errorf("share of trigger '%s' must not be negative", trigger)
+		}
+	}
+
+	return nil
+}
+
+// Synthesize generates the functions of the synthetic workload, deterministically for the given seed
+func Synthesize(spec *Specification) []*common.Function {
+	gen := rand.New(rand.NewSource(uint64(spec.Seed)))
+
+	popularity := popularityShares(spec.Popularity, spec.Functions)
+	patterns := assignPatterns(spec.Patterns, spec.Functions, gen)
+	triggers := assignTriggers(spec.Triggers, spec.Functions, gen)
+
+	functions := make([]*common.Function, 0, spec.Functions)
+	for i := 0; i < spec.Functions; i++ {
+		hashOwner, hashApp, hashFunction := randomHash(gen), randomHash(gen), randomHash(gen)
+		invocations := generateInvocations(spec.Popularity.TotalRPS*popularity[i]*60, patterns[i], spec.Duration, gen)
+
+		count := 0
+		for _, c := range invocations {
+			count += c
+		}
+		// statistics must be valid even for functions that are never invoked
+		count = common.MaxOf(count, 1)
+
+		runtime := generateRuntimeStats(spec.Runtime, count, gen)
+		runtime.HashOwner, runtime.HashApp, runtime.HashFunction = hashOwner, hashApp, hashFunction
+
+		memory := generateMemoryStats(spec.Memory, count, gen)
+		memory.HashOwner, memory.HashApp, memory.HashFunction = hashOwner, hashApp, hashFunction
+
+		functions = append(functions, &common.Function{
+			Name: fmt.Sprintf("%s-%d", common.FunctionNamePrefix, i),
+			InvocationStats: &common.FunctionInvocationStats{
+				HashOwner:    hashOwner,
+				HashApp:      hashApp,
+				HashFunction: hashFunction,
+				Trigger:      triggers[i],
+				Invocations:  invocations,
+			},
+			RuntimeStats: runtime,
+			MemoryStats:  memory,
+		})
+	}
+
+	return functions
+}
+
+// SynthesizeTrace generates the workload and writes it as an Azure trace directory
+func SynthesizeTrace(spec *Specification, outputPath string) error {
+	functions := Synthesize(spec)
+	log.Infof("Synthesized %d functions over %d minutes.", len(functions), spec.Duration)
+
+	return trace.WriteAzureTrace(outputPath, functions)
+}
+
+func popularityShares(popularity Popularity, n int) []float64 {
+	shares := make([]float64, n)
+	total := 0.0
+
+	for i := 0; i < n; i++ {
+		if popularity.Law == "zipf" {
+			shares[i] = 1 / math.Pow(float64(i+1), popularity.Exponent)
+		} else {
+			shares[i] = 1
+		}
+
+		total += shares[i]
+	}
+
+	for i := range shares {
+		shares[i] /= total
+	}
+
+	return shares
+}
+
+// assignPatterns gives each function a pattern, with the number of functions per pattern proportional to its share
+func assignPatterns(patterns []Pattern, n int, gen *rand.Rand) []Pattern {
+	if len(patterns) == 0 {
+		patterns = []Pattern{{Type: "flat", Share: 1}}
+	}
+
+	shares := make([]float64, len(patterns))
+	for i, pattern := range patterns {
+		shares[i] = pattern.Share
+	}
+
+	result := make([]Pattern, 0, n)
+	for _, index := range apportion(shares, n, gen) {
+		result = append(result, patterns[index])
+	}
+
+	return result
+}
+
+func assignTriggers(triggers map[string]float64, n int, gen *rand.Rand) []string {
+	if len(triggers) == 0 {
+		triggers = map[string]float64{"http": 1}
+	}
+
+	// map iteration order is random, so the triggers are sorted for reproducibility
+	names := make([]string, 0, len(triggers))
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	shares := make([]float64, len(names))
+	for i, name := range names {
+		shares[i] = triggers[name]
+	}
+
+	result := make([]string, 0, n)
+	for _, index := range apportion(shares, n, gen) {
+		result = append(result, names[index])
+	}
+
+	return result
+}
+
+// apportion splits n items into categories proportionally to the shares (largest remainder method) and returns the
+// category of each item in random order, so that categories are not correlated with popularity
+func apportion(shares []float64, n int, gen *rand.Rand) []int {
+	total := 0.0
+	for _, share := range shares {
+		total += share
+	}
+	if total == 0 {
+		shares, total = []float64{1}, 1
+	}
+
+	counts := make([]int, len(shares))
+	remainders := make([]float64, len(shares))
+	assigned := 0
+	for i, share := range shares {
+		exact := share / total * float64(n)
+		counts[i] = int(math.Floor(exact))
+		remainders[i] = exact - float64(counts[i])
+		assigned += counts[i]
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]] > remainders[order[j]] })
+	for i := 0; assigned < n; i++ {
+		counts[order[i%len(order)]]++
+		assigned++
+	}
+
+	result := make([]int, 0, n)
+	for category, count := range counts {
+		for j := 0; j < count; j++ {
+			result = append(result, category)
+		}
+	}
+	gen.Shuffle(len(result), func(i, j int) { result[i], result[j] = result[j], result[i] })
+
+	return result
+}
+
+func generateInvocations(meanIPM float64, pattern Pattern, duration int, gen *rand.Rand) []int {
+	invocations := make([]int, duration)
+
+	burstMultiplier := math.Max(pattern.BurstMultiplier, 1)
+	// keeps the expected load of a bursty function equal to the mean
+	baseMultiplier := 1 / (1 + pattern.BurstProbability*(burstMultiplier-1))
+
+	period := pattern.PeriodMinutes
+	if period <= 0 {
+		period = 1440
+	}
+
+	for minute := 0; minute < duration; minute++ {
+		multiplier := 1.0
+
+		switch pattern.Type {
+		case "diurnal":
+			phase := 2 * math.Pi * float64(minute-pattern.PeakMinute) / float64(period)
+			multiplier = 1 + pattern.Amplitude*math.Cos(phase)
+		case "bursty":
+			multiplier = baseMultiplier
+			if gen.Float64() < pattern.BurstProbability {
+				multiplier *= burstMultiplier
+			}
+		}
+
+		if lambda := meanIPM * multiplier; lambda > 0 {
+			invocations[minute] = int(distuv.Poisson{Lambda: lambda, Src: gen}.Rand())
+		}
+	}
+
+	return invocations
+}
+
+// sampleMedian draws the median of a function from the distribution
+func sampleMedian(distribution Distribution, gen *rand.Rand) float64 {
+	switch distribution.Type {
+	case "uniform":
+		return distribution.Min + gen.Float64()*(distribution.Max-distribution.Min)
+	case "exponential":
+		return gen.ExpFloat64() * distribution.Mean
+	case "lognormal":
+		return distuv.LogNormal{Mu: distribution.Mu, Sigma: distribution.Sigma, Src: gen}.Rand()
+	default:
+		return distribution.Value
+	}
+}
+
+// percentiles of a log-normal distribution of invocations around the function median
+func invocationPercentiles(median float64, spread float64, probabilities []float64) []float64 {
+	result := make([]float64, len(probabilities))
+	for i, p := range probabilities {
+		// the extreme percentiles are taken at 0.1% and 99.9% as the log-normal distribution is unbounded
+		p = math.Min(math.Max(p, 0.001), 0.999)
+		result[i] = median * math.Exp(spread*distuv.UnitNormal.Quantile(p))
+	}
+
+	return result
+}
+
+func generateRuntimeStats(distribution Distribution, count int, gen *rand.Rand) *common.FunctionRuntimeStats {
+	median := math.Max(sampleMedian(distribution, gen), 0)
+	p := invocationPercentiles(median, distribution.Spread, []float64{0, 0.01, 0.25, 0.50, 0.75, 0.99, 1})
+
+	return &common.FunctionRuntimeStats{
+		Average:       median * math.Exp(distribution.Spread*distribution.Spread/2),
+		Count:         float64(count),
+		Minimum:       p[0],
+		Maximum:       p[6],
+		Percentile0:   p[0],
+		Percentile1:   p[1],
+		Percentile25:  p[2],
+		Percentile50:  p[3],
+		Percentile75:  p[4],
+		Percentile99:  p[5],
+		Percentile100: p[6],
+	}
+}
+
+func generateMemoryStats(distribution Distribution, count int, gen *rand.Rand) *common.FunctionMemoryStats {
+	median := math.Max(sampleMedian(distribution, gen), 0)
+	p := invocationPercentiles(median, distribution.Spread, []float64{0.01, 0.05, 0.25, 0.50, 0.75, 0.95, 0.99, 1})
+
+	return &common.FunctionMemoryStats{
+		Count:         float64(count),
+		Average:       median * math.Exp(distribution.Spread*distribution.Spread/2),
+		Percentile1:   p[0],
+		Percentile5:   p[1],
+		Percentile25:  p[2],
+		Percentile50:  p[3],
+		Percentile75:  p[4],
+		Percentile95:  p[5],
+		Percentile99:  p[6],
+		Percentile100: p[7],
+	}
+}
+
+func randomHash(gen *rand.Rand) string {
+	return fmt.Sprintf("%016x%016x%016x%016x", gen.Uint64(), gen.Uint64(), gen.Uint64(), gen.Uint64())
+}
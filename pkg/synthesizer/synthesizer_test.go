@@ -0,0 +1,131 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package synthesizer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/trace"
+)
+
+func createTestSpecification() *Specification {
+	return &Specification{
+		Seed:      42,
+		Functions: 20,
+		Duration:  30,
+		Popularity: Popularity{
+			Law:      "zipf",
+			Exponent: 1.5,
+			TotalRPS: 20,
+		},
+		Patterns: []Pattern{
+			{Type: "flat", Share: 0.5},
+			{Type: "diurnal", Share: 0.25, Amplitude: 0.5, PeriodMinutes: 10},
+			{Type: "bursty", Share: 0.25, BurstProbability: 0.1, BurstMultiplier: 5},
+		},
+		Runtime:  Distribution{Type: "lognormal", Mu: 5, Sigma: 1, Spread: 0.5},
+		Memory:   Distribution{Type: "uniform", Min: 128, Max: 512, Spread: 0.1},
+		Triggers: map[string]float64{"http": 0.5, "timer": 0.5},
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	spec := createTestSpecification()
+	if err := spec.validate(); err != nil {
+		t.Fatalf("Valid specification rejected - %v", err)
+	}
+
+	functions := Synthesize(spec)
+	if len(functions) != spec.Functions {
+		t.Fatalf("Expected %d functions, got %d.", spec.Functions, len(functions))
+	}
+
+	if !reflect.DeepEqual(functions, Synthesize(spec)) {
+		t.Error("Synthesis is not deterministic for the same seed.")
+	}
+
+	total := func(invocations []int) int {
+		sum := 0
+		for _, c := range invocations {
+			sum += c
+		}
+		return sum
+	}
+
+	if total(functions[0].InvocationStats.Invocations) <= total(functions[len(functions)-1].InvocationStats.Invocations) {
+		t.Error("The most popular function should have more invocations than the least popular one.")
+	}
+
+	triggers := map[string]int{}
+	for _, function := range functions {
+		triggers[function.InvocationStats.Trigger]++
+
+		if len(function.InvocationStats.Invocations) != spec.Duration {
+			t.Errorf("Function %s has %d minutes of invocations.", function.Name, len(function.InvocationStats.Invocations))
+		}
+
+		r := function.RuntimeStats
+		if !(r.Percentile0 <= r.Percentile1 && r.Percentile1 <= r.Percentile50 && r.Percentile50 <= r.Percentile99 &&
+			r.Percentile99 <= r.Percentile100) {
+			t.Errorf("Runtime percentiles of function %s are not monotonic.", function.Name)
+		}
+
+		m := function.MemoryStats
+		if m.Percentile50 < spec.Memory.Min || m.Percentile50 > spec.Memory.Max {
+			t.Errorf("Median memory %f of function %s is out of bounds.", m.Percentile50, function.Name)
+		}
+	}
+
+	if triggers["http"] != 10 || triggers["timer"] != 10 {
+		t.Errorf("Unexpected trigger mix %v.", triggers)
+	}
+}
+
+func TestSynthesizeTrace(t *testing.T) {
+	spec := createTestSpecification()
+	outputPath := t.TempDir()
+
+	if err := SynthesizeTrace(spec, outputPath); err != nil {
+		t.Fatalf("Failed to write the synthetic trace - %v", err)
+	}
+
+	parser := trace.NewAzureParser(outputPath, spec.Duration)
+	functions, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(functions) != spec.Functions {
+		t.Fatalf("Expected %d functions after parsing, got %d.", spec.Functions, len(functions))
+	}
+
+	expected := Synthesize(spec)
+	for i, function := range functions {
+		if !reflect.DeepEqual(function.InvocationStats.Invocations, expected[i].InvocationStats.Invocations) {
+			t.Errorf("Invocations of function %d changed when written to disk.", i)
+		}
+	}
+}
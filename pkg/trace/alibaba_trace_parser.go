@@ -0,0 +1,239 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// alibabaIncomingCalls lists the call types of the Alibaba microservice trace that invoke the microservice, as opposed
+// to the calls it makes to other services and databases
+var alibabaIncomingCalls = []string{"providerRPC", "HTTP"}
+
+// AlibabaTraceParser reads the microservice runtime metrics of the Alibaba cluster trace (MSRTMCR*.csv files). Every
+// row contains the call rate in calls per minute (<type>_MCR) and the average response time in milliseconds
+// (<type>_RT) of a microservice instance at a timestamp in milliseconds from the beginning of the trace. Microservices
+// are replayed as functions invoked by the incoming calls of all their instances. The trace contains no absolute
+// memory usage.
+type AlibabaTraceParser struct {
+	DirectoryPath string
+
+	duration              int
+	startMinute           int
+	functionNameGenerator *rand.Rand
+}
+
+func NewAlibabaParser(directoryPath string, totalDuration int) *AlibabaTraceParser {
+	return &AlibabaTraceParser{
+		DirectoryPath: directoryPath,
+
+		duration:              totalDuration,
+		functionNameGenerator: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// WithStartMinute makes the parser read the trace window starting at the given minute of the trace
+func (p *AlibabaTraceParser) WithStartMinute(startMinute int) *AlibabaTraceParser {
+	p.startMinute = startMinute
+
+	return p
+}
+
+type alibabaService struct {
+	name        string
+	invocations []float64
+	runtimes    []float64
+	weights     []float64
+}
+
+func (p *AlibabaTraceParser) Parse() ([]*common.Function, error) {
+	files, err := filepath.Glob(filepath.Join(p.DirectoryPath, "MSRTMCR*.csv"))
+	if err != nil {
+		return nil, err
+	} else if len(files) == 0 {
+		return nil, fmt.Errorf("trace directory %s contains no MSRTMCR*.csv files", p.DirectoryPath)
+	}
+	sort.Strings(files)
+
+	log.Infof("Parsing Alibaba trace %s (start: minute %d, duration: %d min)", p.DirectoryPath, p.startMinute, p.duration)
+
+	var order []*alibabaService
+	services := make(map[string]*alibabaService)
+	lastMinute := -1
+
+	for _, path := range files {
+		last, err := p.readRuntimeMetrics(path, services, &order)
+		if err != nil {
+			return nil, err
+		}
+		lastMinute = common.MaxOf(lastMinute, last)
+	}
+
+	if lastMinute+1 < p.startMinute+p.duration {
+		return nil, fmt.Errorf("trace %s contains %d minutes, which is shorter than the trace window [%d, %d)",
+			p.DirectoryPath, lastMinute+1, p.startMinute, p.startMinute+p.duration)
+	}
+
+	var result []*common.Function
+	for _, service := range order {
+		if len(service.runtimes) == 0 {
+			log.Debugf("Microservice %s is never invoked in the trace.", service.name)
+			continue
+		}
+
+		invocations := make([]int, p.duration)
+		for i := range invocations {
+			invocations[i] = int(math.Round(service.invocations[i]))
+		}
+
+		runtimeStats := sampleRuntimeStats(service.name, service.name, service.runtimes, service.weights)
+
+		result = append(result, newTraceFunction(len(result), p.functionNameGenerator,
+			&common.FunctionInvocationStats{
+				HashOwner:    service.name,
+				HashApp:      service.name,
+				HashFunction: service.name,
+				Trigger:      "unknown",
+				Invocations:  invocations,
+			},
+			runtimeStats,
+			constantMemoryStats(service.name, service.name, int(runtimeStats.Count), common.DefaultTraceMemoryMiB),
+		))
+	}
+
+	return result, nil
+}
+
+// readRuntimeMetrics adds the calls of a file to the microservices and returns the last minute of the file
+func (p *AlibabaTraceParser) readRuntimeMetrics(path string, services map[string]*alibabaService, order *[]*alibabaService) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, &ParseError{File: path, Line: 1, Err: err}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"timestamp", "msname"} {
+		if _, ok := columns[name]; !ok {
+			return 0, &ParseError{File: path, Line: 1, Err: fmt.Errorf("missing column '%s'", name)}
+		}
+	}
+
+	var calls []string
+	for _, call := range alibabaIncomingCalls {
+		_, hasRate := columns[call+"_MCR"]
+		_, hasRuntime := columns[call+"_RT"]
+		if hasRate && hasRuntime {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return 0, &ParseError{File: path, Line: 1, Err: fmt.Errorf("missing the call rate and response time columns of %s",
+			strings.Join(alibabaIncomingCalls, " and "))}
+	}
+
+	lastMinute := -1
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, &ParseError{File: path, Line: line, Err: err}
+		}
+
+		timestamp, err := parseNonNegativeFloat(record[columns["timestamp"]])
+		if err != nil {
+			return 0, &ParseError{File: path, Line: line, Column: "timestamp", Err: err}
+		}
+		minute := int(timestamp / 60_000)
+		lastMinute = common.MaxOf(lastMinute, minute)
+
+		name := strings.TrimSpace(record[columns["msname"]])
+		if name == "" {
+			return 0, &ParseError{File: path, Line: line, Column: "msname", Err: fmt.Errorf("empty microservice name")}
+		}
+
+		service, ok := services[name]
+		if !ok {
+			service = &alibabaService{name: name, invocations: make([]float64, p.duration)}
+			services[name] = service
+			*order = append(*order, service)
+		}
+
+		for _, call := range calls {
+			rate, runtime := strings.TrimSpace(record[columns[call+"_MCR"]]), strings.TrimSpace(record[columns[call+"_RT"]])
+			if rate == "" {
+				continue
+			}
+
+			callRate, err := parseNonNegativeFloat(rate)
+			if err != nil {
+				return 0, &ParseError{File: path, Line: line, Column: call + "_MCR", Err: err}
+			} else if callRate == 0 {
+				continue
+			}
+
+			if minute >= p.startMinute && minute < p.startMinute+p.duration {
+				service.invocations[minute-p.startMinute] += callRate
+			}
+
+			if runtime == "" {
+				continue
+			}
+			responseTime, err := parseNonNegativeFloat(runtime)
+			if err != nil {
+				return 0, &ParseError{File: path, Line: line, Column: call + "_RT", Err: err}
+			}
+
+			service.runtimes = append(service.runtimes, responseTime)
+			service.weights = append(service.weights, callRate)
+		}
+	}
+
+	return lastMinute, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestAlibabaTraceParser(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"MSRTMCR_0.csv": {
+			"timestamp,msname,msinstanceid,nodeid,providerRPC_MCR,providerRPC_RT,consumerRPC_MCR,consumerRPC_RT,HTTP_MCR,HTTP_RT",
+			"0,s1,i1,n1,10,5,100,1,,",
+			"0,s1,i2,n2,20,10,,,,",
+			"60000,s1,i1,n1,,,,,6,50",
+		},
+		"MSRTMCR_1.csv": {
+			"timestamp,msname,msinstanceid,nodeid,providerRPC_MCR,providerRPC_RT,consumerRPC_MCR,consumerRPC_RT,HTTP_MCR,HTTP_RT",
+			"120000,s2,i3,n1,4,8,,,,",
+			"120000,s3,i4,n1,,,50,2,,",
+		},
+	})
+
+	parser, err := NewTraceParser(ParserConfiguration{Format: common.AlibabaTraceFormat, Path: directory, Duration: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// s3 only calls other services
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions, got %d.", len(functions))
+	}
+
+	s1, s2 := functions[0], functions[1]
+	if !reflect.DeepEqual(s1.InvocationStats.Invocations, []int{30, 6, 0}) {
+		t.Errorf("Unexpected invocations of s1: %v.", s1.InvocationStats.Invocations)
+	}
+	if !reflect.DeepEqual(s2.InvocationStats.Invocations, []int{0, 0, 4}) {
+		t.Errorf("Unexpected invocations of s2: %v.", s2.InvocationStats.Invocations)
+	}
+	if s1.RuntimeStats.Count != 36 || s1.RuntimeStats.Minimum != 5 || s1.RuntimeStats.Maximum != 50 {
+		t.Errorf("Unexpected runtime statistics %+v.", *s1.RuntimeStats)
+	}
+	if s1.MemoryStats.Percentile50 != common.DefaultTraceMemoryMiB {
+		t.Errorf("Expected the default memory, got %f.", s1.MemoryStats.Percentile50)
+	}
+
+	if _, err = NewAlibabaParser(directory, 4).Parse(); err == nil {
+		t.Error("Expected an error for a trace window longer than the trace.")
+	}
+}
+
+func TestAlibabaTraceParserErrors(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"MSRTMCR_0.csv": {
+			"timestamp,msname,providerRPC_MCR,providerRPC_RT",
+			"0,s1,10,5",
+			"0,s1,10,-5",
+		},
+	})
+
+	_, err := NewAlibabaParser(directory, 1).Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Line != 3 || parseError.Column != "providerRPC_RT" {
+		t.Errorf("Expected a parse error in line 3 and column providerRPC_RT, got %v.", err)
+	}
+}
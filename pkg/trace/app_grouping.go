@@ -0,0 +1,208 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/generator"
+)
+
+// ParseAppMemoryTrace reads application memory statistics in the format of the Azure 2019 trace, i.e., the memory
+// statistics of the shared instances of an application, keyed by HashApp.
+func ParseAppMemoryTrace(path string, skipInvalidRows bool) (map[string]*common.FunctionMemoryStats, error) {
+	rows, err := parseStatsTrace[common.FunctionMemoryStats](path, "HashApp", skipInvalidRows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*common.FunctionMemoryStats)
+	for i := 0; i < len(rows); i++ {
+		if _, ok := result[rows[i].HashApp]; !ok {
+			result[rows[i].HashApp] = &rows[i]
+		}
+	}
+
+	return result, nil
+}
+
+// GroupByApp creates one service per application of the trace, which all the functions of the application are
+// deployed into and routed to. The ServiceName of every grouped function is set to the name of its service. The
+// memory of a service is taken from appMemory if the application has statistics there, and is otherwise the sum of
+// the memory of its functions. Functions without an application are not grouped.
+func GroupByApp(functions []*common.Function, appMemory map[string]*common.FunctionMemoryStats) []*common.Function {
+	var services []*common.Function
+	serviceOf := make(map[string]*common.Function)
+	members := make(map[*common.Function][]*common.Function)
+
+	for _, function := range functions {
+		if function.InvocationStats == nil || function.InvocationStats.HashApp == "" {
+			continue
+		}
+
+		app := function.InvocationStats.HashApp
+		service, ok := serviceOf[app]
+		if !ok {
+			service = &common.Function{
+				Name: fmt.Sprintf("%s-%d-app", common.FunctionNamePrefix, len(services)),
+				InvocationStats: &common.FunctionInvocationStats{
+					HashOwner:    function.InvocationStats.HashOwner,
+					HashApp:      app,
+					HashFunction: app,
+					Trigger:      function.InvocationStats.Trigger,
+				},
+				DirigentMetadata: function.DirigentMetadata,
+			}
+
+			serviceOf[app] = service
+			services = append(services, service)
+		}
+
+		function.ServiceName = service.Name
+		members[service] = append(members[service], function)
+	}
+
+	for _, service := range services {
+		group := members[service]
+
+		service.InvocationStats.Invocations = sumInvocations(group)
+		service.RuntimeStats = mergeRuntimeStats(group)
+		if memory, ok := appMemory[service.InvocationStats.HashApp]; ok {
+			memory := *memory
+			memory.HashFunction = service.InvocationStats.HashApp
+			service.MemoryStats = &memory
+		} else {
+			service.MemoryStats = sumMemoryStats(group)
+		}
+
+		for _, function := range group {
+			service.InitialScale += function.InitialScale
+		}
+		service.ColdStartBusyLoopMs = generator.ComputeBusyLoopPeriod(int(service.MemoryStats.Percentile50))
+
+		log.Debugf("Functions of application %s will be deployed as service %s.", service.InvocationStats.HashApp, service.Name)
+	}
+
+	return services
+}
+
+func sumInvocations(functions []*common.Function) []int {
+	var result []int
+	for _, function := range functions {
+		for minute, count := range function.InvocationStats.Invocations {
+			if minute >= len(result) {
+				result = append(result, make([]int, minute-len(result)+1)...)
+			}
+			result[minute] += count
+		}
+	}
+
+	return result
+}
+
+// mergeRuntimeStats weighs the runtime statistics of the functions by their number of invocations, as the instances
+// of a service serve the invocations of all of its functions
+func mergeRuntimeStats(functions []*common.Function) *common.FunctionRuntimeStats {
+	result := &common.FunctionRuntimeStats{
+		HashOwner:    functions[0].RuntimeStats.HashOwner,
+		HashApp:      functions[0].RuntimeStats.HashApp,
+		HashFunction: functions[0].RuntimeStats.HashApp,
+		Minimum:      math.Inf(1),
+	}
+
+	weights := make([]float64, len(functions))
+	var totalWeight float64
+	for i, function := range functions {
+		for _, count := range function.InvocationStats.Invocations {
+			weights[i] += float64(count)
+		}
+		totalWeight += weights[i]
+	}
+	if totalWeight == 0 {
+		// none of the functions is invoked in the window
+		for i := range weights {
+			weights[i] = 1
+		}
+		totalWeight = float64(len(weights))
+	}
+
+	for i, function := range functions {
+		stats := function.RuntimeStats
+		weight := weights[i]
+
+		result.Count += stats.Count
+		result.Minimum = math.Min(result.Minimum, stats.Minimum)
+		result.Maximum = math.Max(result.Maximum, stats.Maximum)
+
+		result.Average += weight * stats.Average
+		result.Percentile0 += weight * stats.Percentile0
+		result.Percentile1 += weight * stats.Percentile1
+		result.Percentile25 += weight * stats.Percentile25
+		result.Percentile50 += weight * stats.Percentile50
+		result.Percentile75 += weight * stats.Percentile75
+		result.Percentile99 += weight * stats.Percentile99
+		result.Percentile100 += weight * stats.Percentile100
+	}
+
+	result.Average /= totalWeight
+	result.Percentile0 /= totalWeight
+	result.Percentile1 /= totalWeight
+	result.Percentile25 /= totalWeight
+	result.Percentile50 /= totalWeight
+	result.Percentile75 /= totalWeight
+	result.Percentile99 /= totalWeight
+	result.Percentile100 /= totalWeight
+
+	return result
+}
+
+// sumMemoryStats approximates the memory of an application instance as the sum of the memory of its functions
+func sumMemoryStats(functions []*common.Function) *common.FunctionMemoryStats {
+	result := &common.FunctionMemoryStats{
+		HashOwner:    functions[0].MemoryStats.HashOwner,
+		HashApp:      functions[0].MemoryStats.HashApp,
+		HashFunction: functions[0].MemoryStats.HashApp,
+	}
+
+	for _, function := range functions {
+		stats := function.MemoryStats
+
+		result.Count += stats.Count
+		result.Average += stats.Average
+		result.Percentile1 += stats.Percentile1
+		result.Percentile5 += stats.Percentile5
+		result.Percentile25 += stats.Percentile25
+		result.Percentile50 += stats.Percentile50
+		result.Percentile75 += stats.Percentile75
+		result.Percentile95 += stats.Percentile95
+		result.Percentile99 += stats.Percentile99
+		result.Percentile100 += stats.Percentile100
+	}
+
+	return result
+}
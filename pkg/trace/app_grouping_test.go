@@ -0,0 +1,109 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestGroupByApp(t *testing.T) {
+	newFunction := func(name string, app string, invocations []int, runtime float64, memory float64) *common.Function {
+		return &common.Function{
+			Name:         name,
+			InitialScale: 1,
+			InvocationStats: &common.FunctionInvocationStats{
+				HashApp:      app,
+				HashFunction: name,
+				Invocations:  invocations,
+			},
+			RuntimeStats: &common.FunctionRuntimeStats{HashApp: app, Average: runtime, Minimum: runtime, Maximum: runtime, Percentile50: runtime},
+			MemoryStats:  &common.FunctionMemoryStats{HashApp: app, Average: memory, Percentile50: memory, Percentile100: memory},
+		}
+	}
+
+	functions := []*common.Function{
+		newFunction("f1", "a1", []int{3, 0}, 100, 100),
+		newFunction("f2", "a2", []int{1, 1}, 50, 64),
+		newFunction("f3", "a1", []int{0, 1}, 500, 200),
+		newFunction("f4", "", []int{1, 1}, 10, 128),
+	}
+	appMemory := map[string]*common.FunctionMemoryStats{
+		"a2": {HashApp: "a2", Average: 256, Percentile50: 256, Percentile100: 512},
+	}
+
+	services := GroupByApp(functions, appMemory)
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 services, got %d.", len(services))
+	}
+
+	a1, a2 := services[0], services[1]
+	if functions[0].ServiceName != a1.Name || functions[2].ServiceName != a1.Name || functions[1].ServiceName != a2.Name {
+		t.Errorf("Functions routed to the wrong services: %s, %s, %s.", functions[0].ServiceName, functions[1].ServiceName, functions[2].ServiceName)
+	}
+	if functions[3].ServiceName != "" {
+		t.Errorf("Function without an application should not be grouped, got service %s.", functions[3].ServiceName)
+	}
+
+	if !reflect.DeepEqual(a1.InvocationStats.Invocations, []int{3, 1}) || a1.InitialScale != 2 {
+		t.Errorf("Wrong invocations %v or initial scale %d of the service.", a1.InvocationStats.Invocations, a1.InitialScale)
+	}
+	// weighted by 3 and 1 invocations
+	if a1.RuntimeStats.Average != 200 || a1.RuntimeStats.Minimum != 100 || a1.RuntimeStats.Maximum != 500 {
+		t.Errorf("Wrong runtime statistics of the service: %+v.", a1.RuntimeStats)
+	}
+	if a1.MemoryStats.Percentile100 != 300 {
+		t.Errorf("Expected the memory of the functions to be summed up, got %f.", a1.MemoryStats.Percentile100)
+	}
+	if a2.MemoryStats.Percentile100 != 512 || a2.MemoryStats.HashFunction != "a2" {
+		t.Errorf("Expected the application memory statistics, got %+v.", a2.MemoryStats)
+	}
+	if appMemory["a2"].HashFunction != "" {
+		t.Error("Application memory statistics should not be modified.")
+	}
+}
+
+func TestParseAppMemoryTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app_memory.csv")
+	content := "HashOwner,HashApp,SampleCount,AverageAllocatedMb,AverageAllocatedMb_pct1,AverageAllocatedMb_pct100\n" +
+		"o1,a1,10,120,100,150\n" +
+		"o1,a2,5,60,50,70\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := ParseAppMemoryTrace(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 || stats["a1"].Average != 120 || stats["a2"].Percentile100 != 70 {
+		t.Errorf("Wrong application memory statistics: %+v.", stats)
+	}
+}
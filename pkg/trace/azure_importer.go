@@ -0,0 +1,420 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	Azure2019Format = "azure2019"
+	Azure2021Format = "azure2021"
+)
+
+// ImportConfiguration describes the raw public Azure Functions dataset to convert into a loader trace directory
+type ImportConfiguration struct {
+	// Format Either 'azure2019' (directory with the per-day files) or 'azure2021' (per-invocation trace file)
+	Format    string
+	InputPath string
+	// Days Days of the 2019 dataset to import, numbered from 1. All the days found are imported if empty.
+	Days []int
+	// MemoryMiB Memory of the functions of the 2021 dataset, which contains no memory information
+	MemoryMiB float64
+}
+
+var raw2019DayPattern = regexp.MustCompile(`^invocations_per_function_md\.anon\.d(\d+)\.csv$`)
+
+// ImportAzureTrace converts the raw dataset into a trace directory readable by AzureTraceParser. A single day of the
+// 2019 dataset is written as invocations.csv, durations.csv and memory.csv, several days as per-day files numbered
+// from 1 in the order of import. The 2021 dataset is written as a single trace spanning all of its minutes.
+func ImportAzureTrace(cfg ImportConfiguration, outputPath string) error {
+	switch cfg.Format {
+	case Azure2019Format:
+		return importAzure2019(cfg, outputPath)
+	case Azure2021Format:
+		functions, err := readAzure2021(cfg.InputPath, cfg.MemoryMiB)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Imported %d functions from %s.", len(functions), cfg.InputPath)
+		return WriteAzureTrace(outputPath, functions)
+	default:
+		return fmt.Errorf("unsupported dataset format '%s'", cfg.Format)
+	}
+}
+
+func importAzure2019(cfg ImportConfiguration, outputPath string) error {
+	days := cfg.Days
+	if len(days) == 0 {
+		entries, err := os.ReadDir(cfg.InputPath)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if match := raw2019DayPattern.FindStringSubmatch(entry.Name()); match != nil {
+				day, _ := strconv.Atoi(match[1])
+				days = append(days, day)
+			}
+		}
+		sort.Ints(days)
+	}
+
+	if len(days) == 0 {
+		return fmt.Errorf("%s contains no invocations_per_function_md.anon.dXX.csv files", cfg.InputPath)
+	}
+
+	for i, day := range days {
+		functions, err := readAzure2019Day(cfg.InputPath, day)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Imported %d functions of day %d.", len(functions), day)
+
+		if len(days) == 1 {
+			return WriteAzureTrace(outputPath, functions)
+		}
+
+		if err = WriteAzureTraceDay(outputPath, i+1, functions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readAzure2019Day reads a day of the 2019 dataset, keeping only the invoked functions with a non-zero duration that
+// appear in all three files. As the memory is reported per application, each function of an application gets an
+// equal share of it.
+func readAzure2019Day(inputPath string, day int) ([]*common.Function, error) {
+	paths := make([]string, 3)
+	for i, name := range []string{"invocations_per_function_md", "function_durations_percentiles", "app_memory_percentiles"} {
+		paths[i] = filepath.Join(inputPath, fmt.Sprintf("%s.anon.d%02d.csv", name, day))
+
+		if _, err := os.Stat(paths[i]); err != nil {
+			return nil, fmt.Errorf("day %d of the dataset is incomplete - %v", day, err)
+		}
+	}
+
+	// invalid rows are cleaned as well
+	invocations, err := parseInvocationTrace(paths[0], 0, common.MinutesInADay, true)
+	if err != nil {
+		return nil, err
+	}
+	runtime, err := parseRuntimeTrace(paths[1], true)
+	if err != nil {
+		return nil, err
+	}
+	runtimeByFunction := createRuntimeMap(runtime)
+
+	// HashFunction is absent in the application memory file
+	appMemory, err := parseStatsTrace[common.FunctionMemoryStats](paths[2], "HashApp", true)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryByApp := make(map[string]*common.FunctionMemoryStats)
+	for _, stats := range appMemory {
+		if _, ok := memoryByApp[stats.HashApp]; !ok {
+			memory := stats
+			memoryByApp[stats.HashApp] = &memory
+		}
+	}
+
+	seen := make(map[string]bool)
+	var functions []*common.Function
+	functionsPerApp := make(map[string]int)
+
+	for i := range *invocations {
+		stats := &(*invocations)[i]
+		runtime := runtimeByFunction[stats.HashFunction]
+
+		if seen[stats.HashFunction] || runtime == nil || runtime.Average == 0 || memoryByApp[stats.HashApp] == nil {
+			continue
+		}
+
+		total := 0
+		for _, count := range stats.Invocations {
+			total += count
+		}
+		if total == 0 {
+			continue
+		}
+
+		seen[stats.HashFunction] = true
+		functionsPerApp[stats.HashApp]++
+
+		functions = append(functions, &common.Function{
+			InvocationStats: stats,
+			RuntimeStats:    runtime,
+		})
+	}
+
+	for _, function := range functions {
+		memory := *memoryByApp[function.InvocationStats.HashApp]
+		share := float64(functionsPerApp[function.InvocationStats.HashApp])
+
+		memory.HashFunction = function.InvocationStats.HashFunction
+		memory.Average /= share
+		memory.Percentile1 /= share
+		memory.Percentile5 /= share
+		memory.Percentile25 /= share
+		memory.Percentile50 /= share
+		memory.Percentile75 /= share
+		memory.Percentile95 /= share
+		memory.Percentile99 /= share
+		memory.Percentile100 /= share
+
+		function.MemoryStats = &memory
+	}
+
+	return functions, nil
+}
+
+// readAzure2021 reads the per-invocation trace of the 2021 dataset (app, func, end_timestamp and duration in seconds)
+// and bins the invocations into per-minute counts by their start time
+func readAzure2021(path string, memoryMiB float64) ([]*common.Function, error) {
+	if memoryMiB <= 0 {
+		return nil, fmt.Errorf("memory of the imported functions must be positive")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header of %s - %v", path, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"app", "func", "end_timestamp", "duration"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s does not contain the column '%s'", path, name)
+		}
+	}
+
+	type functionTrace struct {
+		app, function string
+		starts        []int
+		durations     []float64
+	}
+
+	var order []*functionTrace
+	byHash := make(map[string]*functionTrace)
+	duration := 0
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		end, err := strconv.ParseFloat(record[columns["end_timestamp"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid end timestamp - %v", path, row, err)
+		}
+		runtime, err := strconv.ParseFloat(record[columns["duration"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid duration - %v", path, row, err)
+		}
+
+		app, function := record[columns["app"]], record[columns["func"]]
+
+		// function hashes are only unique within an application
+		key := app + "/" + function
+
+		invocationTrace, ok := byHash[key]
+		if !ok {
+			invocationTrace = &functionTrace{app: app, function: function}
+			byHash[key] = invocationTrace
+			order = append(order, invocationTrace)
+		}
+
+		minute := common.MaxOf(int(math.Floor((end-runtime)/60)), 0)
+		duration = common.MaxOf(duration, minute+1)
+
+		invocationTrace.starts = append(invocationTrace.starts, minute)
+		invocationTrace.durations = append(invocationTrace.durations, runtime*1000)
+	}
+
+	occurrences := make(map[string]int)
+	for _, invocationTrace := range order {
+		occurrences[invocationTrace.function]++
+	}
+
+	var functions []*common.Function
+	for _, invocationTrace := range order {
+		// the loader identifies functions by their hash alone
+		if occurrences[invocationTrace.function] > 1 {
+			invocationTrace.function += "-" + invocationTrace.app
+		}
+
+		invocations := make([]int, duration)
+		for _, minute := range invocationTrace.starts {
+			invocations[minute]++
+		}
+
+		functions = append(functions, &common.Function{
+			InvocationStats: &common.FunctionInvocationStats{
+				// the dataset does not contain owners and triggers
+				HashOwner:    invocationTrace.app,
+				HashApp:      invocationTrace.app,
+				HashFunction: invocationTrace.function,
+				Trigger:      "unknown",
+				Invocations:  invocations,
+			},
+			RuntimeStats: sampleRuntimeStats(invocationTrace.app, invocationTrace.function, invocationTrace.durations, nil),
+			MemoryStats:  constantMemoryStats(invocationTrace.app, invocationTrace.function, len(invocationTrace.durations), memoryMiB),
+		})
+	}
+
+	return functions, nil
+}
+
+// sampleRuntimeStats summarizes runtimes in milliseconds in the format of the durations file. Each sample stands for
+// the number of invocations given by its weight, or for a single one if weights is nil. The samples are sorted in place.
+func sampleRuntimeStats(app string, function string, durations []float64, weights []float64) *common.FunctionRuntimeStats {
+	sortSamples(durations, weights)
+	percentile := func(p float64) float64 {
+		return stat.Quantile(p, stat.Empirical, durations, weights)
+	}
+
+	return &common.FunctionRuntimeStats{
+		HashOwner:     app,
+		HashApp:       app,
+		HashFunction:  function,
+		Average:       stat.Mean(durations, weights),
+		Count:         sampleCount(durations, weights),
+		Minimum:       durations[0],
+		Maximum:       durations[len(durations)-1],
+		Percentile0:   durations[0],
+		Percentile1:   percentile(0.01),
+		Percentile25:  percentile(0.25),
+		Percentile50:  percentile(0.50),
+		Percentile75:  percentile(0.75),
+		Percentile99:  percentile(0.99),
+		Percentile100: durations[len(durations)-1],
+	}
+}
+
+// sampleMemoryStats summarizes memory usage in MiB in the format of the memory file, with the same weighting as
+// sampleRuntimeStats. The samples are sorted in place.
+func sampleMemoryStats(app string, function string, memory []float64, weights []float64) *common.FunctionMemoryStats {
+	sortSamples(memory, weights)
+	percentile := func(p float64) float64 {
+		return stat.Quantile(p, stat.Empirical, memory, weights)
+	}
+
+	return &common.FunctionMemoryStats{
+		HashOwner:     app,
+		HashApp:       app,
+		HashFunction:  function,
+		Count:         sampleCount(memory, weights),
+		Average:       stat.Mean(memory, weights),
+		Percentile1:   percentile(0.01),
+		Percentile5:   percentile(0.05),
+		Percentile25:  percentile(0.25),
+		Percentile50:  percentile(0.50),
+		Percentile75:  percentile(0.75),
+		Percentile95:  percentile(0.95),
+		Percentile99:  percentile(0.99),
+		Percentile100: memory[len(memory)-1],
+	}
+}
+
+// sortSamples sorts the samples in ascending order together with their weights
+func sortSamples(samples []float64, weights []float64) {
+	if weights == nil {
+		sort.Float64s(samples)
+		return
+	}
+
+	sort.Sort(weightedSamples{samples: samples, weights: weights})
+}
+
+func sampleCount(samples []float64, weights []float64) float64 {
+	if weights == nil {
+		return float64(len(samples))
+	}
+
+	return floats.Sum(weights)
+}
+
+type weightedSamples struct {
+	samples, weights []float64
+}
+
+func (w weightedSamples) Len() int           { return len(w.samples) }
+func (w weightedSamples) Less(i, j int) bool { return w.samples[i] < w.samples[j] }
+func (w weightedSamples) Swap(i, j int) {
+	w.samples[i], w.samples[j] = w.samples[j], w.samples[i]
+	w.weights[i], w.weights[j] = w.weights[j], w.weights[i]
+}
+
+// constantMemoryStats describes a function whose invocations all use the same amount of memory
+func constantMemoryStats(app string, function string, count int, memoryMiB float64) *common.FunctionMemoryStats {
+	return &common.FunctionMemoryStats{
+		HashOwner:     app,
+		HashApp:       app,
+		HashFunction:  function,
+		Count:         float64(count),
+		Average:       memoryMiB,
+		Percentile1:   memoryMiB,
+		Percentile5:   memoryMiB,
+		Percentile25:  memoryMiB,
+		Percentile50:  memoryMiB,
+		Percentile75:  memoryMiB,
+		Percentile95:  memoryMiB,
+		Percentile99:  memoryMiB,
+		Percentile100: memoryMiB,
+	}
+}
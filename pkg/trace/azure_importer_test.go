@@ -0,0 +1,189 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeRaw2019Day(t *testing.T, directory string, day int, invocationRows []string, durationRows []string, memoryRows []string) {
+	header := []string{"HashOwner", "HashApp", "HashFunction", "Trigger"}
+	for minute := 1; minute <= 1440; minute++ {
+		header = append(header, fmt.Sprintf("%d", minute))
+	}
+
+	files := map[string][]string{
+		"invocations_per_function_md": append([]string{strings.Join(header, ",")}, invocationRows...),
+		"function_durations_percentiles": append([]string{"HashOwner,HashApp,HashFunction,Average,Count,Minimum,Maximum," +
+			"percentile_Average_0,percentile_Average_1,percentile_Average_25,percentile_Average_50,percentile_Average_75," +
+			"percentile_Average_99,percentile_Average_100"}, durationRows...),
+		"app_memory_percentiles": append([]string{"HashOwner,HashApp,SampleCount,AverageAllocatedMb,AverageAllocatedMb_pct1," +
+			"AverageAllocatedMb_pct5,AverageAllocatedMb_pct25,AverageAllocatedMb_pct50,AverageAllocatedMb_pct75," +
+			"AverageAllocatedMb_pct95,AverageAllocatedMb_pct99,AverageAllocatedMb_pct100"}, memoryRows...),
+	}
+
+	for name, rows := range files {
+		path := filepath.Join(directory, fmt.Sprintf("%s.anon.d%02d.csv", name, day))
+		if err := os.WriteFile(path, []byte(strings.Join(rows, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func invocationRow(owner, app, function string, count int) string {
+	return fmt.Sprintf("%s,%s,%s,http,%s", owner, app, function, strings.TrimSuffix(strings.Repeat(fmt.Sprintf("%d,", count), 1440), ","))
+}
+
+func TestImportAzure2019(t *testing.T) {
+	input, output := t.TempDir(), t.TempDir()
+
+	writeRaw2019Day(t, input, 1,
+		[]string{
+			invocationRow("o1", "a1", "f1", 1),
+			invocationRow("o1", "a1", "f2", 2),
+			invocationRow("o1", "a1", "f1", 5), // duplicate
+			invocationRow("o2", "a2", "f3", 0), // never invoked
+			invocationRow("o3", "a3", "f4", 1), // no memory
+			invocationRow("o1", "a1", "f5", 1), // zero duration
+			invocationRow("o1", "a1", "f6", 1), // no duration
+		},
+		[]string{
+			"o1,a1,f1,10,100,1,20,1,2,5,10,15,19,20",
+			"o1,a1,f2,30,100,1,60,1,2,15,30,45,59,60",
+			"o2,a2,f3,10,100,1,20,1,2,5,10,15,19,20",
+			"o3,a3,f4,10,100,1,20,1,2,5,10,15,19,20",
+			"o1,a1,f5,0,100,0,0,0,0,0,0,0,0,0",
+		},
+		[]string{
+			"o1,a1,100,200,100,120,140,200,260,280,300,400",
+			"o2,a2,100,200,100,120,140,200,260,280,300,400",
+		})
+
+	err := ImportAzureTrace(ImportConfiguration{Format: Azure2019Format, InputPath: input}, output)
+	if err != nil {
+		t.Fatalf("Failed to import the dataset - %v", err)
+	}
+
+	functions, err := NewAzureParser(output, 10).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions after filtering, got %d.", len(functions))
+	}
+
+	for i, expected := range []struct {
+		hash        string
+		invocations int
+		runtime     float64
+	}{{"f1", 1, 10}, {"f2", 2, 30}} {
+		function := functions[i]
+		if function.InvocationStats.HashFunction != expected.hash || function.InvocationStats.Invocations[0] != expected.invocations ||
+			function.RuntimeStats.Average != expected.runtime {
+			t.Errorf("Unexpected function %s imported.", function.InvocationStats.HashFunction)
+		}
+
+		// the memory of the application is split among its two functions
+		if function.MemoryStats.Average != 100 || function.MemoryStats.Percentile50 != 100 || function.MemoryStats.Count != 100 {
+			t.Errorf("Unexpected memory statistics %+v of function %s.", function.MemoryStats, function.InvocationStats.HashFunction)
+		}
+	}
+}
+
+func TestImportAzure2019MultipleDays(t *testing.T) {
+	input, output := t.TempDir(), t.TempDir()
+
+	for _, day := range []int{3, 4} {
+		writeRaw2019Day(t, input, day,
+			[]string{invocationRow("o1", "a1", "f1", day)},
+			[]string{"o1,a1,f1,10,100,1,20,1,2,5,10,15,19,20"},
+			[]string{"o1,a1,100,200,100,120,140,200,260,280,300,400"})
+	}
+
+	err := ImportAzureTrace(ImportConfiguration{Format: Azure2019Format, InputPath: input, Days: []int{3, 4}}, output)
+	if err != nil {
+		t.Fatalf("Failed to import the dataset - %v", err)
+	}
+
+	functions, err := NewAzureParser(output, 4).WithStartMinute(1438).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 1 || !reflect.DeepEqual(functions[0].InvocationStats.Invocations, []int{3, 3, 4, 4}) {
+		t.Errorf("Imported days should be renumbered from the first one, got %v.", functions[0].InvocationStats.Invocations)
+	}
+}
+
+func TestImportAzure2021(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "AzureFunctionsInvocationTraceForTwoWeeksJan2021.txt")
+	output := t.TempDir()
+
+	trace := "app,func,end_timestamp,duration\n" +
+		"a1,f1,1.5,0.5\n" +
+		"a1,f1,59.0,0.1\n" +
+		"a1,f1,61.0,2.0\n" + // started in the first minute
+		"a1,f1,125.0,0.2\n" +
+		"a2,f1,30.0,1.0\n" // same function hash in another application
+	if err := os.WriteFile(input, []byte(trace), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ImportAzureTrace(ImportConfiguration{Format: Azure2021Format, InputPath: input, MemoryMiB: 256}, output)
+	if err != nil {
+		t.Fatalf("Failed to import the dataset - %v", err)
+	}
+
+	functions, err := NewAzureParser(output, 3).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions, got %d.", len(functions))
+	}
+
+	if !reflect.DeepEqual(functions[0].InvocationStats.Invocations, []int{3, 0, 1}) ||
+		!reflect.DeepEqual(functions[1].InvocationStats.Invocations, []int{1, 0, 0}) {
+		t.Errorf("Invocations are not binned by their start minute: %v, %v.",
+			functions[0].InvocationStats.Invocations, functions[1].InvocationStats.Invocations)
+	}
+
+	if functions[1].InvocationStats.HashFunction != "f1-a2" {
+		t.Errorf("Function hash shared by two applications should be disambiguated, got %s.", functions[1].InvocationStats.HashFunction)
+	}
+
+	runtime := functions[0].RuntimeStats
+	if runtime.Count != 4 || !floatEqual(runtime.Minimum, 100) || !floatEqual(runtime.Maximum, 2000) || !floatEqual(runtime.Average, 700) {
+		t.Errorf("Unexpected runtime statistics %+v.", runtime)
+	}
+
+	if functions[0].MemoryStats.Percentile50 != 256 {
+		t.Errorf("Memory of the imported functions should be %d MiB.", 256)
+	}
+}
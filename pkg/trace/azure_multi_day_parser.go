@@ -0,0 +1,231 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+var dayFilePattern = regexp.MustCompile(`^invocations_d(\d+)\.csv$`)
+
+// traceDay holds the files of a single day of a multi-day trace, i.e., invocations_dNN.csv, durations_dNN.csv and
+// memory_dNN.csv, with days numbered from 1
+type traceDay struct {
+	invocationPath string
+	runtimePath    string
+	memoryPath     string
+}
+
+func (p *AzureTraceParser) findTraceDays() (map[int]traceDay, error) {
+	entries, err := os.ReadDir(p.DirectoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the trace directory %s - %v", p.DirectoryPath, err)
+	}
+
+	days := make(map[int]traceDay)
+	for _, entry := range entries {
+		match := dayFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		number, _ := strconv.Atoi(match[1])
+		suffix := fmt.Sprintf("_d%s.csv", match[1])
+
+		days[number-1] = traceDay{
+			invocationPath: filepath.Join(p.DirectoryPath, entry.Name()),
+			runtimePath:    p.dayOrCommonFile("durations", suffix),
+			memoryPath:     p.dayOrCommonFile("memory", suffix),
+		}
+	}
+
+	return days, nil
+}
+
+// dayOrCommonFile returns the per-day file if it exists and the file shared by all days otherwise
+func (p *AzureTraceParser) dayOrCommonFile(name string, suffix string) string {
+	path := filepath.Join(p.DirectoryPath, name+suffix)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	return filepath.Join(p.DirectoryPath, name+".csv")
+}
+
+// parseMultiDayTrace reads the trace window from per-day files. Functions are identified across days by their
+// HashFunction, and their runtime and memory statistics follow the day being replayed.
+func (p *AzureTraceParser) parseMultiDayTrace() ([]*common.Function, error) {
+	days, err := p.findTraceDays()
+	if err != nil {
+		return nil, err
+	} else if len(days) == 0 {
+		return nil, fmt.Errorf("trace directory %s contains neither invocations.csv nor per-day invocations_dNN.csv files", p.DirectoryPath)
+	}
+
+	duration := common.MaxOf(p.duration, 1)
+	firstDay := p.startMinute / common.MinutesInADay
+	lastDay := (p.startMinute + duration - 1) / common.MinutesInADay
+
+	var invocations []common.FunctionInvocationStats
+	functionIndex := make(map[string]int)
+
+	var runtimeByDay []map[string]*common.FunctionRuntimeStats
+	var memoryByDay []map[string]*common.FunctionMemoryStats
+	parsedRuntime := make(map[string]map[string]*common.FunctionRuntimeStats)
+	parsedMemory := make(map[string]map[string]*common.FunctionMemoryStats)
+
+	for day := firstDay; day <= lastDay; day++ {
+		files, ok := days[day]
+		if !ok {
+			return nil, fmt.Errorf("trace window requires day %d, but %s does not contain invocations_d%02d.csv", day+1, p.DirectoryPath, day+1)
+		}
+
+		// part of the window falling onto this day
+		windowStart := day*common.MinutesInADay - p.startMinute
+		dayStart := common.MaxOf(p.startMinute-day*common.MinutesInADay, 0)
+		dayDuration := common.MinOf(common.MinutesInADay-dayStart, duration-common.MaxOf(windowStart, 0))
+
+		dayInvocations, err := parseInvocationTrace(files.invocationPath, dayStart, dayDuration, p.skipInvalidRows)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stats := range *dayInvocations {
+			index, ok := functionIndex[stats.HashFunction]
+			if !ok {
+				index = len(invocations)
+				functionIndex[stats.HashFunction] = index
+
+				invocations = append(invocations, stats)
+				invocations[index].Invocations = make([]int, duration)
+			}
+
+			copy(invocations[index].Invocations[common.MaxOf(windowStart, 0):], stats.Invocations)
+		}
+
+		// days sharing a file share the parsed statistics
+		if _, ok = parsedRuntime[files.runtimePath]; !ok {
+			runtime, err := parseRuntimeTrace(files.runtimePath, p.skipInvalidRows)
+			if err != nil {
+				return nil, err
+			}
+			parsedRuntime[files.runtimePath] = createRuntimeMap(runtime)
+		}
+		if _, ok = parsedMemory[files.memoryPath]; !ok {
+			memory, err := parseMemoryTrace(files.memoryPath, p.skipInvalidRows)
+			if err != nil {
+				return nil, err
+			}
+			parsedMemory[files.memoryPath] = createMemoryMap(memory)
+		}
+
+		runtimeByDay = append(runtimeByDay, parsedRuntime[files.runtimePath])
+		memoryByDay = append(memoryByDay, parsedMemory[files.memoryPath])
+	}
+
+	periods := make(map[string][]common.ExecutionStatsPeriod)
+	var runtime []common.FunctionRuntimeStats
+	var memory []common.FunctionMemoryStats
+
+	for _, stats := range invocations {
+		functionPeriods := dailyStats(stats.HashFunction, runtimeByDay, memoryByDay, p.startMinute)
+		periods[stats.HashFunction] = functionPeriods
+
+		// the statistics of the first day are the default ones of the function
+		if functionPeriods[0].RuntimeStats != nil {
+			runtime = append(runtime, *functionPeriods[0].RuntimeStats)
+		}
+		if functionPeriods[0].MemoryStats != nil {
+			memory = append(memory, *functionPeriods[0].MemoryStats)
+		}
+	}
+
+	// functions without statistics on any day of the window are reported against the files of the first day
+	functions, err := p.extractFunctions(&invocations, &runtime, &memory, days[firstDay].runtimePath, days[firstDay].memoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, function := range functions {
+		functionPeriods := periods[function.InvocationStats.HashFunction]
+
+		for _, period := range functionPeriods[1:] {
+			if period.RuntimeStats != functionPeriods[0].RuntimeStats || period.MemoryStats != functionPeriods[0].MemoryStats {
+				function.ExecutionStatsPeriods = functionPeriods
+				break
+			}
+		}
+	}
+
+	return functions, nil
+}
+
+// dailyStats returns the statistics of each day of the window, falling back to the closest earlier (or later) day in
+// which the function has statistics
+func dailyStats(hash string, runtimeByDay []map[string]*common.FunctionRuntimeStats,
+	memoryByDay []map[string]*common.FunctionMemoryStats, startMinute int) []common.ExecutionStatsPeriod {
+
+	var periods []common.ExecutionStatsPeriod
+	var runtime *common.FunctionRuntimeStats
+	var memory *common.FunctionMemoryStats
+
+	for day := range runtimeByDay {
+		if stats, ok := runtimeByDay[day][hash]; ok {
+			runtime = stats
+		}
+		if stats, ok := memoryByDay[day][hash]; ok {
+			memory = stats
+		}
+
+		fromMinute := 0
+		if day > 0 {
+			fromMinute = (startMinute/common.MinutesInADay+day)*common.MinutesInADay - startMinute
+		}
+
+		periods = append(periods, common.ExecutionStatsPeriod{
+			FromMinute:   fromMinute,
+			RuntimeStats: runtime,
+			MemoryStats:  memory,
+		})
+	}
+
+	// days before the first one with statistics use the first available ones
+	for i := len(periods) - 2; i >= 0; i-- {
+		if periods[i].RuntimeStats == nil {
+			periods[i].RuntimeStats = periods[i+1].RuntimeStats
+		}
+		if periods[i].MemoryStats == nil {
+			periods[i].MemoryStats = periods[i+1].MemoryStats
+		}
+	}
+
+	return periods
+}
@@ -27,12 +27,13 @@ package trace
 import (
 	"encoding/csv"
 	"fmt"
-	"github.com/gocarina/gocsv"
 	"github.com/vhive-serverless/loader/pkg/common"
 	"github.com/vhive-serverless/loader/pkg/generator"
 	"io"
+	"math"
 	"math/rand"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +45,8 @@ type AzureTraceParser struct {
 	DirectoryPath string
 
 	duration              int
+	startMinute           int
+	skipInvalidRows       bool
 	functionNameGenerator *rand.Rand
 }
 
@@ -56,6 +59,26 @@ func NewAzureParser(directoryPath string, totalDuration int) *AzureTraceParser {
 	}
 }
 
+// WithStartMinute makes the parser read the trace window starting at the given minute of the trace instead of the
+// first minute. The window may span several days.
+func (p *AzureTraceParser) WithStartMinute(startMinute int) *AzureTraceParser {
+	if startMinute < 0 {
+		log.Fatalf("Trace start minute must not be negative, got %d.", startMinute)
+	}
+
+	p.startMinute = startMinute
+
+	return p
+}
+
+// WithSkipInvalidRows makes the parser drop the invalid rows of the trace and the functions missing from the runtime
+// or the memory trace with a warning. By default, the parser reports all of them as an error.
+func (p *AzureTraceParser) WithSkipInvalidRows(skip bool) *AzureTraceParser {
+	p.skipInvalidRows = skip
+
+	return p
+}
+
 func createRuntimeMap(runtime *[]common.FunctionRuntimeStats) map[string]*common.FunctionRuntimeStats {
 	result := make(map[string]*common.FunctionRuntimeStats)
 
@@ -86,162 +109,308 @@ func createDirigentMetadataMap(metadata *[]common.DirigentMetadata) map[string]*
 	return result
 }
 
-func (p *AzureTraceParser) extractFunctions(invocations *[]common.FunctionInvocationStats, runtime *[]common.FunctionRuntimeStats, memory *[]common.FunctionMemoryStats) []*common.Function {
+// extractFunctions joins the statistics of the functions by HashFunction. Functions missing from the runtime or the
+// memory trace are dropped in the skip mode and reported otherwise.
+func (p *AzureTraceParser) extractFunctions(invocations *[]common.FunctionInvocationStats, runtime *[]common.FunctionRuntimeStats,
+	memory *[]common.FunctionMemoryStats, runtimePath string, memoryPath string) ([]*common.Function, error) {
+
 	var result []*common.Function
 
 	runtimeByHashFunction := createRuntimeMap(runtime)
 	memoryByHashFunction := createMemoryMap(memory)
 
+	var missing MissingStatsErrors
+	missingRuntime := &MissingStatsError{File: runtimePath}
+	missingMemory := &MissingStatsError{File: memoryPath}
+
 	gen := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	for i := 0; i < len(*invocations); i++ {
 		invocationStats := (*invocations)[i]
 
+		runtimeStats, hasRuntime := runtimeByHashFunction[invocationStats.HashFunction]
+		memoryStats, hasMemory := memoryByHashFunction[invocationStats.HashFunction]
+		if !hasRuntime {
+			missingRuntime.Functions = append(missingRuntime.Functions, invocationStats.HashFunction)
+		}
+		if !hasMemory {
+			missingMemory.Functions = append(missingMemory.Functions, invocationStats.HashFunction)
+		}
+		if !hasRuntime || !hasMemory {
+			continue
+		}
+
 		function := &common.Function{
 			Name: fmt.Sprintf("%s-%d-%d", common.FunctionNamePrefix, i, p.functionNameGenerator.Uint64()),
 
 			InvocationStats: &invocationStats,
-			RuntimeStats:    runtimeByHashFunction[invocationStats.HashFunction],
-			MemoryStats:     memoryByHashFunction[invocationStats.HashFunction],
+			RuntimeStats:    runtimeStats,
+			MemoryStats:     memoryStats,
 
-			ColdStartBusyLoopMs: generator.ComputeBusyLoopPeriod(generator.GenerateMemorySpec(gen, gen.Float64(), memoryByHashFunction[invocationStats.HashFunction])),
+			ColdStartBusyLoopMs: generator.ComputeBusyLoopPeriod(generator.GenerateMemorySpec(gen, gen.Float64(), memoryStats)),
 		}
 
 		result = append(result, function)
 	}
 
-	return result
+	for _, missingStats := range []*MissingStatsError{missingRuntime, missingMemory} {
+		if len(missingStats.Functions) > 0 {
+			missing = append(missing, missingStats)
+		}
+	}
+
+	if len(missing) > 0 {
+		if !p.skipInvalidRows {
+			return nil, missing
+		}
+
+		log.Warnf("Skipping functions without statistics - %v", missing)
+	}
+
+	return result, nil
 }
 
-func (p *AzureTraceParser) Parse() []*common.Function {
+func (p *AzureTraceParser) Parse() ([]*common.Function, error) {
 	invocationPath := p.DirectoryPath + "/invocations.csv"
 	runtimePath := p.DirectoryPath + "/durations.csv"
 	memoryPath := p.DirectoryPath + "/memory.csv"
 
-	invocationTrace := parseInvocationTrace(invocationPath, p.duration)
-	runtimeTrace := parseRuntimeTrace(runtimePath)
-	memoryTrace := parseMemoryTrace(memoryPath)
+	if _, err := os.Stat(invocationPath); err != nil {
+		return p.parseMultiDayTrace()
+	}
 
-	return p.extractFunctions(invocationTrace, runtimeTrace, memoryTrace)
+	invocationTrace, err := parseInvocationTrace(invocationPath, p.startMinute, p.duration, p.skipInvalidRows)
+	if err != nil {
+		return nil, err
+	}
+	runtimeTrace, err := parseRuntimeTrace(runtimePath, p.skipInvalidRows)
+	if err != nil {
+		return nil, err
+	}
+	memoryTrace, err := parseMemoryTrace(memoryPath, p.skipInvalidRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.extractFunctions(invocationTrace, runtimeTrace, memoryTrace, runtimePath, memoryPath)
 }
 
-func parseInvocationTrace(traceFile string, traceDuration int) *[]common.FunctionInvocationStats {
-	log.Infof("Parsing function invocation trace %s (duration: %d min)", traceFile, traceDuration)
+// parseInvocationTrace reads the invocations of the trace window [startMinute, startMinute + traceDuration) minutes
+func parseInvocationTrace(traceFile string, startMinute int, traceDuration int, skipInvalidRows bool) (*[]common.FunctionInvocationStats, error) {
+	log.Infof("Parsing function invocation trace %s (start: minute %d, duration: %d min)", traceFile, startMinute, traceDuration)
 
-	// Fit duration on (0, 1440] interval
-	traceDuration = common.MaxOf(common.MinOf(traceDuration, 1440), 1)
+	traceDuration = common.MaxOf(traceDuration, 1)
 
 	var result []common.FunctionInvocationStats
 
-	invocationIndices := make([][]int, traceDuration)
-	totalInvocations := make([]int, traceDuration)
-
 	csvfile, err := os.Open(traceFile)
 	if err != nil {
-		log.Fatal("Failed to open invocation CSV file.", err)
+		return nil, fmt.Errorf("failed to open the invocation trace - %v", err)
 	}
+	defer csvfile.Close()
 
 	reader := csv.NewReader(csvfile)
+	// the number of fields is checked row by row to report the row instead of stopping at it
+	reader.FieldsPerRecord = -1
 
-	rowID := -1
-	hashOwnerIndex, hashAppIndex, hashFunctionIndex, invocationColumnIndex := -1, -1, -1, -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, &ParseError{File: traceFile, Line: 1, Err: err}
+	}
 
-	for {
-		record, err := reader.Read()
+	hashOwnerIndex, hashAppIndex, hashFunctionIndex, triggerIndex := -1, -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "hashowner":
+			hashOwnerIndex = i
+		case "hashapp":
+			hashAppIndex = i
+		case "hashfunction":
+			hashFunctionIndex = i
+		case "trigger":
+			triggerIndex = i
+		}
+	}
 
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			log.Fatal(err)
-		}
-
-		if rowID == -1 {
-			// Parse header
-			for i := 0; i < 4; i++ {
-				switch strings.ToLower(record[i]) {
-				case "hashowner":
-					hashOwnerIndex = i
-				case "hashapp":
-					hashAppIndex = i
-				case "hashfunction":
-					hashFunctionIndex = i
-				case "trigger": //! Unused field.
-					invocationColumnIndex = i + 1
-				}
-			}
+	if hashOwnerIndex == -1 || hashAppIndex == -1 || hashFunctionIndex == -1 {
+		return nil, &ParseError{File: traceFile, Line: 1, Err: fmt.Errorf("missing at least one of the columns HashOwner, HashApp and HashFunction")}
+	}
 
-			if hashOwnerIndex == -1 || hashAppIndex == -1 || hashFunctionIndex == -1 {
-				log.Fatal("Invocation trace does not contain at least one of the hashes.")
-			}
+	// the minute columns follow the hash and the trigger columns
+	invocationColumnIndex := common.MaxOf(hashOwnerIndex, hashAppIndex, hashFunctionIndex, triggerIndex) + 1
+
+	firstColumn := invocationColumnIndex + startMinute
+	if firstColumn+traceDuration > len(header) {
+		return nil, &ParseError{File: traceFile, Line: 1, Err: fmt.Errorf("the trace contains %d minutes, but the trace window ends at minute %d",
+			len(header)-invocationColumnIndex, startMinute+traceDuration)}
+	}
 
-			if invocationColumnIndex == -1 {
-				invocationColumnIndex = 3
+	problems := newRowProblems(skipInvalidRows)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
 			}
-		} else {
-			// Parse invocations
-			var invocations []int
 
-			for i := invocationColumnIndex; i < invocationColumnIndex+traceDuration; i++ {
-				minute := i - invocationColumnIndex
-				num, err := strconv.Atoi(record[i])
-				common.Check(err)
+			problems.add(&ParseError{File: traceFile, Line: line, Err: err})
+			continue
+		}
 
-				invocations = append(invocations, num)
+		if len(record) != len(header) {
+			problems.add(&ParseError{File: traceFile, Line: line, Err: fmt.Errorf("expected %d columns, got %d", len(header), len(record))})
+			continue
+		}
+		if strings.TrimSpace(record[hashFunctionIndex]) == "" {
+			problems.add(&ParseError{File: traceFile, Line: line, Column: header[hashFunctionIndex], Err: fmt.Errorf("empty function hash")})
+			continue
+		}
 
-				for j := 0; j < num; j++ {
-					invocationIndices[minute] = append(invocationIndices[minute], rowID)
-				}
-				totalInvocations[minute] = totalInvocations[minute] + num
+		valid := true
+		invocations := make([]int, 0, traceDuration)
+		for i := firstColumn; i < firstColumn+traceDuration; i++ {
+			count, err := strconv.Atoi(strings.TrimSpace(record[i]))
+			if err == nil && count < 0 {
+				err = fmt.Errorf("negative number of invocations %d", count)
+			}
+			if err != nil {
+				problems.add(&ParseError{File: traceFile, Line: line, Column: header[i], Err: err})
+				valid = false
+				continue
 			}
 
-			result = append(result, common.FunctionInvocationStats{
-				HashOwner:    record[hashOwnerIndex],
-				HashApp:      record[hashAppIndex],
-				HashFunction: record[hashFunctionIndex],
-				Trigger:      record[invocationColumnIndex-1],
-				Invocations:  invocations,
-			})
+			invocations = append(invocations, count)
+		}
+		if !valid {
+			continue
+		}
+
+		trigger := ""
+		if triggerIndex != -1 {
+			trigger = record[triggerIndex]
 		}
 
-		rowID++
+		result = append(result, common.FunctionInvocationStats{
+			HashOwner:    record[hashOwnerIndex],
+			HashApp:      record[hashAppIndex],
+			HashFunction: record[hashFunctionIndex],
+			Trigger:      trigger,
+			Invocations:  invocations,
+		})
 	}
 
-	return &result
+	return &result, problems.err()
 }
 
-func parseRuntimeTrace(traceFile string) *[]common.FunctionRuntimeStats {
+func parseRuntimeTrace(traceFile string, skipInvalidRows bool) (*[]common.FunctionRuntimeStats, error) {
 	log.Infof("Parsing function duration trace: %s\n", traceFile)
 
+	runtime, err := parseStatsTrace[common.FunctionRuntimeStats](traceFile, "HashFunction", skipInvalidRows)
+	return &runtime, err
+}
+
+func parseMemoryTrace(traceFile string, skipInvalidRows bool) (*[]common.FunctionMemoryStats, error) {
+	log.Infof("Parsing function memory trace: %s", traceFile)
+
+	memory, err := parseStatsTrace[common.FunctionMemoryStats](traceFile, "HashFunction", skipInvalidRows)
+	return &memory, err
+}
+
+// parseStatsTrace reads the rows of a statistics file into the fields of T by their csv tags. Only the key column is
+// required, and empty cells are read as zero.
+func parseStatsTrace[T any](traceFile string, key string, skipInvalidRows bool) ([]T, error) {
 	f, err := os.Open(traceFile)
 	if err != nil {
-		log.Fatal("Failed to open trace runtime specification file.")
+		return nil, fmt.Errorf("failed to open the statistics trace - %v", err)
 	}
 	defer f.Close()
 
-	var runtime []common.FunctionRuntimeStats
-	err = gocsv.UnmarshalFile(f, &runtime)
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
 	if err != nil {
-		log.Fatal("Failed to parse trace runtime specification.")
+		return nil, &ParseError{File: traceFile, Line: 1, Err: err}
 	}
 
-	return &runtime
-}
-
-func parseMemoryTrace(traceFile string) *[]common.FunctionMemoryStats {
-	log.Infof("Parsing function memory trace: %s", traceFile)
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns[key]; !ok {
+		return nil, &ParseError{File: traceFile, Line: 1, Err: fmt.Errorf("missing column '%s'", key)}
+	}
 
-	f, err := os.Open(traceFile)
-	if err != nil {
-		log.Fatal("Failed to open trace memory specification file.")
+	// field index of the columns present in the file
+	statsType := reflect.TypeOf((*T)(nil)).Elem()
+	fields := make(map[int]int)
+	for i := 0; i < statsType.NumField(); i++ {
+		if column, ok := columns[statsType.Field(i).Tag.Get("csv")]; ok {
+			fields[column] = i
+		}
 	}
-	defer f.Close()
 
-	var memory []common.FunctionMemoryStats
-	err = gocsv.UnmarshalFile(f, &memory)
-	if err != nil {
-		log.Fatal("Failed to parse trace runtime specification.")
+	var result []T
+	problems := newRowProblems(skipInvalidRows)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
+			}
+
+			problems.add(&ParseError{File: traceFile, Line: line, Err: err})
+			continue
+		}
+
+		if len(record) != len(header) {
+			problems.add(&ParseError{File: traceFile, Line: line, Err: fmt.Errorf("expected %d columns, got %d", len(header), len(record))})
+			continue
+		}
+
+		var stats T
+		value := reflect.ValueOf(&stats).Elem()
+		valid := true
+
+		for column, field := range fields {
+			cell := strings.TrimSpace(record[column])
+
+			switch value.Field(field).Kind() {
+			case reflect.String:
+				value.Field(field).SetString(cell)
+			case reflect.Float64:
+				if cell == "" {
+					continue
+				}
+
+				number, err := strconv.ParseFloat(cell, 64)
+				if err == nil && (math.IsNaN(number) || number < 0) {
+					err = fmt.Errorf("invalid value %s", cell)
+				}
+				if err != nil {
+					problems.add(&ParseError{File: traceFile, Line: line, Column: header[column], Err: err})
+					valid = false
+					continue
+				}
+
+				value.Field(field).SetFloat(number)
+			}
+		}
+
+		if valid && strings.TrimSpace(record[columns[key]]) == "" {
+			problems.add(&ParseError{File: traceFile, Line: line, Column: key, Err: fmt.Errorf("empty key")})
+			valid = false
+		}
+		if valid {
+			result = append(result, stats)
+		}
 	}
 
-	return &memory
+	return result, problems.err()
 }
@@ -25,8 +25,11 @@
 package trace
 
 import (
+	"errors"
 	"github.com/vhive-serverless/loader/pkg/common"
 	"math"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -37,7 +40,11 @@ func floatEqual(n, expected float64) bool {
 
 func TestParseInvocationTrace(t *testing.T) {
 	duration := 10
-	invocationTrace := *parseInvocationTrace("test_data/invocations.csv", duration)
+	parsed, err := parseInvocationTrace("test_data/invocations.csv", 0, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invocationTrace := *parsed
 
 	if len(invocationTrace) != 1 {
 		t.Error("Invalid invocations trace provided.")
@@ -65,7 +72,11 @@ func TestParseInvocationTrace(t *testing.T) {
 }
 
 func TestParseRuntimeTrace(t *testing.T) {
-	runtimeTrace := *parseRuntimeTrace("test_data/durations.csv")
+	parsed, err := parseRuntimeTrace("test_data/durations.csv", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtimeTrace := *parsed
 
 	if len(runtimeTrace) != 1 {
 		t.Error("Invalid runtime trace provided.")
@@ -93,7 +104,11 @@ func TestParseRuntimeTrace(t *testing.T) {
 }
 
 func TestParseMemoryTrace(t *testing.T) {
-	memoryTrace := *parseMemoryTrace("test_data/memory.csv")
+	parsed, err := parseMemoryTrace("test_data/memory.csv", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memoryTrace := *parsed
 
 	if len(memoryTrace) != 1 {
 		t.Error("Invalid memory trace provided.")
@@ -121,7 +136,10 @@ func TestParseMemoryTrace(t *testing.T) {
 
 func TestParserWrapper(t *testing.T) {
 	parser := NewAzureParser("test_data", 10)
-	functions := parser.Parse()
+	functions, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(functions) != 1 {
 		t.Error("Invalid function array length.")
@@ -134,3 +152,168 @@ func TestParserWrapper(t *testing.T) {
 		t.Error("Unexpected results.")
 	}
 }
+
+func TestParseInvocationTraceWindow(t *testing.T) {
+	parsed, err := parseInvocationTrace("test_data/invocations.csv", 5, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	function := (*parsed)[0]
+
+	expected := []int{6, 7, 8, 9, 10, 5, 5, 5, 5, 5}
+	if !reflect.DeepEqual(function.Invocations, expected) {
+		t.Errorf("Expected invocations %v of the trace window, got %v.", expected, function.Invocations)
+	}
+}
+
+func TestParseMultiDayTrace(t *testing.T) {
+	// window from 23:58 of the first day to 00:03 of the second day
+	functions, err := NewAzureParser("test_data/multi_day", 5).WithStartMinute(1438).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions, got %d.", len(functions))
+	}
+
+	byHash := make(map[string]*common.Function)
+	for _, function := range functions {
+		byHash[function.InvocationStats.HashFunction] = function
+	}
+
+	a, b := byHash["function-a"], byHash["function-b"]
+	if a == nil || b == nil {
+		t.Fatal("Functions are not identified by their hash across days.")
+	}
+
+	if !reflect.DeepEqual(a.InvocationStats.Invocations, []int{9, 10, 100, 101, 102}) ||
+		!reflect.DeepEqual(b.InvocationStats.Invocations, []int{0, 0, 7, 7, 7}) {
+		t.Errorf("Unexpected invocations across the day boundary: %v, %v.", a.InvocationStats.Invocations, b.InvocationStats.Invocations)
+	}
+
+	if a.RuntimeStats.Average != 10 || len(a.ExecutionStatsPeriods) != 2 ||
+		a.ExecutionStatsPeriods[1].FromMinute != 2 || a.ExecutionStatsPeriods[1].RuntimeStats.Average != 200 {
+		t.Errorf("Runtime statistics of function-a should change with the day, got %+v.", a.ExecutionStatsPeriods)
+	}
+
+	// function-b has statistics only for the second day, and memory statistics are shared by all the days
+	if b.RuntimeStats == nil || b.RuntimeStats.Average != 50 || b.ExecutionStatsPeriods != nil || b.MemoryStats.Average != 256 {
+		t.Errorf("Statistics of function-b should fall back to the second day, got %+v.", b.ExecutionStatsPeriods)
+	}
+}
+
+func TestParseInvocationTraceErrors(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"invocations.csv": {
+			"HashOwner,HashApp,HashFunction,Trigger,1,2",
+			"o1,a1,f1,http,1,2",
+			"o1,a1,f2,http,1,x",
+			"o1,a1,f3,http,1",
+			"o1,a1,,http,1,1",
+			"o1,a1,f4,http,-1,2",
+		},
+	})
+	path := filepath.Join(directory, "invocations.csv")
+
+	_, err := parseInvocationTrace(path, 0, 2, false)
+
+	var problems ParseErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Expected all the problems of the trace, got %v.", err)
+	}
+
+	expected := []ParseError{
+		{File: path, Line: 3, Column: "2"},
+		{File: path, Line: 4},
+		{File: path, Line: 5, Column: "HashFunction"},
+		{File: path, Line: 6, Column: "1"},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v.", len(expected), problems)
+	}
+	for i, problem := range problems {
+		if problem.File != expected[i].File || problem.Line != expected[i].Line || problem.Column != expected[i].Column {
+			t.Errorf("Expected a problem in line %d and column '%s', got %v.", expected[i].Line, expected[i].Column, problem)
+		}
+	}
+
+	invocations, err := parseInvocationTrace(path, 0, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*invocations) != 1 || (*invocations)[0].HashFunction != "f1" {
+		t.Errorf("Expected only the valid row to be parsed, got %v.", *invocations)
+	}
+
+	skipped := newRowProblems(true)
+	skipped.add(&ParseError{File: path, Line: 3, Column: "1", Err: errors.New("invalid")})
+	skipped.add(&ParseError{File: path, Line: 3, Column: "2", Err: errors.New("invalid")})
+	skipped.add(&ParseError{File: path, Line: 4, Err: errors.New("invalid")})
+	if len(skipped.lines) != 2 || skipped.err() != nil {
+		t.Errorf("Expected two skipped rows, got %d.", len(skipped.lines))
+	}
+}
+
+func TestParseMissingStats(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"invocations.csv": {
+			"HashOwner,HashApp,HashFunction,Trigger,1",
+			"o1,a1,f1,http,1",
+			"o1,a1,f2,http,1",
+			"o1,a1,f3,http,1",
+		},
+		"durations.csv": {
+			"HashOwner,HashApp,HashFunction,Average,Count,Minimum,Maximum,percentile_Average_0,percentile_Average_1," +
+				"percentile_Average_25,percentile_Average_50,percentile_Average_75,percentile_Average_99,percentile_Average_100",
+			"o1,a1,f1,10,1,10,10,10,10,10,10,10,10,10",
+			"o1,a1,f3,10,1,10,10,10,10,10,10,10,10,10",
+			"o1,a1,f4,ten,1,10,10,10,10,10,10,10,10,10",
+		},
+		"memory.csv": {
+			"HashOwner,HashApp,HashFunction,SampleCount,AverageAllocatedMb,AverageAllocatedMb_pct1,AverageAllocatedMb_pct5," +
+				"AverageAllocatedMb_pct25,AverageAllocatedMb_pct50,AverageAllocatedMb_pct75,AverageAllocatedMb_pct95," +
+				"AverageAllocatedMb_pct99,AverageAllocatedMb_pct100",
+			"o1,a1,f1,1,128,128,128,128,128,128,128,128,128",
+			"o1,a1,f2,1,128,128,128,128,128,128,128,128,128",
+		},
+	})
+
+	_, err := NewAzureParser(directory, 1).Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Line != 4 || parseError.Column != "Average" {
+		t.Fatalf("Expected the invalid runtime of f4, got %v.", err)
+	}
+
+	functions, err := NewAzureParser(directory, 1).WithSkipInvalidRows(true).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 1 || functions[0].InvocationStats.HashFunction != "f1" {
+		t.Errorf("Expected only the function with all the statistics, got %d functions.", len(functions))
+	}
+
+	writeTraceFiles(t, directory, map[string][]string{
+		"durations.csv": {
+			"HashOwner,HashApp,HashFunction,Average",
+			"o1,a1,f1,10",
+			"o1,a1,f3,10",
+		},
+	})
+
+	_, err = NewAzureParser(directory, 1).Parse()
+
+	var missing MissingStatsErrors
+	if !errors.As(err, &missing) || len(missing) != 2 {
+		t.Fatalf("Expected functions missing from both statistics files, got %v.", err)
+	}
+	if !reflect.DeepEqual(missing[0].Functions, []string{"f2"}) || missing[0].File != directory+"/durations.csv" {
+		t.Errorf("Unexpected functions missing from the runtime trace: %v.", missing[0])
+	}
+	if !reflect.DeepEqual(missing[1].Functions, []string{"f3"}) || missing[1].File != directory+"/memory.csv" {
+		t.Errorf("Unexpected functions missing from the memory trace: %v.", missing[1])
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gocarina/gocsv"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// WriteAzureTrace writes the functions as an Azure trace directory, i.e., invocations.csv, durations.csv and
+// memory.csv with the headers expected by AzureTraceParser, and dirigent.json if any function carries Dirigent
+// metadata.
+func WriteAzureTrace(directoryPath string, functions []*common.Function) error {
+	return writeAzureTraceFiles(directoryPath, "", functions)
+}
+
+// WriteAzureTraceDay writes the functions as a single day of a multi-day trace, i.e., invocations_dNN.csv,
+// durations_dNN.csv and memory_dNN.csv, with days numbered from 1
+func WriteAzureTraceDay(directoryPath string, day int, functions []*common.Function) error {
+	return writeAzureTraceFiles(directoryPath, fmt.Sprintf("_d%02d", day), functions)
+}
+
+func writeAzureTraceFiles(directoryPath string, suffix string, functions []*common.Function) error {
+	if err := os.MkdirAll(directoryPath, 0755); err != nil {
+		return err
+	}
+
+	if err := writeInvocationTrace(filepath.Join(directoryPath, "invocations"+suffix+".csv"), functions); err != nil {
+		return err
+	}
+
+	runtime := make([]*common.FunctionRuntimeStats, 0, len(functions))
+	memory := make([]*common.FunctionMemoryStats, 0, len(functions))
+	var dirigentMetadata []*common.DirigentMetadata
+
+	for _, function := range functions {
+		if function.RuntimeStats == nil || function.MemoryStats == nil {
+			return fmt.Errorf("function %s has no runtime or memory statistics", function.Name)
+		}
+
+		runtime = append(runtime, function.RuntimeStats)
+		memory = append(memory, function.MemoryStats)
+
+		if function.DirigentMetadata != nil {
+			dirigentMetadata = append(dirigentMetadata, function.DirigentMetadata)
+		}
+	}
+
+	if err := writeCSV(filepath.Join(directoryPath, "durations"+suffix+".csv"), &runtime); err != nil {
+		return err
+	}
+	if err := writeCSV(filepath.Join(directoryPath, "memory"+suffix+".csv"), &memory); err != nil {
+		return err
+	}
+
+	if len(dirigentMetadata) > 0 {
+		data, err := json.MarshalIndent(dirigentMetadata, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(filepath.Join(directoryPath, "dirigent.json"), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeInvocationTrace(path string, functions []*common.Function) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	duration := 0
+	for _, function := range functions {
+		duration = common.MaxOf(duration, len(function.InvocationStats.Invocations))
+	}
+
+	writer := csv.NewWriter(f)
+
+	header := []string{"HashOwner", "HashApp", "HashFunction", "Trigger"}
+	for minute := 1; minute <= duration; minute++ {
+		header = append(header, strconv.Itoa(minute))
+	}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, function := range functions {
+		stats := function.InvocationStats
+
+		record := []string{stats.HashOwner, stats.HashApp, stats.HashFunction, stats.Trigger}
+		for minute := 0; minute < duration; minute++ {
+			count := 0
+			if minute < len(stats.Invocations) {
+				count = stats.Invocations[minute]
+			}
+
+			record = append(record, strconv.Itoa(count))
+		}
+
+		if err = writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func writeCSV(path string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gocsv.MarshalFile(data, f)
+}
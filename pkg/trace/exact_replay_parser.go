@@ -0,0 +1,279 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// ExactReplayParser reads a per-invocation trace, i.e., one row per invocation with its arrival timestamp and duration,
+// and builds the specification of every function directly from the trace instead of generating IATs and runtimes from
+// per-minute statistics. Timestamps and durations are in seconds, counted from the beginning of the trace.
+type ExactReplayParser struct {
+	Path string
+
+	duration              int
+	startMinute           int
+	functionNameGenerator *rand.Rand
+}
+
+func NewExactReplayParser(path string, totalDuration int) *ExactReplayParser {
+	return &ExactReplayParser{
+		Path: path,
+
+		duration:              totalDuration,
+		functionNameGenerator: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// WithStartMinute makes the parser replay the trace window starting at the given minute of the trace
+func (p *ExactReplayParser) WithStartMinute(startMinute int) *ExactReplayParser {
+	if startMinute < 0 {
+		log.Fatalf("Trace start minute must not be negative, got %d.", startMinute)
+	}
+
+	p.startMinute = startMinute
+
+	return p
+}
+
+type replayedInvocation struct {
+	start    float64
+	duration float64
+	memory   float64
+}
+
+type replayedFunction struct {
+	app, function string
+	invocations   []replayedInvocation
+}
+
+func (p *ExactReplayParser) Parse() ([]*common.Function, error) {
+	replayed, err := p.readInvocations()
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := make(map[string]int)
+	for _, function := range replayed {
+		occurrences[function.function]++
+	}
+
+	var result []*common.Function
+	for _, function := range replayed {
+		// the loader identifies functions by their hash alone
+		if occurrences[function.function] > 1 {
+			function.function += "-" + function.app
+		}
+
+		f := p.buildFunction(len(result), function)
+		if f == nil {
+			log.Debugf("Function %s is not invoked within the trace window.", function.function)
+			continue
+		}
+
+		result = append(result, f)
+	}
+
+	log.Infof("Replaying %d out of %d functions of the trace.", len(result), len(replayed))
+
+	return result, nil
+}
+
+// readInvocations groups the invocations of the trace by function, keeping the order in which the functions first
+// appear in the trace
+func (p *ExactReplayParser) readInvocations() ([]*replayedFunction, error) {
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, &ParseError{File: p.Path, Line: 1, Err: err}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"app", "func", "duration"} {
+		if _, ok := columns[name]; !ok {
+			return nil, &ParseError{File: p.Path, Line: 1, Err: fmt.Errorf("missing column '%s'", name)}
+		}
+	}
+
+	// the arrival is given either directly or as the end of the invocation as in the Azure 2021 dataset
+	startColumn, hasStart := columns["start_timestamp"]
+	endColumn, hasEnd := columns["end_timestamp"]
+	if !hasStart && !hasEnd {
+		return nil, &ParseError{File: p.Path, Line: 1, Err: fmt.Errorf("missing column 'start_timestamp' or 'end_timestamp'")}
+	}
+	memoryColumn, hasMemory := columns["memory"]
+
+	var order []*replayedFunction
+	byKey := make(map[string]*replayedFunction)
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &ParseError{File: p.Path, Line: row, Err: err}
+		}
+
+		duration, err := parseNonNegativeFloat(record[columns["duration"]])
+		if err != nil {
+			return nil, &ParseError{File: p.Path, Line: row, Column: "duration", Err: err}
+		}
+
+		var start float64
+		if hasStart {
+			if start, err = strconv.ParseFloat(record[startColumn], 64); err != nil {
+				return nil, &ParseError{File: p.Path, Line: row, Column: "start_timestamp", Err: err}
+			}
+		} else {
+			if start, err = strconv.ParseFloat(record[endColumn], 64); err != nil {
+				return nil, &ParseError{File: p.Path, Line: row, Column: "end_timestamp", Err: err}
+			}
+			start -= duration
+		}
+
+		memory := float64(common.DefaultTraceMemoryMiB)
+		if hasMemory {
+			if memory, err = parseNonNegativeFloat(record[memoryColumn]); err != nil || memory == 0 {
+				return nil, &ParseError{File: p.Path, Line: row, Column: "memory", Err: fmt.Errorf("invalid memory '%s'", record[memoryColumn])}
+			}
+		}
+
+		app, function := record[columns["app"]], record[columns["func"]]
+
+		// function hashes are only unique within an application
+		key := app + "/" + function
+
+		replayed, ok := byKey[key]
+		if !ok {
+			replayed = &replayedFunction{app: app, function: function}
+			byKey[key] = replayed
+			order = append(order, replayed)
+		}
+
+		replayed.invocations = append(replayed.invocations, replayedInvocation{
+			start:    start,
+			duration: duration,
+			memory:   memory,
+		})
+	}
+
+	return order, nil
+}
+
+// buildFunction creates the specification of the invocations within the trace window. The runtime and memory
+// statistics used for deployment describe all the invocations of the function in the trace. Returns nil if the
+// function is not invoked within the window.
+func (p *ExactReplayParser) buildFunction(index int, replayed *replayedFunction) *common.Function {
+	invocations := replayed.invocations
+	sort.SliceStable(invocations, func(i, j int) bool {
+		return invocations[i].start < invocations[j].start
+	})
+
+	windowStart := float64(p.startMinute) * 60
+	windowEnd := windowStart + float64(p.duration)*60
+
+	spec := &common.FunctionSpecification{
+		PerMinuteCount: make([]int, p.duration),
+	}
+	durations := make([]float64, 0, len(invocations))
+	memory := make([]float64, 0, len(invocations))
+
+	previous := windowStart
+	for _, invocation := range invocations {
+		durations = append(durations, invocation.duration*1000)
+		memory = append(memory, invocation.memory)
+
+		if invocation.start < windowStart || invocation.start >= windowEnd {
+			continue
+		}
+
+		spec.IAT = append(spec.IAT, (invocation.start-previous)*common.OneSecondInMicroseconds)
+		spec.PerMinuteCount[int((invocation.start-windowStart)/60)]++
+		previous = invocation.start
+
+		// the functions are invoked with whole milliseconds, as with the continuous execution spec samplers
+		runtimeMicroseconds := common.MinOf(common.MaxOf(int(math.Round(invocation.duration*common.OneSecondInMicroseconds)),
+			common.MinExecTimeMicro), common.MaxExecTimeMilli*1000)
+		spec.RuntimeSpecification = append(spec.RuntimeSpecification, common.RuntimeSpecification{
+			Runtime:             common.MaxOf(common.MinExecTimeMilli, int(math.Round(float64(runtimeMicroseconds)/1e3))),
+			Memory:              common.MinOf(common.MaxOf(int(math.Round(invocation.memory)), common.MinMemQuotaMib), common.MaxMemQuotaMib),
+			RuntimeMicroseconds: runtimeMicroseconds,
+		})
+	}
+
+	if len(spec.IAT) == 0 {
+		return nil
+	}
+
+	function := newTraceFunction(index, p.functionNameGenerator,
+		&common.FunctionInvocationStats{
+			HashOwner:    replayed.app,
+			HashApp:      replayed.app,
+			HashFunction: replayed.function,
+			Trigger:      "unknown",
+			Invocations:  append([]int(nil), spec.PerMinuteCount...),
+		},
+		sampleRuntimeStats(replayed.app, replayed.function, durations, nil),
+		sampleMemoryStats(replayed.app, replayed.function, memory, nil),
+	)
+	function.Specification = spec
+
+	return function
+}
+
+func parseNonNegativeFloat(value string) (float64, error) {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, err
+	} else if parsed < 0 || math.IsNaN(parsed) {
+		return 0, fmt.Errorf("negative value '%s'", value)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,133 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestExactReplayParser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invocations.csv")
+	rows := []string{
+		"app,func,end_timestamp,duration",
+		"a1,f1,30.5,0.5",   // starts at 30 s
+		"a1,f1,10.25,0.25", // starts at 10 s - out of order
+		"a1,f1,90,0.0000005",
+		"a2,f2,200,100", // starts at 100 s
+		"a3,f3,250,1",   // outside the window
+		"a1,f4,65,5",    // outside the window - starts at minute 1
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(rows, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	functions, err := NewExactReplayParser(path, 2).WithStartMinute(0).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 3 {
+		t.Fatalf("Expected 3 replayed functions, got %d.", len(functions))
+	}
+
+	f1 := functions[0]
+	if f1.InvocationStats.HashFunction != "f1" || f1.InvocationStats.HashApp != "a1" {
+		t.Fatalf("Unexpected first function %s/%s.", f1.InvocationStats.HashApp, f1.InvocationStats.HashFunction)
+	}
+
+	expectedIAT := common.IATArray{10e6, 20e6, 60e6 - 5e-1}
+	for i, iat := range f1.Specification.IAT {
+		if diff := iat - expectedIAT[i]; diff > 1 || diff < -1 {
+			t.Errorf("IAT %d - expected %f, got %f.", i, expectedIAT[i], iat)
+		}
+	}
+	if !reflect.DeepEqual(f1.Specification.PerMinuteCount, []int{2, 1}) {
+		t.Errorf("Unexpected per-minute count %v.", f1.Specification.PerMinuteCount)
+	}
+	if !reflect.DeepEqual(f1.InvocationStats.Invocations, []int{2, 1}) {
+		t.Errorf("Unexpected invocations %v.", f1.InvocationStats.Invocations)
+	}
+
+	expectedRuntime := common.RuntimeSpecificationArray{
+		{Runtime: 250, Memory: common.DefaultTraceMemoryMiB, RuntimeMicroseconds: 250_000},
+		{Runtime: 500, Memory: common.DefaultTraceMemoryMiB, RuntimeMicroseconds: 500_000},
+		{Runtime: common.MinExecTimeMilli, Memory: common.DefaultTraceMemoryMiB, RuntimeMicroseconds: 1},
+	}
+	if !reflect.DeepEqual(f1.Specification.RuntimeSpecification, expectedRuntime) {
+		t.Errorf("Unexpected runtime specification %v.", f1.Specification.RuntimeSpecification)
+	}
+
+	f2 := functions[1]
+	if f2.Specification.RuntimeSpecification[0].Runtime != common.MaxExecTimeMilli {
+		t.Errorf("Expected the runtime to be clamped to %d ms, got %d ms.", int(common.MaxExecTimeMilli), f2.Specification.RuntimeSpecification[0].Runtime)
+	}
+	if f2.RuntimeStats.Maximum != 100_000 {
+		t.Errorf("Expected the runtime statistics to contain the original runtime, got %f.", f2.RuntimeStats.Maximum)
+	}
+
+	if functions[2].InvocationStats.HashFunction != "f4" || !reflect.DeepEqual(functions[2].Specification.PerMinuteCount, []int{0, 1}) {
+		t.Errorf("Unexpected specification of the function starting in the second minute.")
+	}
+}
+
+func TestExactReplayParserStartMinute(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invocations.csv")
+	rows := []string{
+		"app,func,start_timestamp,duration,memory",
+		"a1,f1,30,1,100",
+		"a1,f1,130,1,300",
+		"a1,f1,190,1,200",
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(rows, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	functions, err := NewExactReplayParser(path, 2).WithStartMinute(2).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 1 {
+		t.Fatalf("Expected 1 replayed function, got %d.", len(functions))
+	}
+
+	spec := functions[0].Specification
+	if !reflect.DeepEqual(spec.IAT, common.IATArray{10e6, 60e6}) {
+		t.Errorf("Unexpected IATs %v.", spec.IAT)
+	}
+	if !reflect.DeepEqual(spec.PerMinuteCount, []int{1, 1}) {
+		t.Errorf("Unexpected per-minute count %v.", spec.PerMinuteCount)
+	}
+	if spec.RuntimeSpecification[0].Memory != 300 || spec.RuntimeSpecification[1].Memory != 200 {
+		t.Errorf("Unexpected memory of the replayed invocations %v.", spec.RuntimeSpecification)
+	}
+	if functions[0].MemoryStats.Percentile100 != 300 {
+		t.Errorf("Expected the memory statistics to describe the whole trace, got %f.", functions[0].MemoryStats.Percentile100)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// HuaweiTraceParser reads the per-minute function traces of Huawei Cloud. Each file of the trace directory contains
+// one row per minute and one column per function next to the 'day' and 'time' columns. The requests are taken from
+// requests_minute.csv, the average runtime in milliseconds from function_delay_minute.csv and the average memory usage
+// in MiB from the optional memory_usage_minute.csv.
+type HuaweiTraceParser struct {
+	DirectoryPath string
+
+	duration              int
+	startMinute           int
+	functionNameGenerator *rand.Rand
+}
+
+func NewHuaweiParser(directoryPath string, totalDuration int) *HuaweiTraceParser {
+	return &HuaweiTraceParser{
+		DirectoryPath: directoryPath,
+
+		duration:              totalDuration,
+		functionNameGenerator: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// WithStartMinute makes the parser read the trace window starting at the given minute of the trace
+func (p *HuaweiTraceParser) WithStartMinute(startMinute int) *HuaweiTraceParser {
+	p.startMinute = startMinute
+
+	return p
+}
+
+func (p *HuaweiTraceParser) Parse() ([]*common.Function, error) {
+	requestPath := filepath.Join(p.DirectoryPath, "requests_minute.csv")
+	delayPath := filepath.Join(p.DirectoryPath, "function_delay_minute.csv")
+	memoryPath := filepath.Join(p.DirectoryPath, "memory_usage_minute.csv")
+
+	log.Infof("Parsing Huawei trace %s (start: minute %d, duration: %d min)", p.DirectoryPath, p.startMinute, p.duration)
+
+	requests, err := readMinuteTable(requestPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, perMinute := range requests.values {
+		for i := range perMinute {
+			perMinute[i] = zeroIfNaN(perMinute[i])
+		}
+	}
+	if requests.minutes < p.startMinute+p.duration {
+		return nil, fmt.Errorf("%s contains %d minutes, which is shorter than the trace window [%d, %d)",
+			requestPath, requests.minutes, p.startMinute, p.startMinute+p.duration)
+	}
+
+	delays, err := readMinuteTable(delayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var memory *minuteTable
+	if _, err = os.Stat(memoryPath); err == nil {
+		if memory, err = readMinuteTable(memoryPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []*common.Function
+	for _, function := range requests.functions {
+		perMinute := requests.values[function]
+
+		invocations := make([]int, p.duration)
+		for i := range invocations {
+			invocations[i] = int(math.Round(perMinute[p.startMinute+i]))
+		}
+
+		runtimes, runtimeWeights, err := weightedByRequests(delays, delayPath, function, perMinute)
+		if err != nil {
+			return nil, err
+		} else if runtimes == nil {
+			log.Debugf("Function %s is never invoked in the trace.", function)
+			continue
+		}
+		runtimeStats := sampleRuntimeStats(function, function, runtimes, runtimeWeights)
+
+		memoryStats := constantMemoryStats(function, function, int(runtimeStats.Count), common.DefaultTraceMemoryMiB)
+		if memory != nil {
+			usage, usageWeights, err := weightedByRequests(memory, memoryPath, function, perMinute)
+			if err != nil {
+				return nil, err
+			}
+			memoryStats = sampleMemoryStats(function, function, usage, usageWeights)
+		}
+
+		result = append(result, newTraceFunction(len(result), p.functionNameGenerator,
+			&common.FunctionInvocationStats{
+				// the trace identifies functions by a single ID and contains no triggers
+				HashOwner:    function,
+				HashApp:      function,
+				HashFunction: function,
+				Trigger:      "unknown",
+				Invocations:  invocations,
+			},
+			runtimeStats,
+			memoryStats,
+		))
+	}
+
+	return result, nil
+}
+
+// weightedByRequests returns the values of a function in the minutes it has been invoked in, weighted by the number
+// of requests. Returns nil if the function is never invoked.
+func weightedByRequests(table *minuteTable, path string, function string, requests []float64) ([]float64, []float64, error) {
+	values, ok := table.values[function]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s contains no column for function %s", path, function)
+	}
+
+	var samples, weights []float64
+	invoked := false
+	for minute, count := range requests {
+		if count <= 0 {
+			continue
+		}
+		invoked = true
+
+		if minute < len(values) && !math.IsNaN(values[minute]) {
+			samples = append(samples, values[minute])
+			weights = append(weights, count)
+		}
+	}
+
+	if !invoked {
+		return nil, nil, nil
+	} else if len(samples) == 0 {
+		return nil, nil, fmt.Errorf("%s contains no values for function %s in the minutes it is invoked in", path, function)
+	}
+
+	return samples, weights, nil
+}
+
+// minuteTable holds a per-minute metric of every function. Empty cells are NaN.
+type minuteTable struct {
+	functions []string
+	values    map[string][]float64
+	minutes   int
+}
+
+// readMinuteTable reads a file with one row per minute and one column per function, ignoring the 'day' and 'time'
+// columns
+func readMinuteTable(path string) (*minuteTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, &ParseError{File: path, Line: 1, Err: err}
+	}
+
+	table := &minuteTable{values: make(map[string][]float64)}
+	columns := make(map[int]string)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(name) {
+		case "", "day", "time":
+			continue
+		}
+
+		if _, ok := table.values[name]; ok {
+			return nil, &ParseError{File: path, Line: 1, Column: name, Err: fmt.Errorf("duplicate function")}
+		}
+
+		columns[i] = name
+		table.functions = append(table.functions, name)
+		table.values[name] = nil
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &ParseError{File: path, Line: line, Err: err}
+		}
+
+		for i, function := range columns {
+			value := math.NaN()
+			if cell := strings.TrimSpace(record[i]); cell != "" {
+				if value, err = parseNonNegativeFloat(cell); err != nil {
+					return nil, &ParseError{File: path, Line: line, Column: function, Err: err}
+				}
+			}
+
+			table.values[function] = append(table.values[function], value)
+		}
+		table.minutes++
+	}
+
+	return table, nil
+}
+
+// zeroIfNaN treats an empty cell of a request count as no requests
+func zeroIfNaN(value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+
+	return value
+}
@@ -0,0 +1,143 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func writeTraceFiles(t *testing.T, directory string, files map[string][]string) {
+	for name, rows := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(strings.Join(rows, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHuaweiTraceParser(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"requests_minute.csv": {
+			"day,time,f1,f2,f3",
+			"0,0,10,0,0",
+			"0,60,30,,0",
+			"0,120,0,5,0",
+		},
+		"function_delay_minute.csv": {
+			"day,time,f1,f2,f3",
+			"0,0,100,,",
+			"0,60,200,,",
+			"0,120,,40,",
+		},
+		"memory_usage_minute.csv": {
+			"day,time,f1,f2,f3",
+			"0,0,64,,",
+			"0,60,128,,",
+			"0,120,,256,",
+		},
+	})
+
+	parser, err := NewTraceParser(ParserConfiguration{
+		Format:      common.HuaweiTraceFormat,
+		Path:        directory,
+		Duration:    2,
+		StartMinute: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// f3 is never invoked
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 functions, got %d.", len(parsed))
+	}
+
+	f1, f2 := parsed[0], parsed[1]
+	if f1.InvocationStats.HashFunction != "f1" || !reflect.DeepEqual(f1.InvocationStats.Invocations, []int{30, 0}) {
+		t.Errorf("Unexpected invocations of %s: %v.", f1.InvocationStats.HashFunction, f1.InvocationStats.Invocations)
+	}
+	if !reflect.DeepEqual(f2.InvocationStats.Invocations, []int{0, 5}) {
+		t.Errorf("Unexpected invocations of f2: %v.", f2.InvocationStats.Invocations)
+	}
+
+	// the statistics of the whole trace are weighted by the number of requests
+	if f1.RuntimeStats.Count != 40 || f1.RuntimeStats.Average != 175 || f1.RuntimeStats.Percentile50 != 200 {
+		t.Errorf("Unexpected runtime statistics %+v.", *f1.RuntimeStats)
+	}
+	if f1.MemoryStats.Percentile1 != 64 || f1.MemoryStats.Percentile100 != 128 {
+		t.Errorf("Unexpected memory statistics %+v.", *f1.MemoryStats)
+	}
+	if f2.RuntimeStats.Percentile50 != 40 || f2.MemoryStats.Percentile50 != 256 {
+		t.Errorf("Unexpected statistics of f2 %+v %+v.", *f2.RuntimeStats, *f2.MemoryStats)
+	}
+}
+
+func TestHuaweiTraceParserErrors(t *testing.T) {
+	directory := t.TempDir()
+	writeTraceFiles(t, directory, map[string][]string{
+		"requests_minute.csv": {
+			"day,time,f1",
+			"0,0,10",
+			"0,60,x",
+		},
+		"function_delay_minute.csv": {
+			"day,time,f1",
+			"0,0,100",
+			"0,60,100",
+		},
+	})
+
+	_, err := NewHuaweiParser(directory, 2).Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected a parse error, got %v.", err)
+	}
+	if parseError.File != filepath.Join(directory, "requests_minute.csv") || parseError.Line != 3 || parseError.Column != "f1" {
+		t.Errorf("Unexpected location of the parse error: %v.", parseError)
+	}
+
+	writeTraceFiles(t, directory, map[string][]string{
+		"requests_minute.csv": {
+			"day,time,f1,f2",
+			"0,0,10,1",
+		},
+	})
+
+	if _, err = NewHuaweiParser(directory, 1).Parse(); err == nil || !strings.Contains(err.Error(), "no column for function f2") {
+		t.Errorf("Expected an error about the missing runtime of f2, got %v.", err)
+	}
+}
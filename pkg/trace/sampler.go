@@ -0,0 +1,292 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+	"gonum.org/v1/gonum/stat"
+)
+
+// SamplerConfiguration describes the sample to derive from a trace. The size of the sample is given either directly
+// by FunctionCount or derived from TargetRPS. MemoryBudgetMiB, if set, caps the estimated memory footprint of the
+// sample regardless of how its size has been determined.
+type SamplerConfiguration struct {
+	FunctionCount   int
+	TargetRPS       float64
+	MemoryBudgetMiB float64
+
+	// Trials Number of random samples of the same size out of which the one closest to the original trace is kept
+	Trials int
+	Seed   int64
+}
+
+// SampleDistance is the two-sample Kolmogorov-Smirnov statistic between the sample and the original trace in the
+// dimensions the sampler preserves
+type SampleDistance struct {
+	InvocationRate float64 `json:"InvocationRate"`
+	Runtime        float64 `json:"Runtime"`
+	Memory         float64 `json:"Memory"`
+}
+
+func (sd SampleDistance) Mean() float64 {
+	return (sd.InvocationRate + sd.Runtime + sd.Memory) / 3
+}
+
+type SamplingReport struct {
+	OriginalFunctions int   `json:"OriginalFunctions"`
+	SampledFunctions  int   `json:"SampledFunctions"`
+	Seed              int64 `json:"Seed"`
+
+	OriginalRPS       float64 `json:"OriginalRPS"`
+	SampledRPS        float64 `json:"SampledRPS"`
+	OriginalMemoryMiB float64 `json:"OriginalMemoryMiB"`
+	SampledMemoryMiB  float64 `json:"SampledMemoryMiB"`
+
+	Distance SampleDistance `json:"Distance"`
+}
+
+// SampleTrace derives a sample from the Azure trace in tracePath and writes it, together with the sampling report,
+// as a new trace directory into outputPath
+func SampleTrace(tracePath string, outputPath string, duration int, cfg SamplerConfiguration) (*SamplingReport, error) {
+	functions, err := NewAzureParser(tracePath, duration).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(tracePath, "dirigent.json")); err == nil {
+		NewDirigentMetadataParser(tracePath, functions, "", "Dirigent").Parse()
+	}
+
+	sample, report, err := SampleFunctions(functions, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = WriteAzureTrace(outputPath, sample); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return report, os.WriteFile(filepath.Join(outputPath, "sampling_report.json"), data, 0644)
+}
+
+// SampleFunctions selects a subset of functions preserving the invocation rate, runtime and memory distributions of
+// the original trace. Functions are sorted by invocation rate and split into as many strata as there are functions
+// to sample, and one function is drawn from each stratum. Out of several such trials, the sample with the smallest
+// mean Kolmogorov-Smirnov distance to the original trace is returned.
+func SampleFunctions(functions []*common.Function, cfg SamplerConfiguration) ([]*common.Function, *SamplingReport, error) {
+	var candidates []*common.Function
+	for _, function := range functions {
+		if function.RuntimeStats == nil || function.MemoryStats == nil {
+			log.Warnf("Function %s has no runtime or memory statistics and will not be sampled.", function.Name)
+			continue
+		}
+
+		candidates = append(candidates, function)
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("trace contains no function with complete statistics")
+	}
+
+	// sort once by invocation rate, so that each stratum contains functions of similar popularity
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return averageRPS(candidates[i]) < averageRPS(candidates[j])
+	})
+
+	originalRPS, originalMemory := totalRPS(candidates), totalMemoryFootprint(candidates)
+
+	sampleSize, err := determineSampleSize(candidates, cfg, originalRPS, originalMemory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trials := common.MaxOf(cfg.Trials, 1)
+	gen := rand.New(rand.NewSource(cfg.Seed))
+
+	for ; sampleSize > 0; sampleSize-- {
+		var best []*common.Function
+		var bestDistance SampleDistance
+		bestScore := math.Inf(1)
+
+		for trial := 0; trial < trials; trial++ {
+			sample := stratifiedSample(candidates, sampleSize, gen)
+			if cfg.MemoryBudgetMiB > 0 && totalMemoryFootprint(sample) > cfg.MemoryBudgetMiB {
+				continue
+			}
+
+			distance := computeSampleDistance(candidates, sample)
+			score := distance.Mean()
+			if cfg.TargetRPS > 0 {
+				score += math.Abs(totalRPS(sample)-cfg.TargetRPS) / cfg.TargetRPS
+			}
+
+			if score < bestScore {
+				best, bestDistance, bestScore = sample, distance, score
+			}
+		}
+
+		if best == nil {
+			log.Debugf("No sample of %d functions fits into the memory budget.", sampleSize)
+			continue
+		}
+
+		return best, &SamplingReport{
+			OriginalFunctions: len(candidates),
+			SampledFunctions:  len(best),
+			Seed:              cfg.Seed,
+			OriginalRPS:       originalRPS,
+			SampledRPS:        totalRPS(best),
+			OriginalMemoryMiB: originalMemory,
+			SampledMemoryMiB:  totalMemoryFootprint(best),
+			Distance:          bestDistance,
+		}, nil
+	}
+
+	return nil, nil, fmt.Errorf("no sample fits into the memory budget of %.0f MiB", cfg.MemoryBudgetMiB)
+}
+
+func determineSampleSize(candidates []*common.Function, cfg SamplerConfiguration, originalRPS float64, originalMemory float64) (int, error) {
+	sampleSize := cfg.FunctionCount
+
+	if sampleSize <= 0 && cfg.TargetRPS > 0 {
+		if originalRPS == 0 {
+			return 0, fmt.Errorf("trace has no invocations, cannot sample for the target RPS")
+		}
+
+		// stratified sampling keeps the average rate per function, so the load scales with the sample size
+		sampleSize = int(math.Round(float64(len(candidates)) * cfg.TargetRPS / originalRPS))
+	}
+
+	if sampleSize <= 0 && cfg.MemoryBudgetMiB > 0 {
+		if originalMemory == 0 {
+			return 0, fmt.Errorf("trace has no memory usage, cannot sample for the memory budget")
+		}
+
+		sampleSize = int(math.Floor(float64(len(candidates)) * cfg.MemoryBudgetMiB / originalMemory))
+	}
+
+	if sampleSize <= 0 {
+		return 0, fmt.Errorf("sample size has to be set either directly, through the target RPS or the memory budget")
+	}
+
+	return common.MinOf(sampleSize, len(candidates)), nil
+}
+
+// stratifiedSample draws one function from each of the n equally-sized strata of the candidates sorted by rate
+func stratifiedSample(candidates []*common.Function, n int, gen *rand.Rand) []*common.Function {
+	sample := make([]*common.Function, 0, n)
+
+	for i := 0; i < n; i++ {
+		begin := i * len(candidates) / n
+		end := (i + 1) * len(candidates) / n
+
+		sample = append(sample, candidates[begin+gen.Intn(end-begin)])
+	}
+
+	return sample
+}
+
+func computeSampleDistance(original []*common.Function, sample []*common.Function) SampleDistance {
+	rate := func(f *common.Function) float64 { return averageRPS(f) }
+	runtime := func(f *common.Function) float64 { return f.RuntimeStats.Average }
+	memory := func(f *common.Function) float64 { return f.MemoryStats.Average }
+
+	return SampleDistance{
+		InvocationRate: ksDistance(original, sample, rate),
+		Runtime:        ksDistance(original, sample, runtime),
+		Memory:         ksDistance(original, sample, memory),
+	}
+}
+
+func ksDistance(original []*common.Function, sample []*common.Function, metric func(*common.Function) float64) float64 {
+	extract := func(functions []*common.Function) []float64 {
+		result := make([]float64, len(functions))
+		for i, f := range functions {
+			result[i] = metric(f)
+		}
+		sort.Float64s(result)
+
+		return result
+	}
+
+	return stat.KolmogorovSmirnov(extract(original), nil, extract(sample), nil)
+}
+
+// averageRPS returns the average number of invocations per second over the whole trace
+func averageRPS(function *common.Function) float64 {
+	invocations := function.InvocationStats.Invocations
+	if len(invocations) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, count := range invocations {
+		total += count
+	}
+
+	return float64(total) / float64(len(invocations)*60)
+}
+
+func totalRPS(functions []*common.Function) float64 {
+	result := 0.0
+	for _, function := range functions {
+		result += averageRPS(function)
+	}
+
+	return result
+}
+
+// memoryFootprint estimates the memory needed by the function at its peak, i.e., the maximum memory of an instance
+// times the number of instances needed to sustain the peak expected concurrency
+func memoryFootprint(function *common.Function) float64 {
+	peakConcurrency := 0.0
+	for minute := range function.InvocationStats.Invocations {
+		peakConcurrency = math.Max(peakConcurrency, profileConcurrency(function, minute))
+	}
+
+	return math.Max(1, math.Ceil(peakConcurrency)) * function.MemoryStats.Percentile100
+}
+
+func totalMemoryFootprint(functions []*common.Function) float64 {
+	result := 0.0
+	for _, function := range functions {
+		result += memoryFootprint(function)
+	}
+
+	return result
+}
@@ -0,0 +1,134 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func createSamplerTestFunctions(count int) []*common.Function {
+	var functions []*common.Function
+
+	for i := 0; i < count; i++ {
+		hash := fmt.Sprintf("hash-%d", i)
+		invocations := make([]int, 10)
+		for minute := range invocations {
+			invocations[minute] = (i % 20) * 6
+		}
+
+		functions = append(functions, &common.Function{
+			Name: fmt.Sprintf("%s-%d-0", common.FunctionNamePrefix, i),
+			InvocationStats: &common.FunctionInvocationStats{
+				HashOwner: "owner", HashApp: "app", HashFunction: hash, Trigger: "http",
+				Invocations: invocations,
+			},
+			RuntimeStats: &common.FunctionRuntimeStats{
+				HashOwner: "owner", HashApp: "app", HashFunction: hash,
+				Average: float64(100 + i), Count: 10, Percentile0: 1, Percentile1: 2, Percentile25: 50,
+				Percentile50: 100, Percentile75: 150, Percentile99: 200, Percentile100: 250,
+			},
+			MemoryStats: &common.FunctionMemoryStats{
+				HashOwner: "owner", HashApp: "app", HashFunction: hash,
+				Count: 10, Average: float64(128 + i%4*64), Percentile1: 100, Percentile5: 110, Percentile25: 120,
+				Percentile50: 130, Percentile75: 140, Percentile95: 150, Percentile99: 160, Percentile100: 170,
+			},
+		})
+	}
+
+	return functions
+}
+
+func TestSampleFunctions(t *testing.T) {
+	functions := createSamplerTestFunctions(200)
+
+	sample, report, err := SampleFunctions(functions, SamplerConfiguration{FunctionCount: 20, Trials: 8, Seed: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sample) != 20 || report.SampledFunctions != 20 || report.OriginalFunctions != 200 {
+		t.Errorf("Unexpected sample size %d.", len(sample))
+	}
+	if report.Distance.InvocationRate > 0.2 || report.Distance.Runtime > 0.2 || report.Distance.Memory > 0.2 {
+		t.Errorf("Sample is too far from the original trace: %+v.", report.Distance)
+	}
+
+	again, _, _ := SampleFunctions(functions, SamplerConfiguration{FunctionCount: 20, Trials: 8, Seed: 42})
+	for i := range sample {
+		if sample[i] != again[i] {
+			t.Fatal("Sampling is not deterministic for the same seed.")
+		}
+	}
+
+	sample, report, err = SampleFunctions(functions, SamplerConfiguration{TargetRPS: report.OriginalRPS / 4, Trials: 8, Seed: 42})
+	if err != nil || len(sample) != 50 {
+		t.Errorf("Sampling for the target RPS should select a quarter of the functions - %v.", err)
+	}
+
+	_, report, err = SampleFunctions(functions, SamplerConfiguration{FunctionCount: 100, MemoryBudgetMiB: 2000, Trials: 8, Seed: 42})
+	if err != nil || report.SampledMemoryMiB > 2000 {
+		t.Errorf("Sample does not fit into the memory budget - %v.", err)
+	}
+
+	for _, function := range functions {
+		function.MemoryStats.Percentile100 = 0
+	}
+	if _, _, err = SampleFunctions(functions, SamplerConfiguration{MemoryBudgetMiB: 2000, Trials: 8, Seed: 42}); err == nil {
+		t.Error("Sampling for a memory budget should fail for a trace without memory usage.")
+	}
+}
+
+func TestWriteAzureTrace(t *testing.T) {
+	functions := createSamplerTestFunctions(3)
+	directory := t.TempDir()
+
+	if err := WriteAzureTrace(directory, functions); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := NewAzureParser(directory, 10).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 3 {
+		t.Fatal("Unexpected number of functions read back.")
+	}
+
+	for i, function := range parsed {
+		original := functions[i]
+
+		if function.InvocationStats.HashFunction != original.InvocationStats.HashFunction ||
+			function.InvocationStats.Trigger != original.InvocationStats.Trigger ||
+			function.InvocationStats.Invocations[9] != original.InvocationStats.Invocations[9] ||
+			function.RuntimeStats == nil || !floatEqual(function.RuntimeStats.Average, original.RuntimeStats.Average) ||
+			function.MemoryStats == nil || !floatEqual(function.MemoryStats.Average, original.MemoryStats.Average) {
+
+			t.Errorf("Function %d has not been written correctly.", i)
+		}
+	}
+}
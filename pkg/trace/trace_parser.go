@@ -0,0 +1,211 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"github.com/vhive-serverless/loader/pkg/generator"
+)
+
+// TraceParser reads the functions of a trace together with their invocation, runtime and memory statistics
+type TraceParser interface {
+	Parse() ([]*common.Function, error)
+}
+
+// ParserConfiguration selects the trace and the window [StartMinute, StartMinute + Duration) minutes to parse
+type ParserConfiguration struct {
+	Format      common.TraceFormat
+	Path        string
+	Duration    int
+	StartMinute int
+
+	// SkipInvalidRows drops the invalid rows of an Azure trace with a warning instead of reporting them as an error
+	SkipInvalidRows bool
+}
+
+// NewTraceParser returns the parser of the trace of the given format
+func NewTraceParser(cfg ParserConfiguration) (TraceParser, error) {
+	if cfg.StartMinute < 0 {
+		return nil, fmt.Errorf("trace start minute must not be negative, got %d", cfg.StartMinute)
+	}
+
+	switch cfg.Format {
+	case common.AzureTraceFormat:
+		return NewAzureParser(cfg.Path, cfg.Duration).WithStartMinute(cfg.StartMinute).WithSkipInvalidRows(cfg.SkipInvalidRows), nil
+	case common.HuaweiTraceFormat:
+		return NewHuaweiParser(cfg.Path, cfg.Duration).WithStartMinute(cfg.StartMinute), nil
+	case common.AlibabaTraceFormat:
+		return NewAlibabaParser(cfg.Path, cfg.Duration).WithStartMinute(cfg.StartMinute), nil
+	default:
+		return nil, fmt.Errorf("unsupported trace format %d", cfg.Format)
+	}
+}
+
+// ParseError locates an invalid value in a trace file. Line is 1-based and includes the header, and Column is empty
+// if the problem concerns the whole line.
+type ParseError struct {
+	File   string
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("%s:%d: column '%s': %v", e.File, e.Line, e.Column, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// maxReportedProblems bounds the number of problems listed in the message of an error
+const maxReportedProblems = 20
+
+// ParseErrors holds all the invalid values found in a trace
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "%d problems in the trace:", len(e))
+	for i, err := range e {
+		if i == maxReportedProblems {
+			fmt.Fprintf(&message, "\n\t... and %d more", len(e)-maxReportedProblems)
+			break
+		}
+
+		fmt.Fprintf(&message, "\n\t%s", err)
+	}
+
+	return message.String()
+}
+
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// MissingStatsError lists the invoked functions that have no statistics in a runtime or memory trace file
+type MissingStatsError struct {
+	File      string
+	Functions []string
+}
+
+func (e *MissingStatsError) Error() string {
+	listed := e.Functions
+	if len(listed) > maxReportedProblems {
+		listed = append(listed[:maxReportedProblems:maxReportedProblems], fmt.Sprintf("... and %d more", len(e.Functions)-maxReportedProblems))
+	}
+
+	return fmt.Sprintf("%s contains no statistics of %d functions: %s", e.File, len(e.Functions), strings.Join(listed, ", "))
+}
+
+// MissingStatsErrors holds the functions missing from each statistics file
+type MissingStatsErrors []*MissingStatsError
+
+func (e MissingStatsErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e MissingStatsErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// rowProblems collects the invalid rows of a trace file. In the skip mode, the rows are only logged.
+type rowProblems struct {
+	skip     bool
+	problems ParseErrors
+	// lines Lines with problems, as a row can contain several invalid values
+	lines map[int]bool
+}
+
+func newRowProblems(skip bool) *rowProblems {
+	return &rowProblems{skip: skip, lines: make(map[int]bool)}
+}
+
+func (r *rowProblems) add(err *ParseError) {
+	if r.skip && !r.lines[err.Line] {
+		log.Warnf("Skipping invalid row - %v", err)
+	} else if r.skip {
+		log.Debugf("Another invalid value in the skipped row - %v", err)
+	}
+
+	r.lines[err.Line] = true
+	r.problems = append(r.problems, err)
+}
+
+// err returns all the problems found in the file unless they have been skipped
+func (r *rowProblems) err() error {
+	if r.skip && len(r.problems) > 0 {
+		log.Warnf("Skipped %d invalid rows of the trace.", len(r.lines))
+		return nil
+	} else if len(r.problems) == 0 {
+		return nil
+	}
+
+	return r.problems
+}
+
+// newTraceFunction names a function of a trace that contains no names of its own
+func newTraceFunction(index int, nameGenerator *rand.Rand, invocationStats *common.FunctionInvocationStats,
+	runtimeStats *common.FunctionRuntimeStats, memoryStats *common.FunctionMemoryStats) *common.Function {
+
+	return &common.Function{
+		Name: fmt.Sprintf("%s-%d-%d", common.FunctionNamePrefix, index, nameGenerator.Uint64()),
+
+		InvocationStats: invocationStats,
+		RuntimeStats:    runtimeStats,
+		MemoryStats:     memoryStats,
+
+		ColdStartBusyLoopMs: generator.ComputeBusyLoopPeriod(int(memoryStats.Percentile50)),
+	}
+}
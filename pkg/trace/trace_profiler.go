@@ -35,7 +35,7 @@ func DoStaticTraceProfiling(functions []*common.Function) {
 	for i := 0; i < len(functions); i++ {
 		f := functions[i]
 
-		f.InitialScale = int(math.Ceil(profileConcurrency(functions[i])))
+		f.InitialScale = int(math.Ceil(profileConcurrency(functions[i], 0)))
 		log.Debugf("Function %s initial scale will be %d.\n", f.Name, f.InitialScale)
 	}
 }
@@ -43,13 +43,7 @@ func DoStaticTraceProfiling(functions []*common.Function) {
 func ApplyResourceLimits(functions []*common.Function, CPULimit string) {
 	for i := 0; i < len(functions); i++ {
 		memoryPct100 := int(functions[i].MemoryStats.Percentile100)
-		var cpuShare int
-		switch CPULimit {
-		case "1vCPU":
-			cpuShare = 1000
-		case "GCP":
-			cpuShare = ConvertMemoryToCpu(memoryPct100)
-		}
+		cpuShare := cpuLimitMilli(CPULimit, memoryPct100)
 
 		functions[i].CPURequestsMilli = cpuShare / common.OvercommitmentRatio
 		functions[i].MemoryRequestsMiB = memoryPct100 / common.OvercommitmentRatio
@@ -57,6 +51,18 @@ func ApplyResourceLimits(functions []*common.Function, CPULimit string) {
 	}
 }
 
+// cpuLimitMilli returns the CPU limit of an instance with the given memory under the CPU limit mapping
+func cpuLimitMilli(CPULimit string, memory int) int {
+	switch CPULimit {
+	case common.CPULimit1vCPU:
+		return 1000
+	case common.CPULimitGCP:
+		return ConvertMemoryToCpu(memory)
+	default:
+		return 0
+	}
+}
+
 // ConvertMemoryToCpu Google Cloud Function conversion table used from https://cloud.google.com/functions/pricing
 func ConvertMemoryToCpu(memoryRequest int) int {
 	var cpuRequest float32
@@ -78,15 +84,41 @@ func ConvertMemoryToCpu(memoryRequest int) int {
 	return int(cpuRequest * 1000)
 }
 
-func profileConcurrency(function *common.Function) float64 {
-	IPM := function.InvocationStats.Invocations[0]
+// profileConcurrency estimates the average number of concurrently running invocations in the given minute
+func profileConcurrency(function *common.Function, minute int) float64 {
+	IPM := function.InvocationStats.Invocations[minute]
 
 	// Arrival rate - unit 1 s
 	rps := float64(IPM) / 60.0
 	// Processing rate = runtime_in_milli / 1000, assuming it can be process right away upon arrival.
-	processingRate := float64(function.RuntimeStats.Average) / 1000.0
+	processingRate := float64(runtimeStatsAt(function, minute).Average) / 1000.0
 	// Expected concurrency == the inventory (total #jobs in the system) of Little's law.
 	concurrency := rps * processingRate
 
 	return concurrency
 }
+
+// runtimeStatsAt returns the runtime statistics of the function in the given minute, which change between the days
+// of a multi-day trace
+func runtimeStatsAt(function *common.Function, minute int) *common.FunctionRuntimeStats {
+	stats := function.RuntimeStats
+	for _, period := range function.ExecutionStatsPeriods {
+		if period.FromMinute <= minute && period.RuntimeStats != nil {
+			stats = period.RuntimeStats
+		}
+	}
+
+	return stats
+}
+
+// memoryStatsAt is the memory counterpart of runtimeStatsAt
+func memoryStatsAt(function *common.Function, minute int) *common.FunctionMemoryStats {
+	stats := function.MemoryStats
+	for _, period := range function.ExecutionStatsPeriods {
+		if period.FromMinute <= minute && period.MemoryStats != nil {
+			stats = period.MemoryStats
+		}
+	}
+
+	return stats
+}
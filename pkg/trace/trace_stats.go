@@ -0,0 +1,302 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+	"gonum.org/v1/gonum/stat"
+)
+
+// popularityLevels are the shares of the most invoked functions reported in the popularity distribution
+var popularityLevels = []float64{0.01, 0.05, 0.1, 0.25, 0.5}
+
+// TraceStatistics summarizes the load a trace window puts on the cluster
+type TraceStatistics struct {
+	Functions        int `json:"Functions"`
+	InvokedFunctions int `json:"InvokedFunctions"`
+	Duration         int `json:"Duration"`
+
+	TotalInvocations int     `json:"TotalInvocations"`
+	AverageRPS       float64 `json:"AverageRPS"`
+	PeakRPS          float64 `json:"PeakRPS"`
+	PeakMinute       int     `json:"PeakMinute"`
+	PeakConcurrency  float64 `json:"PeakConcurrency"`
+
+	Popularity []PopularityShare  `json:"Popularity"`
+	Runtime    []PercentileSpread `json:"Runtime"`
+	Memory     []PercentileSpread `json:"Memory"`
+
+	Resources ResourceEstimate   `json:"Resources"`
+	Minutes   []MinuteStatistics `json:"Minutes"`
+}
+
+// PopularityShare is the share of all invocations going to the most invoked functions
+type PopularityShare struct {
+	TopFunctionsPercent float64 `json:"TopFunctionsPercent"`
+	Functions           int     `json:"Functions"`
+	InvocationShare     float64 `json:"InvocationShare"`
+}
+
+// PercentileSpread describes how a runtime or memory percentile of the trace varies across the functions
+type PercentileSpread struct {
+	Percentile string  `json:"Percentile"`
+	Minimum    float64 `json:"Minimum"`
+	Median     float64 `json:"Median"`
+	Maximum    float64 `json:"Maximum"`
+}
+
+// ResourceEstimate is the peak cluster capacity needed to run the trace window. Every function keeps at least one
+// instance, and the number of instances follows the expected concurrency.
+type ResourceEstimate struct {
+	CPULimit           string  `json:"CPULimit"`
+	PeakMemoryLimitMiB float64 `json:"PeakMemoryLimitMiB"`
+	PeakCPULimitMilli  float64 `json:"PeakCPULimitMilli"`
+	// requests are overcommitted by common.OvercommitmentRatio
+	PeakMemoryRequestMiB float64 `json:"PeakMemoryRequestMiB"`
+	PeakCPURequestMilli  float64 `json:"PeakCPURequestMilli"`
+}
+
+// MinuteStatistics is the load of a single minute of the trace window
+type MinuteStatistics struct {
+	Minute      int     `json:"Minute"`
+	Invocations int     `json:"Invocations"`
+	RPS         float64 `json:"RPS"`
+	Concurrency float64 `json:"Concurrency"`
+	Instances   int     `json:"Instances"`
+	MemoryMiB   float64 `json:"MemoryMiB"`
+	CPUMilli    float64 `json:"CPUMilli"`
+}
+
+// ComputeTraceStatistics profiles the functions of a trace window under the given CPU limit mapping
+func ComputeTraceStatistics(functions []*common.Function, CPULimit string) *TraceStatistics {
+	result := &TraceStatistics{
+		Functions: len(functions),
+		Resources: ResourceEstimate{CPULimit: CPULimit},
+	}
+
+	for _, function := range functions {
+		result.Duration = common.MaxOf(result.Duration, len(function.InvocationStats.Invocations))
+	}
+
+	result.Minutes = make([]MinuteStatistics, result.Duration)
+	for minute := range result.Minutes {
+		result.Minutes[minute].Minute = minute
+	}
+
+	totals := make([]float64, 0, len(functions))
+	for _, function := range functions {
+		total := 0
+		for minute, count := range function.InvocationStats.Invocations {
+			total += count
+
+			stats := &result.Minutes[minute]
+			stats.Invocations += count
+
+			instances := 1
+			if count > 0 && function.RuntimeStats != nil {
+				concurrency := profileConcurrency(function, minute)
+				stats.Concurrency += concurrency
+				instances = common.MaxOf(1, int(math.Ceil(concurrency)))
+			}
+			stats.Instances += instances
+
+			if memoryStats := memoryStatsAt(function, minute); memoryStats != nil {
+				memory := memoryStats.Percentile100
+				stats.MemoryMiB += float64(instances) * memory
+				stats.CPUMilli += float64(instances * cpuLimitMilli(CPULimit, int(memory)))
+			}
+		}
+
+		result.TotalInvocations += total
+		if total > 0 {
+			result.InvokedFunctions++
+		}
+		totals = append(totals, float64(total))
+	}
+
+	for i := range result.Minutes {
+		stats := &result.Minutes[i]
+		stats.RPS = float64(stats.Invocations) / 60
+
+		if stats.RPS > result.PeakRPS {
+			result.PeakRPS, result.PeakMinute = stats.RPS, stats.Minute
+		}
+		result.PeakConcurrency = math.Max(result.PeakConcurrency, stats.Concurrency)
+		result.Resources.PeakMemoryLimitMiB = math.Max(result.Resources.PeakMemoryLimitMiB, stats.MemoryMiB)
+		result.Resources.PeakCPULimitMilli = math.Max(result.Resources.PeakCPULimitMilli, stats.CPUMilli)
+	}
+	result.Resources.PeakMemoryRequestMiB = result.Resources.PeakMemoryLimitMiB / common.OvercommitmentRatio
+	result.Resources.PeakCPURequestMilli = result.Resources.PeakCPULimitMilli / common.OvercommitmentRatio
+
+	if result.Duration > 0 {
+		result.AverageRPS = float64(result.TotalInvocations) / float64(result.Duration*60)
+	}
+
+	result.Popularity = popularityDistribution(totals)
+	result.Runtime, result.Memory = percentileSpreads(functions)
+
+	return result
+}
+
+func popularityDistribution(totals []float64) []PopularityShare {
+	sort.Sort(sort.Reverse(sort.Float64Slice(totals)))
+
+	sum := 0.0
+	for _, total := range totals {
+		sum += total
+	}
+
+	var result []PopularityShare
+	for _, level := range popularityLevels {
+		n := common.MinOf(len(totals), int(math.Ceil(level*float64(len(totals)))))
+
+		top := 0.0
+		for _, total := range totals[:n] {
+			top += total
+		}
+
+		share := 0.0
+		if sum > 0 {
+			share = top / sum
+		}
+
+		result = append(result, PopularityShare{
+			TopFunctionsPercent: level * 100,
+			Functions:           n,
+			InvocationShare:     share,
+		})
+	}
+
+	return result
+}
+
+func percentileSpreads(functions []*common.Function) ([]PercentileSpread, []PercentileSpread) {
+	runtimePercentiles := map[string]func(*common.FunctionRuntimeStats) float64{
+		"average": func(s *common.FunctionRuntimeStats) float64 { return s.Average },
+		"p0":      func(s *common.FunctionRuntimeStats) float64 { return s.Percentile0 },
+		"p1":      func(s *common.FunctionRuntimeStats) float64 { return s.Percentile1 },
+		"p25":     func(s *common.FunctionRuntimeStats) float64 { return s.Percentile25 },
+		"p50":     func(s *common.FunctionRuntimeStats) float64 { return s.Percentile50 },
+		"p75":     func(s *common.FunctionRuntimeStats) float64 { return s.Percentile75 },
+		"p99":     func(s *common.FunctionRuntimeStats) float64 { return s.Percentile99 },
+		"p100":    func(s *common.FunctionRuntimeStats) float64 { return s.Percentile100 },
+	}
+	memoryPercentiles := map[string]func(*common.FunctionMemoryStats) float64{
+		"average": func(s *common.FunctionMemoryStats) float64 { return s.Average },
+		"p1":      func(s *common.FunctionMemoryStats) float64 { return s.Percentile1 },
+		"p5":      func(s *common.FunctionMemoryStats) float64 { return s.Percentile5 },
+		"p25":     func(s *common.FunctionMemoryStats) float64 { return s.Percentile25 },
+		"p50":     func(s *common.FunctionMemoryStats) float64 { return s.Percentile50 },
+		"p75":     func(s *common.FunctionMemoryStats) float64 { return s.Percentile75 },
+		"p95":     func(s *common.FunctionMemoryStats) float64 { return s.Percentile95 },
+		"p99":     func(s *common.FunctionMemoryStats) float64 { return s.Percentile99 },
+		"p100":    func(s *common.FunctionMemoryStats) float64 { return s.Percentile100 },
+	}
+
+	var runtime, memory []PercentileSpread
+	for _, name := range []string{"average", "p0", "p1", "p5", "p25", "p50", "p75", "p95", "p99", "p100"} {
+		if value, ok := runtimePercentiles[name]; ok {
+			var values []float64
+			for _, function := range functions {
+				if function.RuntimeStats != nil {
+					values = append(values, value(function.RuntimeStats))
+				}
+			}
+			runtime = appendSpread(runtime, name, values)
+		}
+
+		if value, ok := memoryPercentiles[name]; ok {
+			var values []float64
+			for _, function := range functions {
+				if function.MemoryStats != nil {
+					values = append(values, value(function.MemoryStats))
+				}
+			}
+			memory = appendSpread(memory, name, values)
+		}
+	}
+
+	return runtime, memory
+}
+
+func appendSpread(spreads []PercentileSpread, name string, values []float64) []PercentileSpread {
+	if len(values) == 0 {
+		return spreads
+	}
+
+	sort.Float64s(values)
+
+	return append(spreads, PercentileSpread{
+		Percentile: name,
+		Minimum:    values[0],
+		Median:     stat.Quantile(0.5, stat.Empirical, values, nil),
+		Maximum:    values[len(values)-1],
+	})
+}
+
+// WriteTable prints the statistics as human-readable tables
+func (s *TraceStatistics) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Functions\t%d\t(invoked: %d)\t\n", s.Functions, s.InvokedFunctions)
+	fmt.Fprintf(tw, "Duration\t%d\tmin\t\n", s.Duration)
+	fmt.Fprintf(tw, "Invocations\t%d\t\t\n", s.TotalInvocations)
+	fmt.Fprintf(tw, "Average RPS\t%.2f\t\t\n", s.AverageRPS)
+	fmt.Fprintf(tw, "Peak RPS\t%.2f\t(minute %d)\t\n", s.PeakRPS, s.PeakMinute)
+	fmt.Fprintf(tw, "Peak concurrency\t%.2f\t\t\n", s.PeakConcurrency)
+	fmt.Fprintf(tw, "Peak memory (%s)\t%.0f\tMiB limits, %.0f MiB requests\t\n", s.Resources.CPULimit,
+		s.Resources.PeakMemoryLimitMiB, s.Resources.PeakMemoryRequestMiB)
+	fmt.Fprintf(tw, "Peak CPU (%s)\t%.0f\tmilliCPU limits, %.0f milliCPU requests\t\n", s.Resources.CPULimit,
+		s.Resources.PeakCPULimitMilli, s.Resources.PeakCPURequestMilli)
+
+	fmt.Fprintf(tw, "\nTop functions\tFunctions\tInvocation share\t\n")
+	for _, share := range s.Popularity {
+		fmt.Fprintf(tw, "%.0f%%\t%d\t%.1f%%\t\n", share.TopFunctionsPercent, share.Functions, share.InvocationShare*100)
+	}
+
+	for _, spreads := range []struct {
+		title  string
+		values []PercentileSpread
+	}{{"Runtime [ms]", s.Runtime}, {"Memory [MiB]", s.Memory}} {
+		fmt.Fprintf(tw, "\n%s\tMinimum\tMedian\tMaximum\t\n", spreads.title)
+		for _, spread := range spreads.values {
+			fmt.Fprintf(tw, "%s\t%.1f\t%.1f\t%.1f\t\n", spread.Percentile, spread.Minimum, spread.Median, spread.Maximum)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nMinute\tInvocations\tRPS\tConcurrency\tInstances\tMemory [MiB]\tCPU [milli]\t\n")
+	for _, minute := range s.Minutes {
+		fmt.Fprintf(tw, "%d\t%d\t%.2f\t%.2f\t%d\t%.0f\t%.0f\t\n", minute.Minute, minute.Invocations, minute.RPS,
+			minute.Concurrency, minute.Instances, minute.MemoryMiB, minute.CPUMilli)
+	}
+
+	return tw.Flush()
+}
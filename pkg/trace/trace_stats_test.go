@@ -0,0 +1,109 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func TestComputeTraceStatistics(t *testing.T) {
+	functions := []*common.Function{
+		{
+			InvocationStats: &common.FunctionInvocationStats{Invocations: []int{60, 120, 0}},
+			RuntimeStats:    &common.FunctionRuntimeStats{Average: 2000, Percentile50: 2000},
+			MemoryStats:     &common.FunctionMemoryStats{Percentile50: 100, Percentile100: 256},
+			ExecutionStatsPeriods: []common.ExecutionStatsPeriod{
+				{FromMinute: 0},
+				// the runtime doubles in the last minute
+				{FromMinute: 2, RuntimeStats: &common.FunctionRuntimeStats{Average: 4000}},
+			},
+		},
+		{
+			InvocationStats: &common.FunctionInvocationStats{Invocations: []int{0, 60, 60}},
+			RuntimeStats:    &common.FunctionRuntimeStats{Average: 500, Percentile50: 400},
+			MemoryStats:     &common.FunctionMemoryStats{Percentile50: 300, Percentile100: 1024},
+		},
+		{
+			InvocationStats: &common.FunctionInvocationStats{Invocations: []int{0, 0, 0}},
+			RuntimeStats:    &common.FunctionRuntimeStats{Average: 100, Percentile50: 100},
+			MemoryStats:     &common.FunctionMemoryStats{Percentile50: 128, Percentile100: 128},
+		},
+	}
+
+	stats := ComputeTraceStatistics(functions, common.CPULimitGCP)
+
+	if stats.Functions != 3 || stats.InvokedFunctions != 2 || stats.Duration != 3 || stats.TotalInvocations != 300 {
+		t.Errorf("Unexpected totals %+v.", *stats)
+	}
+	if stats.PeakRPS != 3 || stats.PeakMinute != 1 {
+		t.Errorf("Expected the peak of 3 RPS in minute 1, got %f in minute %d.", stats.PeakRPS, stats.PeakMinute)
+	}
+
+	var concurrency []float64
+	var instances []int
+	for _, minute := range stats.Minutes {
+		concurrency = append(concurrency, minute.Concurrency)
+		instances = append(instances, minute.Instances)
+	}
+	if !reflect.DeepEqual(concurrency, []float64{2, 4.5, 0.5}) {
+		t.Errorf("Unexpected concurrency per minute %v.", concurrency)
+	}
+	if !reflect.DeepEqual(instances, []int{4, 6, 3}) {
+		t.Errorf("Unexpected instances per minute %v.", instances)
+	}
+
+	// minute 1: 4 instances of 256 MiB and 0.167 vCPU, 1 instance of 1024 MiB and 0.583 vCPU, 1 idle instance
+	if stats.Resources.PeakMemoryLimitMiB != 4*256+1024+128 || stats.Resources.PeakCPULimitMilli != 4*167+583+83 {
+		t.Errorf("Unexpected resource estimate %+v.", stats.Resources)
+	}
+
+	if stats.Popularity[0].Functions != 1 || stats.Popularity[0].InvocationShare != 0.6 {
+		t.Errorf("Unexpected share of the most popular function %+v.", stats.Popularity[0])
+	}
+
+	expectedRuntime := PercentileSpread{Percentile: "p50", Minimum: 100, Median: 400, Maximum: 2000}
+	found := false
+	for _, spread := range stats.Runtime {
+		if spread.Percentile == "p50" {
+			found = reflect.DeepEqual(spread, expectedRuntime)
+		}
+	}
+	if !found {
+		t.Errorf("Expected the runtime spread %+v, got %+v.", expectedRuntime, stats.Runtime)
+	}
+
+	var table bytes.Buffer
+	if err := stats.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "Peak RPS") {
+		t.Errorf("Unexpected table output:\n%s", table.String())
+	}
+}
@@ -0,0 +1,374 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+// TransformPipeline is a declarative list of transformations applied to the functions of a trace after parsing
+type TransformPipeline struct {
+	// Seed Seed of the random selection and of the stochastic rounding of scaled invocations
+	Seed  int64           `json:"Seed"`
+	Steps []TransformStep `json:"Steps"`
+}
+
+// TransformStep is a single transformation. Only the fields of its type are used.
+type TransformStep struct {
+	// Type One of 'filter', 'top', 'random', 'scale', 'shift', 'runtime', 'memory' or 'merge'
+	Type string `json:"Type"`
+
+	// Triggers, Owners and Apps Values of the functions kept by 'filter'. Empty lists match all the functions.
+	Triggers []string `json:"Triggers"`
+	Owners   []string `json:"Owners"`
+	Apps     []string `json:"Apps"`
+
+	// Count Number of the most invoked ('top') or randomly chosen ('random') functions to keep
+	Count int `json:"Count"`
+
+	// Factor Multiplier of the invocations of every minute ('scale')
+	Factor float64 `json:"Factor"`
+
+	// Minutes Number of minutes the invocations are moved later by, or earlier by if negative ('shift')
+	Minutes int `json:"Minutes"`
+
+	// Scale, Min and Max Multiplier of all the runtime or memory statistics, followed by clamping ('runtime',
+	// 'memory'). A zero Scale keeps the statistics, and a zero bound is not applied.
+	Scale float64 `json:"Scale"`
+	Min   float64 `json:"Min"`
+	Max   float64 `json:"Max"`
+
+	// TracePath and TraceFormat Trace whose functions are appended to the current ones ('merge'), read with the same
+	// window. The format is the one of the loader configuration by default.
+	TracePath   string `json:"TracePath"`
+	TraceFormat string `json:"TraceFormat"`
+}
+
+func ReadTransformPipeline(path string) (*TransformPipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipeline TransformPipeline
+	if err = json.Unmarshal(data, &pipeline); err != nil {
+		return nil, err
+	}
+
+	return &pipeline, pipeline.validate()
+}
+
+func (p *TransformPipeline) validate() error {
+	for i, step := range p.Steps {
+		var err error
+
+		switch step.Type {
+		case "filter":
+		case "top", "random":
+			if step.Count <= 0 {
+				err = fmt.Errorf("number of functions must be positive")
+			}
+		case "scale":
+			if step.Factor < 0 || math.IsNaN(step.Factor) {
+				err = fmt.Errorf("scaling factor must not be negative")
+			}
+		case "shift":
+		case "runtime", "memory":
+			if step.Scale < 0 || step.Min < 0 || step.Max < 0 || (step.Max > 0 && step.Min > step.Max) {
+				err = fmt.Errorf("scale and bounds must not be negative, and the lower bound must not exceed the upper one")
+			}
+		case "merge":
+			if step.TracePath == "" {
+				err = fmt.Errorf("trace path is missing")
+			} else if step.TraceFormat != "" {
+				_, err = common.ParseTraceFormat(step.TraceFormat)
+			}
+		default:
+			err = fmt.Errorf("unknown type '%s'", step.Type)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid transform step %d - %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Apply runs the pipeline over the functions. Traces to merge are parsed with the given configuration, with only the
+// path and format replaced.
+func (p *TransformPipeline) Apply(functions []*common.Function, parserConfiguration ParserConfiguration) ([]*common.Function, error) {
+	gen := rand.New(rand.NewSource(p.Seed))
+
+	for i, step := range p.Steps {
+		before := len(functions)
+
+		switch step.Type {
+		case "filter":
+			functions = filterFunctions(functions, step)
+		case "top":
+			functions = topFunctions(functions, step.Count)
+		case "random":
+			functions = randomFunctions(functions, step.Count, gen)
+		case "scale":
+			scaleInvocations(functions, step.Factor, gen)
+		case "shift":
+			shiftInvocations(functions, step.Minutes)
+		case "runtime":
+			transformRuntime(functions, step)
+		case "memory":
+			transformMemory(functions, step)
+		case "merge":
+			merged, err := parseMergedTrace(step, parserConfiguration)
+			if err != nil {
+				return nil, fmt.Errorf("transform step %d - %v", i+1, err)
+			}
+
+			functions = mergeFunctions(functions, merged)
+		}
+
+		log.Infof("Transform step %d (%s): %d -> %d functions.", i+1, step.Type, before, len(functions))
+	}
+
+	return functions, nil
+}
+
+func filterFunctions(functions []*common.Function, step TransformStep) []*common.Function {
+	matches := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+
+	var result []*common.Function
+	for _, function := range functions {
+		stats := function.InvocationStats
+		if matches(step.Triggers, stats.Trigger) && matches(step.Owners, stats.HashOwner) && matches(step.Apps, stats.HashApp) {
+			result = append(result, function)
+		}
+	}
+
+	return result
+}
+
+func totalInvocations(function *common.Function) int {
+	total := 0
+	for _, count := range function.InvocationStats.Invocations {
+		total += count
+	}
+
+	return total
+}
+
+// topFunctions keeps the most invoked functions in their original order
+func topFunctions(functions []*common.Function, count int) []*common.Function {
+	if count >= len(functions) {
+		return functions
+	}
+
+	ranked := make([]int, len(functions))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return totalInvocations(functions[ranked[i]]) > totalInvocations(functions[ranked[j]])
+	})
+
+	return keepFunctions(functions, ranked[:count])
+}
+
+// randomFunctions keeps a uniform random subset of the functions in their original order
+func randomFunctions(functions []*common.Function, count int, gen *rand.Rand) []*common.Function {
+	if count >= len(functions) {
+		return functions
+	}
+
+	return keepFunctions(functions, gen.Perm(len(functions))[:count])
+}
+
+func keepFunctions(functions []*common.Function, indices []int) []*common.Function {
+	sort.Ints(indices)
+
+	result := make([]*common.Function, 0, len(indices))
+	for _, index := range indices {
+		result = append(result, functions[index])
+	}
+
+	return result
+}
+
+// scaleInvocations multiplies the invocations of every minute, rounding the result up with the probability of its
+// fractional part so that the expected number of invocations is scaled exactly
+func scaleInvocations(functions []*common.Function, factor float64, gen *rand.Rand) {
+	for _, function := range functions {
+		invocations := make([]int, len(function.InvocationStats.Invocations))
+
+		for minute, count := range function.InvocationStats.Invocations {
+			scaled := float64(count) * factor
+			invocations[minute] = int(math.Floor(scaled))
+
+			if gen.Float64() < scaled-math.Floor(scaled) {
+				invocations[minute]++
+			}
+		}
+
+		function.InvocationStats.Invocations = invocations
+	}
+}
+
+// shiftInvocations moves the invocations in time, keeping the length of the trace window. Minutes shifted out of the
+// window are dropped, and the vacated ones have no invocations.
+func shiftInvocations(functions []*common.Function, minutes int) {
+	for _, function := range functions {
+		original := function.InvocationStats.Invocations
+		invocations := make([]int, len(original))
+
+		for minute, count := range original {
+			if target := minute + minutes; target >= 0 && target < len(invocations) {
+				invocations[target] = count
+			}
+		}
+
+		function.InvocationStats.Invocations = invocations
+	}
+}
+
+func scaleAndClamp(value float64, step TransformStep) float64 {
+	if step.Scale > 0 {
+		value *= step.Scale
+	}
+	if step.Min > 0 {
+		value = math.Max(value, step.Min)
+	}
+	if step.Max > 0 {
+		value = math.Min(value, step.Max)
+	}
+
+	return value
+}
+
+// transformRuntime scales and clamps the runtime statistics, including the ones of the periods of multi-day traces,
+// which may be shared between periods
+func transformRuntime(functions []*common.Function, step TransformStep) {
+	transformed := make(map[*common.FunctionRuntimeStats]bool)
+
+	for _, function := range functions {
+		stats := []*common.FunctionRuntimeStats{function.RuntimeStats}
+		for _, period := range function.ExecutionStatsPeriods {
+			stats = append(stats, period.RuntimeStats)
+		}
+
+		for _, s := range stats {
+			if s == nil || transformed[s] {
+				continue
+			}
+			transformed[s] = true
+
+			for _, value := range []*float64{&s.Average, &s.Minimum, &s.Maximum, &s.Percentile0, &s.Percentile1,
+				&s.Percentile25, &s.Percentile50, &s.Percentile75, &s.Percentile99, &s.Percentile100} {
+
+				*value = scaleAndClamp(*value, step)
+			}
+		}
+	}
+}
+
+// transformMemory is the memory counterpart of transformRuntime
+func transformMemory(functions []*common.Function, step TransformStep) {
+	transformed := make(map[*common.FunctionMemoryStats]bool)
+
+	for _, function := range functions {
+		stats := []*common.FunctionMemoryStats{function.MemoryStats}
+		for _, period := range function.ExecutionStatsPeriods {
+			stats = append(stats, period.MemoryStats)
+		}
+
+		for _, s := range stats {
+			if s == nil || transformed[s] {
+				continue
+			}
+			transformed[s] = true
+
+			for _, value := range []*float64{&s.Average, &s.Percentile1, &s.Percentile5, &s.Percentile25,
+				&s.Percentile50, &s.Percentile75, &s.Percentile95, &s.Percentile99, &s.Percentile100} {
+
+				*value = scaleAndClamp(*value, step)
+			}
+		}
+	}
+}
+
+func parseMergedTrace(step TransformStep, parserConfiguration ParserConfiguration) ([]*common.Function, error) {
+	parserConfiguration.Path = step.TracePath
+	if step.TraceFormat != "" {
+		parserConfiguration.Format, _ = common.ParseTraceFormat(step.TraceFormat)
+	}
+
+	parser, err := NewTraceParser(parserConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.Parse()
+}
+
+// mergeFunctions appends the functions of another trace. Function hashes appearing in both traces are suffixed, as
+// the loader identifies functions by their hash.
+func mergeFunctions(functions []*common.Function, merged []*common.Function) []*common.Function {
+	hashes := make(map[string]bool)
+	for _, function := range functions {
+		hashes[function.InvocationStats.HashFunction] = true
+	}
+
+	for _, function := range merged {
+		hash := function.InvocationStats.HashFunction
+		for i := 2; hashes[hash]; i++ {
+			hash = function.InvocationStats.HashFunction + "-" + strconv.Itoa(i)
+		}
+		hashes[hash] = true
+
+		if hash != function.InvocationStats.HashFunction {
+			function.InvocationStats.HashFunction = hash
+			if function.RuntimeStats != nil {
+				function.RuntimeStats.HashFunction = hash
+			}
+			if function.MemoryStats != nil {
+				function.MemoryStats.HashFunction = hash
+			}
+		}
+
+		functions = append(functions, function)
+	}
+
+	return functions
+}
@@ -0,0 +1,175 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2023 EASL and the vHive community
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vhive-serverless/loader/pkg/common"
+)
+
+func transformTestFunctions() []*common.Function {
+	newFunction := func(hash string, trigger string, invocations []int, runtime float64) *common.Function {
+		return &common.Function{
+			Name: hash,
+			InvocationStats: &common.FunctionInvocationStats{
+				HashOwner:    "owner",
+				HashApp:      "app-" + hash,
+				HashFunction: hash,
+				Trigger:      trigger,
+				Invocations:  invocations,
+			},
+			RuntimeStats: &common.FunctionRuntimeStats{HashFunction: hash, Average: runtime, Percentile100: runtime * 2},
+			MemoryStats:  &common.FunctionMemoryStats{HashFunction: hash, Average: 100, Percentile100: 200},
+		}
+	}
+
+	return []*common.Function{
+		newFunction("f1", "http", []int{1, 1, 1}, 100),
+		newFunction("f2", "timer", []int{10, 10, 10}, 20000),
+		newFunction("f3", "http", []int{5, 0, 5}, 50),
+		newFunction("f4", "queue", []int{0, 0, 0}, 10),
+	}
+}
+
+func hashesOf(functions []*common.Function) []string {
+	var hashes []string
+	for _, function := range functions {
+		hashes = append(hashes, function.InvocationStats.HashFunction)
+	}
+
+	return hashes
+}
+
+func TestTransformPipeline(t *testing.T) {
+	pipeline := &TransformPipeline{
+		Seed: 42,
+		Steps: []TransformStep{
+			{Type: "filter", Triggers: []string{"http", "timer"}},
+			{Type: "top", Count: 2},
+			{Type: "shift", Minutes: 1},
+			{Type: "runtime", Scale: 2, Max: 10000},
+			{Type: "memory", Min: 150},
+		},
+	}
+	if err := pipeline.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	functions, err := pipeline.Apply(transformTestFunctions(), ParserConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(hashesOf(functions), []string{"f2", "f3"}) {
+		t.Fatalf("Expected the two most invoked functions in trace order, got %v.", hashesOf(functions))
+	}
+	if !reflect.DeepEqual(functions[1].InvocationStats.Invocations, []int{0, 5, 0}) {
+		t.Errorf("Unexpected shifted invocations %v.", functions[1].InvocationStats.Invocations)
+	}
+	if functions[0].RuntimeStats.Average != 10000 || functions[1].RuntimeStats.Average != 100 || functions[1].RuntimeStats.Percentile100 != 200 {
+		t.Errorf("Unexpected scaled runtimes %+v %+v.", *functions[0].RuntimeStats, *functions[1].RuntimeStats)
+	}
+	if functions[0].MemoryStats.Average != 150 || functions[0].MemoryStats.Percentile100 != 200 {
+		t.Errorf("Unexpected clamped memory %+v.", *functions[0].MemoryStats)
+	}
+}
+
+func TestTransformScaleStochasticRounding(t *testing.T) {
+	function := transformTestFunctions()[1]
+	function.InvocationStats.Invocations = make([]int, 10_000)
+	for i := range function.InvocationStats.Invocations {
+		function.InvocationStats.Invocations[i] = 1
+	}
+
+	pipeline := &TransformPipeline{Seed: 1, Steps: []TransformStep{{Type: "scale", Factor: 1.5}}}
+	functions, err := pipeline.Apply([]*common.Function{function}, ParserConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := totalInvocations(functions[0])
+	if total < 14_700 || total > 15_300 {
+		t.Errorf("Expected about 15000 invocations after scaling, got %d.", total)
+	}
+	for _, count := range functions[0].InvocationStats.Invocations {
+		if count != 1 && count != 2 {
+			t.Fatalf("Expected 1 or 2 invocations per minute, got %d.", count)
+		}
+	}
+}
+
+func TestTransformRandomAndMerge(t *testing.T) {
+	directory := t.TempDir()
+	if err := WriteAzureTrace(directory, transformTestFunctions()[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	pipeline := &TransformPipeline{
+		Seed: 7,
+		Steps: []TransformStep{
+			{Type: "random", Count: 2},
+			{Type: "merge", TracePath: directory},
+		},
+	}
+
+	first, err := pipeline.Apply(transformTestFunctions(), ParserConfiguration{Path: "unused", Duration: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := pipeline.Apply(transformTestFunctions(), ParserConfiguration{Path: "unused", Duration: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 4 || !reflect.DeepEqual(hashesOf(first), hashesOf(second)) {
+		t.Fatalf("Expected the same 2 random and 2 merged functions with the same seed, got %v and %v.", hashesOf(first), hashesOf(second))
+	}
+
+	hashes := make(map[string]bool)
+	for _, hash := range hashesOf(first) {
+		if hashes[hash] {
+			t.Errorf("Function hash %s is duplicated after merging.", hash)
+		}
+		hashes[hash] = true
+	}
+}
+
+func TestReadTransformPipeline(t *testing.T) {
+	if _, err := ReadTransformPipeline("../../cmd/trace_transform.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	if err := os.WriteFile(path, []byte(`{"Steps": [{"Type": "top"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTransformPipeline(path); err == nil {
+		t.Error("Expected an error for a top step without a count.")
+	}
+}
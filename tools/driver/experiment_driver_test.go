@@ -259,9 +259,9 @@ func TestDriver_prepareLoader(t *testing.T) {
 			if err != nil {
 				log.Fatalf("Failed to get home directory: %s", err)
 			}
-			_, err = os.Stat(homedir + "/loader/iat0.json")
+			_, err = os.Stat(homedir + "/loader/workload_spec.jsonl.gz")
 			if err != nil {
-				t.Errorf("iat file %s does not exist: %s", "/loader/iat0.json", err)
+				t.Errorf("workload specification %s does not exist: %s", "/loader/workload_spec.jsonl.gz", err)
 			}
 		})
 	}
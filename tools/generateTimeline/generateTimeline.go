@@ -110,7 +110,10 @@ func generateLoad(outputFilename string, millisecondScale bool) {
 	writer := make(chan interface{}, 1000)
 
 	traceParser := trace.NewAzureParser(*tracePath, *duration)
-	functions := traceParser.Parse()
+	functions, err := traceParser.Parse()
+	if err != nil {
+		log.Fatalf("Failed to parse the trace - %v", err)
+	}
 
 	log.Infof("Traces contain the following %d functions:\n", len(functions))
 